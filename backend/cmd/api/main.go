@@ -11,8 +11,12 @@ import (
 	"time"
 
 	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/landmask"
 	"ocean-digital-twin/internal/server"
+	"ocean-digital-twin/internal/utils/detector"
 	"ocean-digital-twin/internal/utils/scheduler"
+
+	"github.com/paulmach/orb"
 )
 
 const (
@@ -21,6 +25,8 @@ const (
 	maxLat         = 41.46
 	maxLon         = 2.83
 	updateInterval = 24 * time.Hour
+	// webhookDrainTimeout is how long pending webhook deliveries may take on shutdown
+	webhookDrainTimeout = 30 * time.Second
 )
 
 func main() {
@@ -38,6 +44,11 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// load the land mask, cells on land are neither interpolated nor served when masked
+	if err := loadLandMask(ctx, dbService); err != nil {
+		logger.Error("Failed to load land mask", "err", err)
+	}
+
 	// init automatic data updater
 	updater := scheduler.NewUpdater(
 		dbService,
@@ -47,13 +58,14 @@ func main() {
 		minLon,
 		maxLat,
 		maxLon,
+		loadBloomRules(logger),
 	)
 
 	// start the updater in goroutine
 	go updater.Start(ctx)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, dbService, done, logger)
+	go gracefulShutdown(server, dbService, updater, done, logger)
 
 	logger.Info(fmt.Sprintf("Starting server on port:%v...\n", server.Addr))
 	err := server.ListenAndServe()
@@ -66,7 +78,35 @@ func main() {
 	logger.Info("Graceful shutdown complete.")
 }
 
-func gracefulShutdown(apiServer *http.Server, dbService database.Service, done chan bool, logger *slog.Logger) {
+// loadLandMask saves the bundled coastline, or the GeoJSON file set in LAND_MASK_FILE, to the database.
+func loadLandMask(ctx context.Context, dbService database.Service) error {
+	load := landmask.Load
+	if path := os.Getenv("LAND_MASK_FILE"); path != "" {
+		load = func() (orb.MultiPolygon, error) { return landmask.LoadFile(path) }
+	}
+	mask, err := load()
+	if err != nil {
+		return err
+	}
+	return dbService.SaveLandMask(ctx, landmask.Name, mask)
+}
+
+// loadBloomRules returns the bloom detection rules of the JSON file set in BLOOM_RULES_FILE,
+// or the default rules if it isn't set or can't be read.
+func loadBloomRules(logger *slog.Logger) detector.Config {
+	path := os.Getenv("BLOOM_RULES_FILE")
+	if path == "" {
+		return detector.DefaultConfig()
+	}
+	config, err := detector.LoadConfig(path)
+	if err != nil {
+		logger.Error("Failed to load bloom rules, using the default rules", "err", err)
+		return detector.DefaultConfig()
+	}
+	return config
+}
+
+func gracefulShutdown(apiServer *http.Server, dbService database.Service, updater *scheduler.Updater, done chan bool, logger *slog.Logger) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -84,6 +124,13 @@ func gracefulShutdown(apiServer *http.Server, dbService database.Service, done c
 		logger.Info("Server forced to shutdown with error", "err", err)
 	}
 
+	// pending webhook deliveries are recorded in the database, so they finish before it's closed
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), webhookDrainTimeout)
+	defer drainCancel()
+	if err := updater.Shutdown(drainCtx); err != nil {
+		logger.Warn("Cancelled pending webhook deliveries", "err", err)
+	}
+
 	if dbService != nil {
 		if err := dbService.Close(); err != nil {
 			logger.Error("Error closing database connection", "err", err)
@@ -0,0 +1,70 @@
+// Command climatology imports long-term archives into the climatology of a dataset, so
+// anomalies can be computed before the updater has collected a year of data.
+//
+//	go run ./cmd/climatology -dataset chlorophyll archive-2015.csv archive-2016.csv
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/datasets"
+	"ocean-digital-twin/internal/utils/climatology"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	name := flag.String("dataset", "chlorophyll", "name of the dataset the archives hold")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-dataset name] archive.csv...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	ds, ok := datasets.Get(*name)
+	if !ok {
+		logger.Error("Unknown dataset", "dataset", *name)
+		os.Exit(2)
+	}
+
+	os.Exit(run(logger, ds, flag.Args()))
+}
+
+// run imports the archives at paths in order and returns the exit code, stopping at the first failure.
+func run(logger *slog.Logger, ds *datasets.Dataset, paths []string) int {
+	dbService := database.New()
+	defer dbService.Close()
+	if err := dbService.Up(); err != nil {
+		logger.Error("Failed to run migrations", "err", err)
+		return 1
+	}
+
+	builder := climatology.NewBuilder(dbService, logger)
+	for _, path := range paths {
+		if err := importFile(context.Background(), builder, ds, path); err != nil {
+			logger.Error("Failed to import archive", "file", path, "err", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// importFile adds the archive at path to the climatology of the dataset.
+func importFile(ctx context.Context, builder *climatology.Builder, ds *datasets.Dataset, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = builder.Import(ctx, ds, file)
+	return err
+}
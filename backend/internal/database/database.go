@@ -2,16 +2,17 @@ package database
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 	"log/slog"
 	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
 	"os"
 	"strconv"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/paulmach/orb"
 	"github.com/pressly/goose/v3"
@@ -20,28 +21,40 @@ import (
 // Service represents a service that interacts with a database.
 type Service interface {
 	// Operations
-	SaveChlorophyllData(ctx context.Context, data []models.ChlorophyllData) error
-	SaveChlorophyllDataRaw(ctx context.Context, data []models.ChlorophyllData) error
-	GetChlorophyllData(ctx context.Context, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, rawData bool) ([]models.ChlorophyllData, error)
-	GetLatestChlorophyllTimestamp(ctx context.Context) (time.Time, error)
-	GetAllChlorophyllLocations(ctx context.Context) ([]orb.Point, error)
-	GetChlorophyllDataAtLocation(ctx context.Context, point orb.Point) ([]models.ChlorophyllData, error)
-	GetChlorophyllDataAtTimestamp(ctx context.Context, timestamp time.Time) ([][]models.ChlorophyllData, error)
-	GetAllChlorophyllTimestamps(ctx context.Context) ([]time.Time, error)
-	UpdateChlorophyllData(ctx context.Context, data []models.ChlorophyllData) error
-
-	SaveCurrentsData(ctx context.Context, data []models.CurrentsData) error
-	SaveCurrentsDataRaw(ctx context.Context, data []models.CurrentsData) error
-	GetLatestCurrentsTimestamp(ctx context.Context) (time.Time, error)
-	GetCurrentsData(ctx context.Context, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, rawData bool) ([]models.CurrentsData, error)
-	GetAllCurrentsLocations(ctx context.Context) ([]orb.Point, error)
-	GetUCurrentsDataAtLocation(ctx context.Context, point orb.Point) ([]models.UCurrentsData, error)
-	GetVCurrentsDataAtLocation(ctx context.Context, point orb.Point) ([]models.VCurrentsData, error)
-	UpdateUCurrentsData(ctx context.Context, data []models.UCurrentsData) error
-	UpdateVCurrentsData(ctx context.Context, data []models.VCurrentsData) error
-	GetAllCurrentsTimestamps(ctx context.Context) ([]time.Time, error)
-	GetVCurrentDataAtTimestamp(ctx context.Context, timestamp time.Time) ([][]models.VCurrentsData, error)
-	GetUCurrentDataAtTimestamp(ctx context.Context, timestamp time.Time) ([][]models.UCurrentsData, error)
+	IngestDatasetData(ctx context.Context, ds *datasets.Dataset, data []models.GridData) (models.IngestionRun, error)
+	GetDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.GridData, error)
+	StreamDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool, fn func(models.GridData) error) error
+	GetLatestDatasetTimestamp(ctx context.Context, ds *datasets.Dataset) (time.Time, error)
+	GetAllDatasetLocations(ctx context.Context, ds *datasets.Dataset) ([]orb.Point, error)
+	GetDatasetDataAtLocation(ctx context.Context, ds *datasets.Dataset, point orb.Point) ([]models.GridData, error)
+	GetDatasetDataAtTimestamp(ctx context.Context, ds *datasets.Dataset, timestamp time.Time) ([][]models.GridData, error)
+	GetDatasetTimeSeries(ctx context.Context, ds *datasets.Dataset, point orb.Point, startTime, endTime time.Time, rawData bool) ([]models.GridData, error)
+	GetAllDatasetTimestamps(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error)
+	AggregateDatasetData(ctx context.Context, ds *datasets.Dataset, period, stat string, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.AggregateData, error)
+	UpdateDatasetData(ctx context.Context, ds *datasets.Dataset, data []models.GridData) error
+	CleanupDatasetData(ctx context.Context, ds *datasets.Dataset) error
+	SaveLandMask(ctx context.Context, name string, mask orb.MultiPolygon) error
+	GetDatasetLandLocations(ctx context.Context, ds *datasets.Dataset) ([]orb.Point, error)
+	SaveRegion(ctx context.Context, name string, area orb.MultiPolygon) (models.Region, error)
+	GetRegion(ctx context.Context, id int) (models.Region, error)
+	GetRegions(ctx context.Context) ([]models.Region, error)
+	DeleteRegion(ctx context.Context, id int) error
+	SaveBloomEvents(ctx context.Context, measurementTime time.Time, events []models.BloomEvent) ([]models.BloomEvent, error)
+	GetLatestBloomDetection(ctx context.Context) (time.Time, error)
+	GetBloomEvents(ctx context.Context, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rule string) ([]models.BloomEvent, error)
+	GetLatestClimatologyUpdate(ctx context.Context, ds *datasets.Dataset) (time.Time, error)
+	AccumulateClimatology(ctx context.Context, ds *datasets.Dataset, measurementTime time.Time) (int, error)
+	GetClimatologyTimes(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error)
+	AddClimatology(ctx context.Context, ds *datasets.Dataset, times []models.ClimatologyTime, sums []models.ClimatologySums) error
+	GetClimatology(ctx context.Context, ds *datasets.Dataset, days []int, windowDays int, minLat, minLon, maxLat, maxLon float64) ([]models.ClimatologyCell, error)
+	CreateWebhook(ctx context.Context, url string, events []string, secret string) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id int) (models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]models.Webhook, error)
+	GetWebhooksForEvent(ctx context.Context, event string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	CreateWebhookDelivery(ctx context.Context, webhookID int, event string, payload []byte) (models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error
+	GetWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error)
 
 	GetCount() int
 	UpdateCount(int) error
@@ -56,7 +69,7 @@ type Service interface {
 }
 
 type service struct {
-	db *sql.DB
+	db *pgxpool.Pool
 }
 
 var (
@@ -75,7 +88,7 @@ func New() Service {
 		return dbInstance
 	}
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sql.Open("pgx", connStr)
+	db, err := pgxpool.New(context.Background(), connStr)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -91,7 +104,11 @@ func (s *service) Up() error {
 		return err
 	}
 
-	if err := goose.Up(s.db, "internal/database/migrations"); err != nil {
+	// goose works on database/sql, so it gets a wrapper sharing the pool
+	db := stdlib.OpenDBFromPool(s.db)
+	defer db.Close()
+
+	if err := goose.Up(db, "internal/database/migrations"); err != nil {
 		slog.Error("Failed run migrations", "err", err)
 		return err
 	}
@@ -109,7 +126,7 @@ func (s *service) Health() map[string]string {
 	stats := make(map[string]string)
 
 	// Ping the database
-	err := s.db.PingContext(ctx)
+	err := s.db.Ping(ctx)
 	if err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
@@ -121,30 +138,30 @@ func (s *service) Health() map[string]string {
 	stats["status"] = "up"
 	stats["message"] = "It's healthy"
 
-	// Get database stats (like open connections, in use, idle, etc.)
-	dbStats := s.db.Stats()
-	stats["open_connections"] = strconv.Itoa(dbStats.OpenConnections)
-	stats["in_use"] = strconv.Itoa(dbStats.InUse)
-	stats["idle"] = strconv.Itoa(dbStats.Idle)
-	stats["wait_count"] = strconv.FormatInt(dbStats.WaitCount, 10)
-	stats["wait_duration"] = dbStats.WaitDuration.String()
-	stats["max_idle_closed"] = strconv.FormatInt(dbStats.MaxIdleClosed, 10)
-	stats["max_lifetime_closed"] = strconv.FormatInt(dbStats.MaxLifetimeClosed, 10)
+	// Get pool stats (like open connections, in use, idle, etc.)
+	dbStats := s.db.Stat()
+	stats["open_connections"] = strconv.Itoa(int(dbStats.TotalConns()))
+	stats["in_use"] = strconv.Itoa(int(dbStats.AcquiredConns()))
+	stats["idle"] = strconv.Itoa(int(dbStats.IdleConns()))
+	stats["wait_count"] = strconv.FormatInt(dbStats.EmptyAcquireCount(), 10)
+	stats["wait_duration"] = dbStats.EmptyAcquireWaitTime().String()
+	stats["max_idle_closed"] = strconv.FormatInt(dbStats.MaxIdleDestroyCount(), 10)
+	stats["max_lifetime_closed"] = strconv.FormatInt(dbStats.MaxLifetimeDestroyCount(), 10)
 
 	// Evaluate stats to provide a health message
-	if dbStats.OpenConnections > 40 { // Assuming 50 is the max for this example
+	if dbStats.TotalConns() > dbStats.MaxConns()*4/5 { // Assuming 80% of the pool size is heavy load
 		stats["message"] = "The database is experiencing heavy load."
 	}
 
-	if dbStats.WaitCount > 1000 {
+	if dbStats.EmptyAcquireCount() > 1000 {
 		stats["message"] = "The database has a high number of wait events, indicating potential bottlenecks."
 	}
 
-	if dbStats.MaxIdleClosed > int64(dbStats.OpenConnections)/2 {
+	if dbStats.MaxIdleDestroyCount() > int64(dbStats.TotalConns())/2 {
 		stats["message"] = "Many idle connections are being closed, consider revising the connection pool settings."
 	}
 
-	if dbStats.MaxLifetimeClosed > int64(dbStats.OpenConnections)/2 {
+	if dbStats.MaxLifetimeDestroyCount() > int64(dbStats.TotalConns())/2 {
 		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
 	}
 
@@ -157,5 +174,8 @@ func (s *service) Health() map[string]string {
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
 	log.Printf("Disconnected from database: %s", database)
-	return s.db.Close()
+	s.db.Close()
+	// the next call to New opens a new pool
+	dbInstance = nil
+	return nil
 }
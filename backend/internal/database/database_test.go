@@ -2,10 +2,18 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
 	"log"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -20,14 +28,14 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 
 	dbContainer, err := postgres.Run(
 		context.Background(),
-		"postgres:latest",
+		"postgis/postgis:15-3.5",
 		postgres.WithDatabase(dbName),
 		postgres.WithUsername(dbUser),
 		postgres.WithPassword(dbPwd),
 		testcontainers.WithWaitStrategy(
 			wait.ForLog("database system is ready to accept connections").
 				WithOccurrence(2).
-				WithStartupTimeout(5*time.Second)),
+				WithStartupTimeout(30*time.Second)),
 	)
 	if err != nil {
 		return nil, err
@@ -53,6 +61,88 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 	return dbContainer.Terminate, err
 }
 
+// cronMigrations schedule the baseline cleanups with pg_cron, which the postgis image lacks.
+var cronMigrations = map[string]bool{
+	"20250505103513_schedule_chllorophyll_data_cleanup.sql":    true,
+	"20250515152516_schedule_chlorophyll_data_raw_cleanup.sql": true,
+	"20250517211131_schedule_currents_data_cleanup.sql":        true,
+}
+
+// migrationsFS lists the migrations without cronMigrations.
+type migrationsFS struct {
+	fs.FS
+}
+
+func (f migrationsFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	var filtered []fs.DirEntry
+	for _, e := range entries {
+		if !cronMigrations[e.Name()] {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered, nil
+}
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// migrate applies the migrations once, so the tests run against the schema the application uses.
+func migrate(s *service) error {
+	migrateOnce.Do(func() {
+		goose.SetBaseFS(migrationsFS{os.DirFS("migrations")})
+		defer goose.SetBaseFS(nil)
+		if migrateErr = goose.SetDialect("postgres"); migrateErr != nil {
+			return
+		}
+		db := stdlib.OpenDBFromPool(s.db)
+		defer db.Close()
+		migrateErr = goose.Up(db, ".")
+	})
+	return migrateErr
+}
+
+// newTestDataset migrates the database and returns a copy of the registered dataset base named
+// name, stored in empty copies of the tables of base so tests don't see each other's rows.
+func newTestDataset(ctx context.Context, s *service, base, name string) (*datasets.Dataset, error) {
+	if err := migrate(s); err != nil {
+		return nil, err
+	}
+	registered, ok := datasets.Get(base)
+	if !ok {
+		return nil, fmt.Errorf("dataset %s is not registered", base)
+	}
+	ds := *registered
+	ds.Name = name
+	ds.Table = name + "_data"
+	ds.RawTable = name + "_data_raw"
+	for _, t := range [][2]string{{ds.Table, registered.Table}, {ds.RawTable, registered.RawTable}} {
+		_, err := s.db.Exec(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %[1]s; CREATE TABLE %[1]s (LIKE %[2]s INCLUDING ALL)", t[0], t[1]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ds, nil
+}
+
+// resetTables migrates the database and empties the tables shared by all datasets.
+func resetTables(ctx context.Context, s *service, tables ...string) error {
+	if err := migrate(s); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if _, err := s.db.Exec(ctx, "TRUNCATE "+table+" RESTART IDENTITY CASCADE"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func TestMain(m *testing.M) {
 	teardown, err := mustStartPostgresContainer()
 	if err != nil {
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS sst_data (
+    id SERIAL PRIMARY KEY,
+    measurement_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    location GEOGRAPHY(POINT, 4326) NOT NULL,
+    sst FLOAT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS sst_data_raw (
+    id SERIAL PRIMARY KEY,
+    measurement_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    location GEOGRAPHY(POINT, 4326) NOT NULL,
+    sst FLOAT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+-- Index for spatial queries
+CREATE INDEX IF NOT EXISTS sst_data_location_idx ON sst_data USING GIST(location);
+CREATE INDEX IF NOT EXISTS sst_data_raw_location_idx ON sst_data_raw USING GIST(location);
+
+-- Index for time-based queries
+CREATE INDEX IF NOT EXISTS sst_data_time_idx ON sst_data(measurement_time);
+CREATE INDEX IF NOT EXISTS sst_data_raw_time_idx ON sst_data_raw(measurement_time);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- Drop the indexes
+DROP INDEX IF EXISTS sst_data_time_idx;
+DROP INDEX IF EXISTS sst_data_raw_time_idx;
+DROP INDEX IF EXISTS sst_data_location_idx;
+DROP INDEX IF EXISTS sst_data_raw_location_idx;
+-- Drop the main table
+DROP TABLE IF EXISTS sst_data;
+DROP TABLE IF EXISTS sst_data_raw;
+
+-- +goose StatementEnd
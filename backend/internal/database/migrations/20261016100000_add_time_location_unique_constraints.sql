@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Remove duplicated rows keeping the most recently inserted one
+DELETE FROM chlorophyll_data a
+USING chlorophyll_data b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+DELETE FROM chlorophyll_data_raw a
+USING chlorophyll_data_raw b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+DELETE FROM currents_data a
+USING currents_data b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+DELETE FROM currents_data_raw a
+USING currents_data_raw b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+DELETE FROM sst_data a
+USING sst_data b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+DELETE FROM sst_data_raw a
+USING sst_data_raw b
+WHERE a.id < b.id
+    AND a.measurement_time = b.measurement_time
+    AND a.location = b.location;
+
+-- Only one value per location and time is allowed
+ALTER TABLE chlorophyll_data ADD CONSTRAINT chlorophyll_data_time_location_key UNIQUE (measurement_time, location);
+ALTER TABLE chlorophyll_data_raw ADD CONSTRAINT chlorophyll_data_raw_time_location_key UNIQUE (measurement_time, location);
+ALTER TABLE currents_data ADD CONSTRAINT currents_data_time_location_key UNIQUE (measurement_time, location);
+ALTER TABLE currents_data_raw ADD CONSTRAINT currents_data_raw_time_location_key UNIQUE (measurement_time, location);
+ALTER TABLE sst_data ADD CONSTRAINT sst_data_time_location_key UNIQUE (measurement_time, location);
+ALTER TABLE sst_data_raw ADD CONSTRAINT sst_data_raw_time_location_key UNIQUE (measurement_time, location);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE chlorophyll_data DROP CONSTRAINT IF EXISTS chlorophyll_data_time_location_key;
+ALTER TABLE chlorophyll_data_raw DROP CONSTRAINT IF EXISTS chlorophyll_data_raw_time_location_key;
+ALTER TABLE currents_data DROP CONSTRAINT IF EXISTS currents_data_time_location_key;
+ALTER TABLE currents_data_raw DROP CONSTRAINT IF EXISTS currents_data_raw_time_location_key;
+ALTER TABLE sst_data DROP CONSTRAINT IF EXISTS sst_data_time_location_key;
+ALTER TABLE sst_data_raw DROP CONSTRAINT IF EXISTS sst_data_raw_time_location_key;
+
+-- +goose StatementEnd
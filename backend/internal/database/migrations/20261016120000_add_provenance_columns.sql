@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Values are observed unless the interpolator filled them
+ALTER TABLE chlorophyll_data ADD COLUMN IF NOT EXISTS provenance TEXT NOT NULL DEFAULT 'observed';
+ALTER TABLE chlorophyll_data ADD COLUMN IF NOT EXISTS interpolation_method TEXT;
+
+ALTER TABLE currents_data ADD COLUMN IF NOT EXISTS provenance TEXT NOT NULL DEFAULT 'observed';
+ALTER TABLE currents_data ADD COLUMN IF NOT EXISTS interpolation_method TEXT;
+
+ALTER TABLE sst_data ADD COLUMN IF NOT EXISTS provenance TEXT NOT NULL DEFAULT 'observed';
+ALTER TABLE sst_data ADD COLUMN IF NOT EXISTS interpolation_method TEXT;
+
+-- Values filled before provenance was tracked differ from the raw tables,
+-- models.ProvenanceInterpolated marks them as interpolated by an unknown method
+UPDATE chlorophyll_data d
+SET provenance = 'interpolated'
+FROM chlorophyll_data_raw r
+WHERE d.measurement_time = r.measurement_time
+    AND d.location = r.location
+    AND r.chlor_a = 'NaN'
+    AND d.chlor_a <> 'NaN';
+
+UPDATE currents_data d
+SET provenance = 'interpolated'
+FROM currents_data_raw r
+WHERE d.measurement_time = r.measurement_time
+    AND d.location = r.location
+    AND (r.u_current = 'NaN' OR r.v_current = 'NaN')
+    AND d.u_current <> 'NaN'
+    AND d.v_current <> 'NaN';
+
+UPDATE sst_data d
+SET provenance = 'interpolated'
+FROM sst_data_raw r
+WHERE d.measurement_time = r.measurement_time
+    AND d.location = r.location
+    AND r.sst = 'NaN'
+    AND d.sst <> 'NaN';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE chlorophyll_data DROP COLUMN IF EXISTS provenance;
+ALTER TABLE chlorophyll_data DROP COLUMN IF EXISTS interpolation_method;
+ALTER TABLE currents_data DROP COLUMN IF EXISTS provenance;
+ALTER TABLE currents_data DROP COLUMN IF EXISTS interpolation_method;
+ALTER TABLE sst_data DROP COLUMN IF EXISTS provenance;
+ALTER TABLE sst_data DROP COLUMN IF EXISTS interpolation_method;
+
+-- +goose StatementEnd
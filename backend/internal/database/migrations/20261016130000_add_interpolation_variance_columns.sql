@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Estimation variance of values filled by kriging
+ALTER TABLE chlorophyll_data ADD COLUMN IF NOT EXISTS interpolation_variance FLOAT;
+ALTER TABLE currents_data ADD COLUMN IF NOT EXISTS interpolation_variance FLOAT;
+ALTER TABLE sst_data ADD COLUMN IF NOT EXISTS interpolation_variance FLOAT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE chlorophyll_data DROP COLUMN IF EXISTS interpolation_variance;
+ALTER TABLE currents_data DROP COLUMN IF EXISTS interpolation_variance;
+ALTER TABLE sst_data DROP COLUMN IF EXISTS interpolation_variance;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS land_mask (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    geom GEOMETRY(MULTIPOLYGON, 4326) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_land_mask_geom ON land_mask USING GIST(geom);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS land_mask;
+
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS bloom_events (
+    id SERIAL PRIMARY KEY,
+    measurement_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    rule TEXT NOT NULL,
+    geom GEOMETRY(MULTIPOLYGON, 4326) NOT NULL,
+    area_km2 DOUBLE PRECISION NOT NULL,
+    cells INTEGER NOT NULL,
+    mean_chlor_a DOUBLE PRECISION NOT NULL,
+    max_chlor_a DOUBLE PRECISION NOT NULL,
+    max_z_score DOUBLE PRECISION,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bloom_events_measurement_time ON bloom_events(measurement_time);
+CREATE INDEX IF NOT EXISTS idx_bloom_events_geom ON bloom_events USING GIST(geom);
+
+-- measurement times the bloom rules were evaluated at, including those without events
+CREATE TABLE IF NOT EXISTS bloom_detections (
+    measurement_time TIMESTAMP WITH TIME ZONE PRIMARY KEY,
+    events INTEGER NOT NULL,
+    detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS bloom_detections;
+DROP TABLE IF EXISTS bloom_events;
+
+-- +goose StatementEnd
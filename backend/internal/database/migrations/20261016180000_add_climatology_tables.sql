@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- running sums of the observed values of every variable per grid cell and day of the year,
+-- so the climatology keeps growing after the data itself is removed
+CREATE TABLE IF NOT EXISTS climatology (
+    dataset TEXT NOT NULL,
+    variable TEXT NOT NULL,
+    day_of_year SMALLINT NOT NULL CHECK (day_of_year BETWEEN 1 AND 365),
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    samples INTEGER NOT NULL,
+    sum DOUBLE PRECISION NOT NULL,
+    sum_squares DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (dataset, variable, latitude, longitude, day_of_year)
+);
+
+-- measurement times added to the climatology, so none is counted twice
+CREATE TABLE IF NOT EXISTS climatology_updates (
+    dataset TEXT NOT NULL,
+    measurement_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    samples INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (dataset, measurement_time)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS climatology_updates;
+DROP TABLE IF EXISTS climatology;
+
+-- +goose StatementEnd
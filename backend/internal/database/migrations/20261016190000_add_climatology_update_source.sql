@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- measurement times are recorded whether the updater accumulated them or an archive import
+-- added them, so neither counts a time the other already did
+ALTER TABLE climatology_updates ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'update'
+    CHECK (source IN ('update', 'import'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE climatology_updates DROP COLUMN IF EXISTS source;
+
+-- +goose StatementEnd
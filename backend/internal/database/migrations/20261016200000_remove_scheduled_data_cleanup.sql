@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- old data is removed by the updater using the retention of each dataset, the scheduled jobs
+-- called functions that don't exist and are dropped together with the functions they meant
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_cron') THEN
+        PERFORM cron.unschedule(jobname)
+        FROM cron.job
+        WHERE jobname IN (
+            'cleanup-chlorophyll-data',
+            'cleanup-chlorophyll-data-raw',
+            'cleanup-currents-data',
+            'cleanup-currents-data-raw'
+        );
+    END IF;
+END;
+$$;
+
+DROP FUNCTION IF EXISTS cleanup_old_chlorophyll_data();
+DROP FUNCTION IF EXISTS cleanup_old_chlorophyll_data_raw();
+DROP FUNCTION IF EXISTS cleanup_old_currents_data();
+DROP FUNCTION IF EXISTS cleanup_old_currents_data_raw();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE OR REPLACE FUNCTION cleanup_old_chlorophyll_data() RETURNS void AS $$
+BEGIN
+    DELETE FROM chlorophyll_data
+    WHERE measurement_time < (NOW() - INTERVAL '120 days');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION cleanup_old_chlorophyll_data_raw() RETURNS void AS $$
+BEGIN
+    DELETE FROM chlorophyll_data_raw
+    WHERE measurement_time < (NOW() - INTERVAL '120 days');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION cleanup_old_currents_data() RETURNS void AS $$
+BEGIN
+    DELETE FROM currents_data
+    WHERE measurement_time < (NOW() - INTERVAL '120 days');
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION cleanup_old_currents_data_raw() RETURNS void AS $$
+BEGIN
+    DELETE FROM currents_data_raw
+    WHERE measurement_time < (NOW() - INTERVAL '120 days');
+END;
+$$ LANGUAGE plpgsql;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_cron') THEN
+        PERFORM cron.schedule('cleanup-chlorophyll-data', '0 3 * * *', 'SELECT cleanup_chlorophyll_data()');
+        PERFORM cron.schedule('cleanup-chlorophyll-data-raw', '0 3 * * *', 'SELECT cleanup_chlorophyll_data_raw()');
+        PERFORM cron.schedule('cleanup-currents-data', '0 3 * * *', 'SELECT cleanup_currents_data()');
+        PERFORM cron.schedule('cleanup-currents-data-raw', '0 3 * * *', 'SELECT cleanup_currents_raw_data()');
+    END IF;
+END;
+$$;
+
+-- +goose StatementEnd
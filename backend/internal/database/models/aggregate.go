@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Periods grid cells are aggregated over, named as PostgreSQL date_trunc fields.
+// Weeks start on Monday.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Statistics computed for the grid cells of a period.
+const (
+	StatMean   = "mean"
+	StatMedian = "median"
+	StatMax    = "max"
+	StatCount  = "count"
+)
+
+var (
+	AggregatePeriods = []string{PeriodDay, PeriodWeek, PeriodMonth}
+	AggregateStats   = []string{StatMean, StatMedian, StatMax, StatCount}
+)
+
+// AggregateData is a statistic of a grid cell of a registered dataset over a period.
+// Values holds one value per dataset variable, in the order the variables are declared,
+// NaN where the period has no valid values. For vector datasets the components hold the
+// vector whose speed is the statistic of the speeds, see the database AggregateDatasetData.
+type AggregateData struct {
+	PeriodStart time.Time `json:"period_start"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Values      []float32 `json:"values"`
+	// Samples is the number of rows of the period, valid or not
+	Samples int `json:"samples"`
+}
+
+// AggregateDataToGeoJSON returns the aggregated cells as point features carrying the period,
+// the statistic and the values of every dataset variable. Cells with missing values are skipped.
+func AggregateDataToGeoJSON(ds *datasets.Dataset, period, stat string, data []AggregateData) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, d := range data {
+		if hasNaN(d.Values) {
+			continue
+		}
+		feature := geojson.NewFeature(orb.Point{d.Longitude, d.Latitude})
+		feature.Properties = map[string]interface{}{
+			"period":       period,
+			"period_start": d.PeriodStart,
+			"stat":         stat,
+			"samples":      d.Samples,
+		}
+		for i, column := range ds.Columns() {
+			feature.Properties[column] = d.Values[i]
+		}
+		// the count of vector components has no direction
+		if ds.Vector != nil && stat != StatCount {
+			u := d.Values[ds.ColumnIndex(ds.Vector.U)]
+			v := d.Values[ds.ColumnIndex(ds.Vector.V)]
+			feature.Properties[ds.Vector.Angle] = calculateCurrentAngle(u, v)
+			feature.Properties[ds.Vector.Magnitude] = calculateMagnitude(u, v)
+		}
+		fc.Append(feature)
+	}
+	return fc
+}
+
+// WriteAggregateDataCSV writes the aggregated cells as CSV rows with the same fields
+// as AggregateDataToGeoJSON. Missing values are written as empty fields.
+func WriteAggregateDataCSV(w io.Writer, ds *datasets.Dataset, period, stat string, data []AggregateData) error {
+	withVector := ds.Vector != nil && stat != StatCount
+	header := []string{"period", "period_start", "stat", "latitude", "longitude", "samples"}
+	header = append(header, ds.Columns()...)
+	if withVector {
+		header = append(header, ds.Vector.Angle, ds.Vector.Magnitude)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, d := range data {
+		record := []string{
+			period,
+			d.PeriodStart.UTC().Format(time.RFC3339),
+			stat,
+			strconv.FormatFloat(d.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(d.Longitude, 'f', -1, 64),
+			strconv.Itoa(d.Samples),
+		}
+		for _, v := range d.Values {
+			record = append(record, formatCSVValue(v))
+		}
+		if withVector {
+			u := d.Values[ds.ColumnIndex(ds.Vector.U)]
+			v := d.Values[ds.ColumnIndex(ds.Vector.V)]
+			angle, magnitude := float32(math.NaN()), float32(math.NaN())
+			if !math.IsNaN(float64(u)) && !math.IsNaN(float64(v)) {
+				angle, magnitude = calculateCurrentAngle(u, v), calculateMagnitude(u, v)
+			}
+			record = append(record, formatCSVValue(angle), formatCSVValue(magnitude))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// BloomEvent is a patch of chlorophyll flagged by a bloom detection rule at a single measurement time.
+type BloomEvent struct {
+	ID              int              `json:"id"`
+	MeasurementTime time.Time        `json:"measurement_time"`
+	Rule            string           `json:"rule"`
+	Geometry        orb.MultiPolygon `json:"-"`
+	AreaKm2         float64          `json:"area_km2"`
+	Cells           int              `json:"cells"`
+	MeanChlorA      float64          `json:"mean_chlor_a"`
+	MaxChlorA       float64          `json:"max_chlor_a"`
+	// MaxZScore is the largest anomaly of the patch in standard deviations, nil without a climatology
+	MaxZScore  *float64  `json:"max_z_score"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// BloomEventsToGeoJSON returns the events as features of their outline with every other field as properties.
+func BloomEventsToGeoJSON(events []BloomEvent) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, e := range events {
+		feature := geojson.NewFeature(e.Geometry)
+		feature.ID = e.ID
+		feature.Properties = map[string]interface{}{
+			"id":               e.ID,
+			"measurement_time": e.MeasurementTime,
+			"rule":             e.Rule,
+			"area_km2":         e.AreaKm2,
+			"cells":            e.Cells,
+			"mean_chlor_a":     e.MeanChlorA,
+			"max_chlor_a":      e.MaxChlorA,
+			"max_z_score":      e.MaxZScore,
+			"detected_at":      e.DetectedAt,
+		}
+		fc.Append(feature)
+	}
+	return fc
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+)
+
+// MinClimatologySamples a cell needs before anomalies are computed against its climatology.
+const MinClimatologySamples = 10
+
+// ClimatologyWindowDays before and after the day of a measurement are pooled into its
+// climatology, smoothing the seasonal cycle and gathering enough samples per cell.
+const ClimatologyWindowDays = 7
+
+// DayOfYear returns the day of the year of t in UTC from 1 to 365. February 29 shares the
+// day of February 28, so the later days of leap years match those of other years.
+func DayOfYear(t time.Time) int {
+	t = t.UTC()
+	day := t.YearDay()
+	year := t.Year()
+	leap := year%4 == 0 && (year%100 != 0 || year%400 == 0)
+	if leap && day > 59 {
+		day--
+	}
+	return day
+}
+
+// ClimatologyCoordinate rounds a coordinate the way the climatology stores it, so
+// coordinates of the same grid cell from different sources match.
+func ClimatologyCoordinate(c float64) float64 {
+	return math.Round(c*1e6) / 1e6
+}
+
+// ClimatologySums are the running sums of the observations of a variable at a grid cell on
+// a day of the year, from which its mean and standard deviation are derived.
+type ClimatologySums struct {
+	Variable   string
+	DayOfYear  int
+	Latitude   float64
+	Longitude  float64
+	Samples    int
+	Sum        float64
+	SumSquares float64
+}
+
+// Add adds the observation v to the sums.
+func (s *ClimatologySums) Add(v float64) {
+	s.Samples++
+	s.Sum += v
+	s.SumSquares += v * v
+}
+
+// ClimatologyTime is a measurement time added to the climatology with the number of values
+// it added.
+type ClimatologyTime struct {
+	MeasurementTime time.Time
+	Samples         int
+}
+
+// ClimatologyCell is the climatology of a variable at a grid cell around a day of the year.
+type ClimatologyCell struct {
+	Variable  string  `json:"variable"`
+	DayOfYear int     `json:"day_of_year"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Samples   int     `json:"samples"`
+	Mean      float64 `json:"mean"`
+	Std       float64 `json:"std"`
+}
+
+type climatologyKey struct {
+	variable  int
+	dayOfYear int
+	lat, lon  float64
+}
+
+// Climatology computes the anomalies of the grid cells of a dataset.
+type Climatology struct {
+	ds    *datasets.Dataset
+	cells map[climatologyKey]ClimatologyCell
+}
+
+// NewClimatology returns the climatology of ds made of cells, cells of variables that
+// aren't dataset columns are left out.
+func NewClimatology(ds *datasets.Dataset, cells []ClimatologyCell) *Climatology {
+	c := &Climatology{ds: ds, cells: make(map[climatologyKey]ClimatologyCell, len(cells))}
+	for _, cell := range cells {
+		i := ds.ColumnIndex(cell.Variable)
+		if i < 0 {
+			continue
+		}
+		c.cells[climatologyKey{i, cell.DayOfYear, ClimatologyCoordinate(cell.Latitude), ClimatologyCoordinate(cell.Longitude)}] = cell
+	}
+	return c
+}
+
+// Dataset returns the dataset describing the values returned by Anomalies.
+func (c *Climatology) Dataset() *datasets.Dataset {
+	return c.ds.AnomalyDataset()
+}
+
+// Anomalies returns d with the values of Dataset: every value followed by its anomaly and
+// z-score, see Anomaly.
+func (c *Climatology) Anomalies(d GridData) GridData {
+	values := make([]float32, 0, 3*len(d.Values))
+	for i, v := range d.Values {
+		anomaly, z := c.Anomaly(d, i)
+		values = append(values, v, float32(anomaly), float32(z))
+	}
+	d.Values = values
+	return d
+}
+
+// Anomaly returns the difference of the i-th value of d to the climatology mean and that
+// difference in standard deviations of the climatology. Both are NaN if the cell has fewer
+// than MinClimatologySamples, the z-score also if the climatology doesn't vary.
+func (c *Climatology) Anomaly(d GridData, i int) (anomaly, z float64) {
+	v := float64(d.Values[i])
+	cell, ok := c.cells[climatologyKey{i, DayOfYear(d.MeasurementTime), ClimatologyCoordinate(d.Latitude), ClimatologyCoordinate(d.Longitude)}]
+	if !ok || cell.Samples < MinClimatologySamples || math.IsNaN(v) {
+		return math.NaN(), math.NaN()
+	}
+	anomaly, z = v-cell.Mean, math.NaN()
+	if cell.Std > 0 {
+		z = anomaly / cell.Std
+	}
+	return anomaly, z
+}
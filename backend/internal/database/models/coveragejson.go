@@ -0,0 +1,152 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+)
+
+// CoverageJSON domain types of the encoded coverages.
+const (
+	DomainGrid        = "Grid"
+	DomainPointSeries = "PointSeries"
+)
+
+// crs84 is the geographic reference system of CoverageJSON coordinates, longitude first.
+const crs84 = "http://www.opengis.net/def/crs/OGC/1.3/CRS84"
+
+// Coverage is a CoverageJSON coverage, see https://covjson.org/spec/.
+type Coverage struct {
+	Type       string                   `json:"type"`
+	Domain     CoverageDomain           `json:"domain"`
+	Parameters map[string]CoverageParam `json:"parameters"`
+	Ranges     map[string]CoverageRange `json:"ranges"`
+}
+
+type CoverageDomain struct {
+	Type        string                  `json:"type"`
+	DomainType  string                  `json:"domainType"`
+	Axes        map[string]CoverageAxis `json:"axes"`
+	Referencing []CoverageReferencing   `json:"referencing"`
+}
+
+// CoverageAxis holds either the numeric coordinates of the x and y axes or the times of the t axis.
+type CoverageAxis struct {
+	Values any `json:"values"`
+}
+
+type CoverageReferencing struct {
+	Coordinates []string       `json:"coordinates"`
+	System      map[string]any `json:"system"`
+}
+
+// CoverageParam describes a variable of a coverage, it is also the EDR parameter_names entry.
+type CoverageParam struct {
+	Type             string            `json:"type"`
+	Description      map[string]string `json:"description,omitempty"`
+	Unit             *CoverageUnit     `json:"unit,omitempty"`
+	ObservedProperty CoverageProperty  `json:"observedProperty"`
+}
+
+type CoverageUnit struct {
+	Symbol string `json:"symbol"`
+}
+
+type CoverageProperty struct {
+	ID    string            `json:"id,omitempty"`
+	Label map[string]string `json:"label"`
+}
+
+// CoverageRange holds the values of a variable, null where they are missing.
+type CoverageRange struct {
+	Type      string     `json:"type"`
+	DataType  string     `json:"dataType"`
+	AxisNames []string   `json:"axisNames"`
+	Shape     []int      `json:"shape"`
+	Values    []*float32 `json:"values"`
+}
+
+// CoverageParameter returns the CoverageJSON parameter of a dataset variable.
+func CoverageParameter(v datasets.Variable) CoverageParam {
+	label := v.LongName
+	if label == "" {
+		label = v.Column
+	}
+	p := CoverageParam{
+		Type:             "Parameter",
+		ObservedProperty: CoverageProperty{Label: map[string]string{"en": label}},
+	}
+	if v.LongName != "" {
+		p.Description = map[string]string{"en": v.LongName}
+	}
+	if v.Units != "" {
+		p.Unit = &CoverageUnit{Symbol: v.Units}
+	}
+	if v.StandardName != "" {
+		p.ObservedProperty.ID = "http://vocab.nerc.ac.uk/standard_name/" + v.StandardName + "/"
+	}
+	return p
+}
+
+// GridDataToCoverage returns the grid cells of a dataset as a CoverageJSON coverage of
+// the requested columns. Cells of a single location form a PointSeries domain, any other
+// cells a Grid domain over every distinct time, latitude and longitude of the cells.
+// Grid positions without a cell and missing values are null. data must not be empty.
+func GridDataToCoverage(ds *datasets.Dataset, columns []string, data []GridData) Coverage {
+	times, timeIndex := uniqueSorted(data, func(d GridData) float64 { return float64(d.MeasurementTime.Unix()) })
+	lats, latIndex := uniqueSorted(data, func(d GridData) float64 { return d.Latitude })
+	lons, lonIndex := uniqueSorted(data, func(d GridData) float64 { return d.Longitude })
+
+	timeValues := make([]string, len(times))
+	for i, t := range times {
+		timeValues[i] = time.Unix(int64(t), 0).UTC().Format(time.RFC3339)
+	}
+
+	cov := Coverage{
+		Type: "Coverage",
+		Domain: CoverageDomain{
+			Type:       "Domain",
+			DomainType: DomainGrid,
+			Axes: map[string]CoverageAxis{
+				"x": {Values: lons},
+				"y": {Values: lats},
+				"t": {Values: timeValues},
+			},
+			Referencing: []CoverageReferencing{
+				{Coordinates: []string{"x", "y"}, System: map[string]any{"type": "GeographicCRS", "id": crs84}},
+				{Coordinates: []string{"t"}, System: map[string]any{"type": "TemporalRS", "calendar": "Gregorian"}},
+			},
+		},
+		Parameters: make(map[string]CoverageParam, len(columns)),
+		Ranges:     make(map[string]CoverageRange, len(columns)),
+	}
+
+	axisNames, shape := []string{"t", "y", "x"}, []int{len(times), len(lats), len(lons)}
+	if len(lats) == 1 && len(lons) == 1 {
+		cov.Domain.DomainType = DomainPointSeries
+		axisNames, shape = []string{"t"}, []int{len(times)}
+	}
+
+	for _, column := range columns {
+		index := ds.ColumnIndex(column)
+		cov.Parameters[column] = CoverageParameter(ds.Variables[index])
+		values := make([]*float32, len(times)*len(lats)*len(lons))
+		for _, d := range data {
+			v := d.Values[index]
+			if math.IsNaN(float64(v)) {
+				continue
+			}
+			t, y, x := timeIndex[float64(d.MeasurementTime.Unix())], latIndex[d.Latitude], lonIndex[d.Longitude]
+			values[(t*len(lats)+y)*len(lons)+x] = &v
+		}
+		cov.Ranges[column] = CoverageRange{
+			Type:      "NdArray",
+			DataType:  "float",
+			AxisNames: axisNames,
+			Shape:     shape,
+			Values:    values,
+		}
+	}
+	return cov
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+)
+
+// GridDataCSVWriter writes grid cells of a dataset as CSV rows with the same fields
+// as GridDataToGeoJSON. Missing values are written as empty fields.
+type GridDataCSVWriter struct {
+	ds            *datasets.Dataset
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func NewGridDataCSVWriter(w io.Writer, ds *datasets.Dataset) *GridDataCSVWriter {
+	return &GridDataCSVWriter{ds: ds, w: csv.NewWriter(w)}
+}
+
+// Header returns the column names of the rows.
+func (c *GridDataCSVWriter) Header() []string {
+	header := []string{"measurement_time", "latitude", "longitude"}
+	header = append(header, c.ds.Columns()...)
+	if c.ds.Vector != nil {
+		header = append(header, c.ds.Vector.Angle, c.ds.Vector.Magnitude)
+	}
+	return append(header, "provenance", "interpolation_method", "interpolation_variance")
+}
+
+// WriteHeader writes the header row, unless it was already written.
+func (c *GridDataCSVWriter) WriteHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write(c.Header())
+}
+
+// Write writes d as a single row, preceded by the header row if it's the first one.
+func (c *GridDataCSVWriter) Write(d GridData) error {
+	if err := c.WriteHeader(); err != nil {
+		return err
+	}
+	record := []string{
+		d.MeasurementTime.UTC().Format(time.RFC3339),
+		strconv.FormatFloat(d.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(d.Longitude, 'f', -1, 64),
+	}
+	for _, v := range d.Values {
+		record = append(record, formatCSVValue(v))
+	}
+	if c.ds.Vector != nil {
+		u := d.Values[c.ds.ColumnIndex(c.ds.Vector.U)]
+		v := d.Values[c.ds.ColumnIndex(c.ds.Vector.V)]
+		angle, magnitude := float32(math.NaN()), float32(math.NaN())
+		if !math.IsNaN(float64(u)) && !math.IsNaN(float64(v)) {
+			angle, magnitude = calculateCurrentAngle(u, v), calculateMagnitude(u, v)
+		}
+		record = append(record, formatCSVValue(angle), formatCSVValue(magnitude))
+	}
+	variance := ""
+	if d.InterpolationVariance != nil {
+		variance = formatCSVValue(*d.InterpolationVariance)
+	}
+	record = append(record, d.Provenance, d.InterpolationMethod, variance)
+	return c.w.Write(record)
+}
+
+// Flush writes any buffered rows and returns the first error writing failed with.
+func (c *GridDataCSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close writes the header row if no row was written and flushes the rows.
+func (c *GridDataCSVWriter) Close() error {
+	if err := c.WriteHeader(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+func formatCSVValue(v float32) string {
+	if math.IsNaN(float64(v)) {
+		return ""
+	}
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
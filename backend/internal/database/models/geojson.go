@@ -0,0 +1,70 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"ocean-digital-twin/internal/datasets"
+)
+
+// GridDataGeoJSONWriter writes grid cells of a dataset as a GeoJSON FeatureCollection one
+// feature at a time, producing the same document as GridDataToGeoJSON without holding
+// all of the cells in memory. Cells with missing values are skipped.
+type GridDataGeoJSONWriter struct {
+	ds       *datasets.Dataset
+	w        *bufio.Writer
+	started  bool
+	features int
+}
+
+func NewGridDataGeoJSONWriter(w io.Writer, ds *datasets.Dataset) *GridDataGeoJSONWriter {
+	return &GridDataGeoJSONWriter{ds: ds, w: bufio.NewWriter(w)}
+}
+
+// Write writes d as a single feature, preceded by the start of the collection if it's the first one.
+func (g *GridDataGeoJSONWriter) Write(d GridData) error {
+	if d.hasNaN() {
+		return nil
+	}
+	if err := g.start(); err != nil {
+		return err
+	}
+	feature, err := json.Marshal(gridDataFeature(g.ds, d))
+	if err != nil {
+		return err
+	}
+	if g.features > 0 {
+		if err := g.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	g.features++
+	_, err = g.w.Write(feature)
+	return err
+}
+
+// Flush writes any buffered features.
+func (g *GridDataGeoJSONWriter) Flush() error {
+	return g.w.Flush()
+}
+
+// Close ends the collection and flushes it, an empty collection is written if no feature was.
+func (g *GridDataGeoJSONWriter) Close() error {
+	if err := g.start(); err != nil {
+		return err
+	}
+	if _, err := g.w.WriteString("]}\n"); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
+
+func (g *GridDataGeoJSONWriter) start() error {
+	if g.started {
+		return nil
+	}
+	g.started = true
+	_, err := g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Provenance of the values of a grid cell.
+const (
+	ProvenanceObserved         = "observed"
+	ProvenanceTimeInterpolated = "time_interpolated"
+	ProvenanceAreaInterpolated = "area_interpolated"
+	// ProvenanceInterpolated marks values filled before provenance was tracked, the method is unknown
+	ProvenanceInterpolated = "interpolated"
+)
+
+// GridData is a single grid cell of a registered dataset.
+// Values holds one value per dataset variable, in the order the variables are declared.
+type GridData struct {
+	ID              int       `json:"id"`
+	MeasurementTime time.Time `json:"measurement_time"`
+	Latitude        float64   `json:"latitude"`
+	Longitude       float64   `json:"longitude"`
+	Values          []float32 `json:"values"`
+	// Provenance tells whether the values were observed or filled by the interpolator,
+	// InterpolationMethod names the method that filled them
+	Provenance          string `json:"provenance"`
+	InterpolationMethod string `json:"interpolation_method,omitempty"`
+	// InterpolationVariance is the estimation variance of values filled by kriging,
+	// the largest one if several variables were filled
+	InterpolationVariance *float32  `json:"interpolation_variance,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+	// Land marks cells covered by the land mask, it is set by the interpolator and not stored
+	Land bool `json:"-"`
+}
+
+// GridValue exposes a single variable of a grid cell so it can be interpolated on its own.
+// Setting the value marks the cell with Provenance and Method.
+type GridValue struct {
+	Data       *GridData
+	Index      int
+	Provenance string
+	Method     string
+}
+
+// Masked reports cells on land, which are never interpolated.
+func (g GridValue) Masked() bool {
+	return g.Data.Land
+}
+
+func (g GridValue) Value() float32 {
+	return g.Data.Values[g.Index]
+}
+
+func (g GridValue) SetValue(val float32) {
+	g.Data.Values[g.Index] = val
+	if g.Data.InterpolationMethod != g.Method {
+		// the variance belongs to the previous method
+		g.Data.InterpolationVariance = nil
+	}
+	g.Data.Provenance = g.Provenance
+	g.Data.InterpolationMethod = g.Method
+}
+
+// SetVariance stores the estimation variance of the value, keeping the largest
+// variance of the grid cell.
+func (g GridValue) SetVariance(variance float32) {
+	if g.Data.InterpolationVariance == nil || *g.Data.InterpolationVariance < variance {
+		g.Data.InterpolationVariance = &variance
+	}
+}
+
+func (d *GridData) hasNaN() bool {
+	return hasNaN(d.Values)
+}
+
+func hasNaN(values []float32) bool {
+	for _, v := range values {
+		if math.IsNaN(float64(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+func GridDataToGeoJSON(ds *datasets.Dataset, data []GridData) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+
+	for _, d := range data {
+		if d.hasNaN() {
+			continue
+		}
+		fc.Append(gridDataFeature(ds, d))
+	}
+	return fc
+}
+
+// gridDataFeature returns the GeoJSON point feature of a grid cell.
+func gridDataFeature(ds *datasets.Dataset, d GridData) *geojson.Feature {
+	point := orb.Point{d.Longitude, d.Latitude}
+	feature := geojson.NewFeature(point)
+	feature.Properties = map[string]interface{}{
+		"id":               d.ID,
+		"measurement_time": d.MeasurementTime,
+		"provenance":       d.Provenance,
+	}
+	if d.InterpolationMethod != "" {
+		feature.Properties["interpolation_method"] = d.InterpolationMethod
+	}
+	if d.InterpolationVariance != nil {
+		feature.Properties["interpolation_variance"] = *d.InterpolationVariance
+	}
+	for i, column := range ds.Columns() {
+		feature.Properties[column] = d.Values[i]
+	}
+	if ds.Vector != nil {
+		u := d.Values[ds.ColumnIndex(ds.Vector.U)]
+		v := d.Values[ds.ColumnIndex(ds.Vector.V)]
+		feature.Properties[ds.Vector.Angle] = calculateCurrentAngle(u, v)
+		feature.Properties[ds.Vector.Magnitude] = calculateMagnitude(u, v)
+	}
+	return feature
+}
+
+func calculateCurrentAngle(u, v float32) float32 {
+	if u == 0 && v == 0 {
+		return 0.0
+	}
+
+	if u == 0 {
+		if v > 0 {
+			return 0.0 // North
+		}
+		return 180.0 // South
+	}
+
+	if v == 0 {
+		if u > 0 {
+			return 90.0 // East
+		}
+		return 270.0 // West
+	}
+
+	radians := math.Atan2(float64(v), float64(u))
+
+	angleFromEastDegrees := radians * (180.0 / math.Pi)
+
+	// Adjust to Mapbox's system where 0° is North and angles increase clockwise.
+	mapboxAngle := 90.0 - angleFromEastDegrees
+
+	normalizedAngle := math.Mod(mapboxAngle, 360.0)
+
+	if normalizedAngle < 0 {
+		normalizedAngle += 360.0
+	}
+
+	return float32(normalizedAngle)
+}
+
+func calculateMagnitude(u, v float32) float32 {
+	return float32(math.Sqrt(math.Pow(float64(u), 2) + math.Pow(float64(v), 2)))
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// IngestionRun records a successful download of a dataset saved to the database.
+type IngestionRun struct {
+	ID        int       `json:"id"`
+	Dataset   string    `json:"dataset"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Points    int       `json:"points"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IngestionFailure describes a dataset update that failed at stage.
+type IngestionFailure struct {
+	Dataset string `json:"dataset"`
+	Stage   string `json:"stage"`
+	Error   string `json:"error"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+)
+
+// tilePixels is the size in pixels of a rendered tile, thinning spacing is given in pixels.
+const tilePixels = 256
+
+// tileThinning is the minimum spacing in pixels of the points of tiles up to maxZoom,
+// points of tiles zoomed in further are never thinned.
+var tileThinning = []struct {
+	maxZoom maptile.Zoom
+	pixels  float64
+}{
+	{maxZoom: 6, pixels: 8},
+	{maxZoom: 9, pixels: 4},
+	{maxZoom: 11, pixels: 2},
+}
+
+// TileThinningSpacing returns the minimum spacing in pixels of the points of a tile at zoom z, 0 if they aren't thinned.
+func TileThinningSpacing(z maptile.Zoom) float64 {
+	for _, t := range tileThinning {
+		if z <= t.maxZoom {
+			return t.pixels
+		}
+	}
+	return 0
+}
+
+// GridDataToMVT encodes the grid cells of a dataset as a Mapbox Vector Tile with a single
+// layer named after the dataset. The features carry the same properties as GridDataToGeoJSON.
+// Cells with missing values are skipped and, depending on the zoom of the tile, only the
+// first cell within TileThinningSpacing pixels is kept.
+func GridDataToMVT(ds *datasets.Dataset, data []GridData, tile maptile.Tile) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+	for _, d := range data {
+		if d.hasNaN() {
+			continue
+		}
+		feature := gridDataFeature(ds, d)
+		// vector tiles only hold numbers, strings and booleans
+		feature.Properties["measurement_time"] = d.MeasurementTime.UTC().Format(time.RFC3339)
+		fc.Append(feature)
+	}
+
+	layer := mvt.NewLayer(ds.Name, fc)
+	layer.ProjectToTile(tile)
+	if spacing := TileThinningSpacing(tile.Z) * mvt.DefaultExtent / tilePixels; spacing > 0 {
+		layer.Features = thinPoints(layer.Features, spacing)
+	}
+	return mvt.Marshal(mvt.Layers{layer})
+}
+
+// thinPoints keeps the first point feature of every spacing sized cell of the tile.
+func thinPoints(features []*geojson.Feature, spacing float64) []*geojson.Feature {
+	occupied := make(map[[2]int]bool)
+	thinned := features[:0]
+	for _, f := range features {
+		point, ok := f.Geometry.(orb.Point)
+		if !ok {
+			thinned = append(thinned, f)
+			continue
+		}
+		cell := [2]int{int(math.Floor(point.X() / spacing)), int(math.Floor(point.Y() / spacing))}
+		if occupied[cell] {
+			continue
+		}
+		occupied[cell] = true
+		thinned = append(thinned, f)
+	}
+	return thinned
+}
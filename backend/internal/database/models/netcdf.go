@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/batchatco/go-native-netcdf/netcdf/api"
+	"github.com/batchatco/go-native-netcdf/netcdf/cdf"
+	"github.com/batchatco/go-native-netcdf/netcdf/util"
+)
+
+// NetCDFFillValue marks missing values of exported variables, the NetCDF default fill of floats.
+const NetCDFFillValue float32 = 9.96921e+36
+
+// netCDFTimeUnits are the CF units of the exported time coordinate.
+const netCDFTimeUnits = "seconds since 1970-01-01T00:00:00Z"
+
+// provenanceFlags are the values of the exported provenance variable, indexed by flag value.
+var provenanceFlags = []string{
+	ProvenanceObserved,
+	ProvenanceTimeInterpolated,
+	ProvenanceAreaInterpolated,
+	ProvenanceInterpolated,
+}
+
+// ErrNoGridData is returned when there is no data to build a grid from.
+var ErrNoGridData = errors.New("no data to export")
+
+// WriteGridDataNetCDF writes the grid cells of a dataset to path as a CF-1.8 compliant
+// NetCDF file gridded on time, latitude and longitude. Cells without data hold NetCDFFillValue.
+// Provenance and interpolation variance of the cells are exported as ancillary variables.
+func WriteGridDataNetCDF(path string, ds *datasets.Dataset, data []GridData) error {
+	if len(data) == 0 {
+		return ErrNoGridData
+	}
+
+	times, timeIndex := uniqueSorted(data, func(d GridData) float64 { return float64(d.MeasurementTime.Unix()) })
+	lats, latIndex := uniqueSorted(data, func(d GridData) float64 { return d.Latitude })
+	lons, lonIndex := uniqueSorted(data, func(d GridData) float64 { return d.Longitude })
+
+	values := make([][][][]float32, len(ds.Variables))
+	for i := range values {
+		values[i] = newFloatCube(len(times), len(lats), len(lons), NetCDFFillValue)
+	}
+	variance := newFloatCube(len(times), len(lats), len(lons), NetCDFFillValue)
+	provenance := make([][][]int8, len(times))
+	for t := range provenance {
+		provenance[t] = make([][]int8, len(lats))
+		for y := range provenance[t] {
+			provenance[t][y] = make([]int8, len(lons))
+			for x := range provenance[t][y] {
+				provenance[t][y][x] = -1
+			}
+		}
+	}
+
+	for _, d := range data {
+		t := timeIndex[float64(d.MeasurementTime.Unix())]
+		y, x := latIndex[d.Latitude], lonIndex[d.Longitude]
+		for i, v := range d.Values {
+			if !math.IsNaN(float64(v)) {
+				values[i][t][y][x] = v
+			}
+		}
+		if d.InterpolationVariance != nil {
+			variance[t][y][x] = *d.InterpolationVariance
+		}
+		provenance[t][y][x] = provenanceFlag(d.Provenance)
+	}
+
+	cw, err := cdf.OpenWriter(path)
+	if err != nil {
+		return fmt.Errorf("error creating NetCDF file: %w", err)
+	}
+
+	start := time.Unix(int64(times[0]), 0).UTC()
+	end := time.Unix(int64(times[len(times)-1]), 0).UTC()
+	err = cw.AddGlobalAttrs(attributes(
+		"Conventions", "CF-1.8",
+		"title", ds.Name,
+		"source", "ERDDAP griddap dataset "+ds.ERDDAPID,
+		"history", time.Now().UTC().Format(time.RFC3339)+" exported by ocean-digital-twin",
+		"time_coverage_start", start.Format(time.RFC3339),
+		"time_coverage_end", end.Format(time.RFC3339),
+		"geospatial_lat_min", lats[0],
+		"geospatial_lat_max", lats[len(lats)-1],
+		"geospatial_lon_min", lons[0],
+		"geospatial_lon_max", lons[len(lons)-1],
+	))
+	if err != nil {
+		cw.Close()
+		return fmt.Errorf("error adding NetCDF global attributes: %w", err)
+	}
+
+	dims := []string{datasets.DimTime, datasets.DimLatitude, datasets.DimLongitude}
+	vars := []netCDFVariable{
+		{datasets.DimTime, api.Variable{Values: times, Dimensions: dims[:1], Attributes: attributes(
+			"standard_name", "time", "long_name", "Time", "units", netCDFTimeUnits, "calendar", "standard", "axis", "T")}},
+		{datasets.DimLatitude, api.Variable{Values: lats, Dimensions: dims[1:2], Attributes: attributes(
+			"standard_name", "latitude", "long_name", "Latitude", "units", "degrees_north", "axis", "Y")}},
+		{datasets.DimLongitude, api.Variable{Values: lons, Dimensions: dims[2:], Attributes: attributes(
+			"standard_name", "longitude", "long_name", "Longitude", "units", "degrees_east", "axis", "X")}},
+	}
+	for i, v := range ds.Variables {
+		attrs := []any{"_FillValue", NetCDFFillValue}
+		for _, attr := range [][2]string{{"standard_name", v.StandardName}, {"long_name", v.LongName}, {"units", v.Units}} {
+			if attr[1] != "" {
+				attrs = append(attrs, attr[0], attr[1])
+			}
+		}
+		attrs = append(attrs, "ancillary_variables", "provenance interpolation_variance")
+		vars = append(vars, netCDFVariable{v.Column, api.Variable{Values: values[i], Dimensions: dims, Attributes: attributes(attrs...)}})
+	}
+	vars = append(vars,
+		netCDFVariable{"provenance", api.Variable{Values: provenance, Dimensions: dims, Attributes: attributes(
+			"_FillValue", int8(-1),
+			"long_name", "Origin of the values",
+			"flag_values", []int8{0, 1, 2, 3},
+			"flag_meanings", strings.Join(provenanceFlags, " "))}},
+		netCDFVariable{"interpolation_variance", api.Variable{Values: variance, Dimensions: dims, Attributes: attributes(
+			"_FillValue", NetCDFFillValue,
+			"long_name", "Kriging variance of interpolated values")}},
+	)
+
+	for _, v := range vars {
+		if err := cw.AddVar(v.name, v.v); err != nil {
+			cw.Close()
+			return fmt.Errorf("error adding NetCDF variable %s: %w", v.name, err)
+		}
+	}
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("error writing NetCDF file: %w", err)
+	}
+	return nil
+}
+
+type netCDFVariable struct {
+	name string
+	v    api.Variable
+}
+
+// uniqueSorted returns the distinct values of key in ascending order and the index of each value.
+func uniqueSorted(data []GridData, key func(GridData) float64) ([]float64, map[float64]int) {
+	index := make(map[float64]int)
+	var values []float64
+	for _, d := range data {
+		k := key(d)
+		if _, ok := index[k]; !ok {
+			index[k] = 0
+			values = append(values, k)
+		}
+	}
+	sort.Float64s(values)
+	for i, v := range values {
+		index[v] = i
+	}
+	return values, index
+}
+
+func newFloatCube(times, lats, lons int, fill float32) [][][]float32 {
+	cube := make([][][]float32, times)
+	for t := range cube {
+		cube[t] = make([][]float32, lats)
+		for y := range cube[t] {
+			cube[t][y] = make([]float32, lons)
+			for x := range cube[t][y] {
+				cube[t][y][x] = fill
+			}
+		}
+	}
+	return cube
+}
+
+func provenanceFlag(provenance string) int8 {
+	for i, p := range provenanceFlags {
+		if p == provenance {
+			return int8(i)
+		}
+	}
+	return -1
+}
+
+// attributes builds a NetCDF attribute map from alternating names and values, keeping their order.
+func attributes(pairs ...any) api.AttributeMap {
+	keys := make([]string, 0, len(pairs)/2)
+	values := make(map[string]any, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key := pairs[i].(string)
+		keys = append(keys, key)
+		values[key] = pairs[i+1]
+	}
+	attrs, _ := util.NewOrderedMap(keys, values)
+	return attrs
+}
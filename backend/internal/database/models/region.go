@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Region is a named area of interest, e.g. a marine protected area, that queries can be restricted to.
+type Region struct {
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	Geometry  orb.MultiPolygon `json:"-"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// RegionToGeoJSON returns the region as a feature with its ID, name and creation time as properties.
+func RegionToGeoJSON(r Region) *geojson.Feature {
+	feature := geojson.NewFeature(r.Geometry)
+	feature.ID = r.ID
+	feature.Properties = map[string]interface{}{
+		"id":         r.ID,
+		"name":       r.Name,
+		"created_at": r.CreatedAt,
+	}
+	return feature
+}
+
+func RegionsToGeoJSON(regions []Region) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, r := range regions {
+		fc.Append(RegionToGeoJSON(r))
+	}
+	return fc
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events webhooks can subscribe to.
+const (
+	// EventIngestionFailed is sent when a dataset update fails, with an IngestionFailure
+	EventIngestionFailed = "ingestion.failed"
+	// EventDataIngested is sent when new data of a dataset is saved, with its IngestionRun
+	EventDataIngested = "data.ingested"
+	// EventBloomDetected is sent when bloom events are detected, with a FeatureCollection of them
+	EventBloomDetected = "bloom.detected"
+)
+
+// WebhookEvents lists every event in the order they are documented.
+var WebhookEvents = []string{EventIngestionFailed, EventDataIngested, EventBloomDetected}
+
+// Webhook is a URL subscribed to events. The secret signs the payloads and is only shown
+// when the webhook is created.
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery records the delivery of an event to a webhook, including failed attempts.
+type WebhookDelivery struct {
+	ID        int             `json:"id"`
+	WebhookID int             `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	// StatusCode of the last attempt, 0 if no response was received
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+	// DeliveredAt is nil until an attempt is answered with a 2xx status
+	DeliveredAt *time.Time `json:"delivered_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+// AggregateDatasetData computes stat over every period of the time range for each location
+// of the bounding box, ordered by period. NaN values are left out of the statistic.
+//
+// The components of vector datasets aren't aggregated on their own, as that would shrink
+// the speed of currents changing direction. Instead the statistic is computed over the
+// speeds, and the mean and median keep the circular mean of the directions, i.e. the
+// direction of the mean unit vector. The max keeps the vector of the highest speed.
+func (s *service) AggregateDatasetData(ctx context.Context, ds *datasets.Dataset, period, stat string, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.AggregateData, error) {
+	if !slices.Contains(models.AggregatePeriods, period) {
+		return nil, fmt.Errorf("unknown aggregation period %q", period)
+	}
+	aggregates, values, err := aggregateColumns(ds, stat)
+	if err != nil {
+		return nil, err
+	}
+	table := ds.Table
+	if rawData {
+		table = ds.RawTable
+	}
+	filter, args, err := locationFilter(4, minLat, minLon, maxLat, maxLon, area, maskLand)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+            SELECT
+                period_start,
+                ST_Y(location::geometry) as latitude,
+                ST_X(location::geometry) as longitude,
+                samples,
+                %s
+            FROM (
+                SELECT
+                    date_trunc($1, measurement_time, 'UTC') as period_start,
+                    location,
+                    COUNT(*) as samples,
+                    %s
+                FROM
+                    %s
+                WHERE
+                    measurement_time BETWEEN $2 AND $3
+                    AND %s
+                GROUP BY
+                    period_start, location
+            ) aggregates
+            ORDER BY
+                period_start, latitude, longitude
+            `, strings.Join(values, ",\n"), strings.Join(aggregates, ",\n"), table, filter)
+	rows, err := s.db.Query(ctx, query, append([]any{period, startTime, endTime}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating %s data: %w", ds.Name, err)
+	}
+	defer rows.Close()
+
+	var result []models.AggregateData
+	for rows.Next() {
+		d := models.AggregateData{Values: make([]float32, len(ds.Variables))}
+		dest := []any{&d.PeriodStart, &d.Latitude, &d.Longitude, &d.Samples}
+		for i := range d.Values {
+			dest = append(dest, &d.Values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("error scanning %s aggregates: %w", ds.Name, err)
+		}
+		result = append(result, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through %s aggregates: %w", ds.Name, err)
+	}
+	return result, nil
+}
+
+// aggregateColumns returns the aggregate expressions computing stat per period and location,
+// and the expressions deriving the value of every dataset variable from them, NaN if
+// the period had no valid values.
+func aggregateColumns(ds *datasets.Dataset, stat string) (aggregates, values []string, err error) {
+	if !slices.Contains(models.AggregateStats, stat) {
+		return nil, nil, fmt.Errorf("unknown aggregation statistic %q", stat)
+	}
+	var vectorValues map[string]string
+	if ds.Vector != nil {
+		aggregates, vectorValues = vectorAggregates(ds.Vector.U, ds.Vector.V, stat)
+	}
+	for _, column := range ds.Columns() {
+		value, ok := vectorValues[column]
+		if !ok {
+			aggregates = append(aggregates, scalarAggregate(column, stat)+" as "+column)
+			value = column
+		}
+		values = append(values, fmt.Sprintf("COALESCE((%s)::FLOAT, 'NaN') as %s", value, column))
+	}
+	return aggregates, values, nil
+}
+
+// scalarAggregate returns the expression computing stat over the valid values of column.
+func scalarAggregate(column, stat string) string {
+	valid := fmt.Sprintf("FILTER (WHERE %s <> 'NaN')", column)
+	switch stat {
+	case models.StatMedian:
+		return fmt.Sprintf("percentile_cont(0.5) WITHIN GROUP (ORDER BY %s) %s", column, valid)
+	case models.StatMax:
+		return fmt.Sprintf("MAX(%s) %s", column, valid)
+	case models.StatCount:
+		return fmt.Sprintf("COUNT(%s) %s", column, valid)
+	default:
+		return fmt.Sprintf("AVG(%s) %s", column, valid)
+	}
+}
+
+// vectorAggregates returns the expressions computing stat over the vectors of the u and v
+// columns where both components are valid, and the expressions deriving u and v from them.
+func vectorAggregates(u, v, stat string) (aggregates []string, values map[string]string) {
+	valid := fmt.Sprintf("FILTER (WHERE %s <> 'NaN' AND %s <> 'NaN')", u, v)
+	speed := fmt.Sprintf("SQRT(%s * %s + %s * %s)", u, u, v, v)
+	switch stat {
+	case models.StatCount:
+		aggregates = []string{fmt.Sprintf("COUNT(*) %s as vector_count", valid)}
+		return aggregates, map[string]string{u: "vector_count", v: "vector_count"}
+	case models.StatMax:
+		aggregates = []string{
+			fmt.Sprintf("(ARRAY_AGG(%s ORDER BY %s DESC) %s)[1] as vector_east", u, speed, valid),
+			fmt.Sprintf("(ARRAY_AGG(%s ORDER BY %s DESC) %s)[1] as vector_north", v, speed, valid),
+		}
+		return aggregates, map[string]string{u: "vector_east", v: "vector_north"}
+	}
+
+	speedStat := fmt.Sprintf("AVG(%s) %s", speed, valid)
+	if stat == models.StatMedian {
+		speedStat = fmt.Sprintf("percentile_cont(0.5) WITHIN GROUP (ORDER BY %s) %s", speed, valid)
+	}
+	aggregates = []string{
+		speedStat + " as vector_speed",
+		// components of the mean unit vector, their direction is the circular mean
+		fmt.Sprintf("AVG(%s / NULLIF(%s, 0)) %s as vector_east", u, speed, valid),
+		fmt.Sprintf("AVG(%s / NULLIF(%s, 0)) %s as vector_north", v, speed, valid),
+	}
+	// calm currents have no direction, opposite directions cancel out to none
+	component := "CASE WHEN vector_speed = 0 THEN 0 ELSE vector_speed * %s / NULLIF(SQRT(vector_east * vector_east + vector_north * vector_north), 0) END"
+	return aggregates, map[string]string{
+		u: fmt.Sprintf(component, "vector_east"),
+		v: fmt.Sprintf(component, "vector_north"),
+	}
+}
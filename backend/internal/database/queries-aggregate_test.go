@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestAggregateDatasetData(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "currents", "currents_aggregate")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+
+	// a single cell flowing north-east, then south-east and north-east again, 2025-01-06 is a Monday
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	nan := float32(math.NaN())
+	var data []models.GridData
+	for i, values := range [][]float32{{1, 1}, {2, -2}, {1, 1}, {nan, 1}} {
+		data = append(data, models.GridData{
+			MeasurementTime: start.Add(time.Duration(i) * 24 * time.Hour),
+			Latitude:        41,
+			Longitude:       2,
+			Values:          values,
+		})
+	}
+	if _, err := srv.IngestDatasetData(ctx, ds, data); err != nil {
+		t.Fatalf("IngestDatasetData() returned error: %v", err)
+	}
+
+	end := start.Add(7 * 24 * time.Hour)
+	aggregate := func(stat string) models.AggregateData {
+		t.Helper()
+		result, err := srv.AggregateDatasetData(ctx, ds, models.PeriodWeek, stat, start, end, 40, 1, 42, 3, nil, false, false)
+		if err != nil {
+			t.Fatalf("AggregateDatasetData() returned error: %v", err)
+		}
+		if len(result) != 1 {
+			t.Fatalf("expected a single weekly cell, got %d", len(result))
+		}
+		if !result[0].PeriodStart.Equal(start) || result[0].Samples != 4 {
+			t.Errorf("expected the week of %v with 4 samples, got %v with %d", start, result[0].PeriodStart, result[0].Samples)
+		}
+		return result[0]
+	}
+
+	// the mean direction is the circular mean of north-east, south-east and north-east
+	mean := aggregate(models.StatMean)
+	u, v := float64(mean.Values[0]), float64(mean.Values[1])
+	wantSpeed := (2*math.Sqrt2 + 2*math.Sqrt2) / 3
+	wantAngle := math.Atan2(1/math.Sqrt2/3, 1/math.Sqrt2)
+	if math.Abs(math.Hypot(u, v)-wantSpeed) > 1e-5 || math.Abs(math.Atan2(v, u)-wantAngle) > 1e-5 {
+		t.Errorf("unexpected mean vector (%g, %g)", u, v)
+	}
+
+	if fastest := aggregate(models.StatMax); fastest.Values[0] != 2 || fastest.Values[1] != -2 {
+		t.Errorf("expected the fastest vector as max, got %v", fastest.Values)
+	}
+	if count := aggregate(models.StatCount); count.Values[0] != 3 || count.Values[1] != 3 {
+		t.Errorf("expected 3 valid vectors, got %v", count.Values)
+	}
+}
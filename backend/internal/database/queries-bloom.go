@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// SaveBloomEvents replaces the bloom events of measurementTime with events and records the
+// time as evaluated. The cells of each event are merged into a single outline, the saved
+// events are returned with it.
+func (s *service) SaveBloomEvents(ctx context.Context, measurementTime time.Time, events []models.BloomEvent) ([]models.BloomEvent, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM bloom_events WHERE measurement_time = $1", measurementTime); err != nil {
+		return nil, fmt.Errorf("error deleting bloom events: %w", err)
+	}
+	saved := make([]models.BloomEvent, 0, len(events))
+	for _, e := range events {
+		geom, err := geojson.NewGeometry(e.Geometry).MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding bloom event: %w", err)
+		}
+		row := tx.QueryRow(ctx, `
+            INSERT INTO bloom_events (measurement_time, rule, geom, area_km2, cells, mean_chlor_a, max_chlor_a, max_z_score)
+            VALUES ($1, $2, ST_Multi(ST_UnaryUnion(ST_SetSRID(ST_GeomFromGeoJSON($3), 4326))), $4, $5, $6, $7, $8)
+            RETURNING `+bloomEventColumns,
+			measurementTime, e.Rule, string(geom), e.AreaKm2, e.Cells, e.MeanChlorA, e.MaxChlorA, e.MaxZScore)
+		event, err := scanBloomEvent(row)
+		if err != nil {
+			return nil, fmt.Errorf("error saving bloom event: %w", err)
+		}
+		saved = append(saved, event)
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO bloom_detections (measurement_time, events)
+        VALUES ($1, $2)
+        ON CONFLICT (measurement_time) DO UPDATE SET events = EXCLUDED.events, detected_at = NOW()
+    `, measurementTime, len(events))
+	if err != nil {
+		return nil, fmt.Errorf("error recording bloom detection: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing bloom events: %w", err)
+	}
+	return saved, nil
+}
+
+// GetLatestBloomDetection returns the last measurement time the bloom rules were evaluated at,
+// the Unix epoch if they never were.
+func (s *service) GetLatestBloomDetection(ctx context.Context) (time.Time, error) {
+	var result time.Time
+	row := s.db.QueryRow(ctx, `
+        SELECT COALESCE(MAX(measurement_time), '1970-01-01'::timestamp)
+        FROM bloom_detections
+    `)
+	if err := row.Scan(&result); err != nil {
+		return time.Time{}, fmt.Errorf("error scanning row: %w", err)
+	}
+	return result, nil
+}
+
+// GetBloomEvents returns the events of the time range intersecting the bounding box and, if
+// it's not nil, the area, newest first. An empty rule returns the events of every rule.
+func (s *service) GetBloomEvents(ctx context.Context, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rule string) ([]models.BloomEvent, error) {
+	args := []any{startTime, endTime, rule, minLon, minLat, maxLon, maxLat}
+	filter := "ST_Intersects(geom, ST_MakeEnvelope($4, $5, $6, $7, 4326))"
+	if area != nil {
+		geom, err := geojson.NewGeometry(area).MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding area: %w", err)
+		}
+		filter += " AND ST_Intersects(geom, ST_MakeValid(ST_SetSRID(ST_GeomFromGeoJSON($8), 4326)))"
+		args = append(args, string(geom))
+	}
+	rows, err := s.db.Query(ctx, `
+        SELECT `+bloomEventColumns+`
+        FROM bloom_events
+        WHERE
+            measurement_time BETWEEN $1 AND $2
+            AND ($3 = '' OR rule = $3)
+            AND `+filter+`
+        ORDER BY measurement_time DESC, area_km2 DESC
+    `, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying bloom events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.BloomEvent
+	for rows.Next() {
+		event, err := scanBloomEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning bloom event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through bloom events: %w", err)
+	}
+	return events, nil
+}
+
+const bloomEventColumns = `id, measurement_time, rule, ST_AsBinary(geom), area_km2, cells, mean_chlor_a, max_chlor_a, max_z_score, detected_at`
+
+func scanBloomEvent(row rowScanner) (models.BloomEvent, error) {
+	var e models.BloomEvent
+	var geomBytes []byte
+	if err := row.Scan(&e.ID, &e.MeasurementTime, &e.Rule, &geomBytes, &e.AreaKm2, &e.Cells, &e.MeanChlorA, &e.MaxChlorA, &e.MaxZScore, &e.DetectedAt); err != nil {
+		return models.BloomEvent{}, err
+	}
+	geom, err := wkb.Unmarshal(geomBytes)
+	if err != nil {
+		return models.BloomEvent{}, fmt.Errorf("error unmarshalling bloom event geometry: %w", err)
+	}
+	outline, ok := geom.(orb.MultiPolygon)
+	if !ok {
+		return models.BloomEvent{}, fmt.Errorf("expected geometry multipolygon, got %T", geom)
+	}
+	e.Geometry = outline
+	return e, nil
+}
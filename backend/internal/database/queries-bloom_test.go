@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/paulmach/orb"
+)
+
+func TestBloomEvents(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	if err := resetTables(ctx, srv, "bloom_events", "bloom_detections"); err != nil {
+		t.Fatalf("could not reset tables: %v", err)
+	}
+
+	if latest, err := srv.GetLatestBloomDetection(ctx); err != nil || latest.Year() != 1970 {
+		t.Fatalf("expected no detection yet, got %v, %v", latest, err)
+	}
+
+	measured := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+	// two adjacent cells merged into a single outline
+	cells := orb.MultiPolygon{
+		orb.Bound{Min: orb.Point{2, 41}, Max: orb.Point{2.01, 41.01}}.ToPolygon(),
+		orb.Bound{Min: orb.Point{2.01, 41}, Max: orb.Point{2.02, 41.01}}.ToPolygon(),
+	}
+	z := 2.5
+	events := []models.BloomEvent{
+		{Rule: "high", Geometry: cells, AreaKm2: 1.9, Cells: 2, MeanChlorA: 3.5, MaxChlorA: 4},
+		{Rule: "anomaly", Geometry: cells[:1], AreaKm2: 0.9, Cells: 1, MeanChlorA: 4, MaxChlorA: 4, MaxZScore: &z},
+	}
+	saved, err := srv.SaveBloomEvents(ctx, measured, events)
+	if err != nil {
+		t.Fatalf("SaveBloomEvents() returned error: %v", err)
+	}
+	if len(saved) != 2 || saved[0].ID == 0 || len(saved[0].Geometry) != 1 {
+		t.Fatalf("expected 2 saved events, the first with a single outline, got %+v", saved)
+	}
+	if saved[1].MaxZScore == nil || *saved[1].MaxZScore != z || saved[0].MaxZScore != nil {
+		t.Errorf("expected the z-score of the anomaly only, got %v and %v", saved[0].MaxZScore, saved[1].MaxZScore)
+	}
+
+	// evaluating a time again replaces its events
+	if _, err := srv.SaveBloomEvents(ctx, measured, events[:1]); err != nil {
+		t.Fatalf("SaveBloomEvents() returned error: %v", err)
+	}
+	// a time without blooms is recorded as evaluated
+	if _, err := srv.SaveBloomEvents(ctx, measured.Add(24*time.Hour), nil); err != nil {
+		t.Fatalf("SaveBloomEvents() returned error: %v", err)
+	}
+	if latest, err := srv.GetLatestBloomDetection(ctx); err != nil || !latest.Equal(measured.Add(24*time.Hour)) {
+		t.Errorf("expected the last evaluated time, got %v, %v", latest, err)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		area  orb.MultiPolygon
+		bound orb.Bound
+		want  int
+	}{
+		{name: "Every rule", bound: orb.Bound{Min: orb.Point{1, 40}, Max: orb.Point{3, 42}}, want: 1},
+		{name: "Other rule", rule: "anomaly", bound: orb.Bound{Min: orb.Point{1, 40}, Max: orb.Point{3, 42}}, want: 0},
+		{name: "Outside of the bounding box", bound: orb.Bound{Min: orb.Point{1, 40}, Max: orb.Point{1.5, 40.5}}, want: 0},
+		{
+			name:  "Within an area",
+			area:  orb.MultiPolygon{orb.Bound{Min: orb.Point{2.015, 41.005}, Max: orb.Point{2.1, 41.1}}.ToPolygon()},
+			bound: orb.Bound{Min: orb.Point{1, 40}, Max: orb.Point{3, 42}},
+			want:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := srv.GetBloomEvents(ctx, measured, measured, tt.bound.Min.Lat(), tt.bound.Min.Lon(), tt.bound.Max.Lat(), tt.bound.Max.Lon(), tt.area, tt.rule)
+			if err != nil {
+				t.Fatalf("GetBloomEvents() returned error: %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("expected %d events, got %d", tt.want, len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrClimatologyTimeAdded is returned when an import holds a measurement time that was
+// already added to the climatology.
+var ErrClimatologyTimeAdded = errors.New("measurement time already added to the climatology")
+
+// climatologyStagingTable is the temporary table imported sums are copied into before they
+// are added to the climatology.
+const climatologyStagingTable = "climatology_staging"
+
+// climatologyMerge adds the sums of the rows inserted into the climatology to those stored.
+const climatologyMerge = `
+        ON CONFLICT (dataset, variable, latitude, longitude, day_of_year) DO UPDATE
+        SET
+            samples = climatology.samples + EXCLUDED.samples,
+            sum = climatology.sum + EXCLUDED.sum,
+            sum_squares = climatology.sum_squares + EXCLUDED.sum_squares,
+            updated_at = NOW()`
+
+// GetLatestClimatologyUpdate returns the last measurement time of the dataset accumulated
+// by AccumulateClimatology, the Unix epoch if none was. Imported times are left out, so
+// the stored times around them are still accumulated.
+func (s *service) GetLatestClimatologyUpdate(ctx context.Context, ds *datasets.Dataset) (time.Time, error) {
+	var result time.Time
+	row := s.db.QueryRow(ctx, `
+        SELECT COALESCE(MAX(measurement_time), '1970-01-01'::timestamp)
+        FROM climatology_updates
+        WHERE dataset = $1 AND source = 'update'
+    `, ds.Name)
+	if err := row.Scan(&result); err != nil {
+		return time.Time{}, fmt.Errorf("error scanning row: %w", err)
+	}
+	return result, nil
+}
+
+// AccumulateClimatology adds the observed values of measurementTime to the climatology of
+// the dataset and returns the number of values added. A measurement time that was already
+// accumulated or imported is skipped, so none is counted twice.
+func (s *service) AccumulateClimatology(ctx context.Context, ds *datasets.Dataset, measurementTime time.Time) (int, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO climatology_updates (dataset, measurement_time, samples)
+        VALUES ($1, $2, 0)
+        ON CONFLICT (dataset, measurement_time) DO NOTHING
+    `, ds.Name, measurementTime)
+	if err != nil {
+		return 0, fmt.Errorf("error recording climatology update: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, nil
+	}
+
+	var samples int
+	day := models.DayOfYear(measurementTime)
+	for _, column := range ds.Columns() {
+		// the raw table holds the observations only, interpolated values would narrow the climatology
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`
+            INSERT INTO climatology (dataset, variable, day_of_year, latitude, longitude, samples, sum, sum_squares)
+            SELECT
+                $1::TEXT, $2::TEXT, $3::SMALLINT,
+                ROUND(ST_Y(location::geometry)::numeric, 6)::FLOAT,
+                ROUND(ST_X(location::geometry)::numeric, 6)::FLOAT,
+                COUNT(*), SUM(%[1]s), SUM(%[1]s * %[1]s)
+            FROM %[2]s
+            WHERE measurement_time = $4 AND %[1]s <> 'NaN'
+            GROUP BY 4, 5
+            `+climatologyMerge, column, ds.RawTable), ds.Name, column, day, measurementTime)
+		if err != nil {
+			return 0, fmt.Errorf("error adding %s to the climatology: %w", column, err)
+		}
+		samples += int(tag.RowsAffected())
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE climatology_updates SET samples = $3
+        WHERE dataset = $1 AND measurement_time = $2
+    `, ds.Name, measurementTime, samples)
+	if err != nil {
+		return 0, fmt.Errorf("error recording climatology update: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing climatology update: %w", err)
+	}
+	return samples, nil
+}
+
+// GetClimatologyTimes returns the measurement times of the dataset added to the climatology,
+// accumulated or imported.
+func (s *service) GetClimatologyTimes(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT measurement_time
+        FROM climatology_updates
+        WHERE dataset = $1
+        ORDER BY measurement_time
+    `, ds.Name)
+	if err != nil {
+		return nil, fmt.Errorf("error querying %s climatology times: %w", ds.Name, err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("error scanning climatology time: %w", err)
+		}
+		times = append(times, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through climatology times: %w", err)
+	}
+	return times, nil
+}
+
+// AddClimatology adds sums computed elsewhere, e.g. from a long-term archive, to the
+// climatology of the dataset and records the measurement times they were computed from.
+// If any of the times was already added, nothing is and ErrClimatologyTimeAdded is returned.
+func (s *service) AddClimatology(ctx context.Context, ds *datasets.Dataset, times []models.ClimatologyTime, sums []models.ClimatologySums) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	measurementTimes := make([]time.Time, len(times))
+	samples := make([]int, len(times))
+	for i, t := range times {
+		measurementTimes[i], samples[i] = t.MeasurementTime, t.Samples
+	}
+	tag, err := tx.Exec(ctx, `
+        INSERT INTO climatology_updates (dataset, measurement_time, samples, source)
+        SELECT $1, t.measurement_time, t.samples, 'import'
+        FROM unnest($2::TIMESTAMPTZ[], $3::INTEGER[]) AS t(measurement_time, samples)
+        ON CONFLICT (dataset, measurement_time) DO NOTHING
+    `, ds.Name, measurementTimes, samples)
+	if err != nil {
+		return fmt.Errorf("error recording climatology import: %w", err)
+	}
+	if int(tag.RowsAffected()) != len(times) {
+		return ErrClimatologyTimeAdded
+	}
+
+	_, err = tx.Exec(ctx, `
+        CREATE TEMPORARY TABLE `+climatologyStagingTable+` (
+            variable TEXT NOT NULL,
+            day_of_year SMALLINT NOT NULL,
+            latitude FLOAT NOT NULL,
+            longitude FLOAT NOT NULL,
+            samples INTEGER NOT NULL,
+            sum FLOAT NOT NULL,
+            sum_squares FLOAT NOT NULL
+        ) ON COMMIT DROP
+    `)
+	if err != nil {
+		return fmt.Errorf("error creating climatology staging table: %w", err)
+	}
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{climatologyStagingTable},
+		[]string{"variable", "day_of_year", "latitude", "longitude", "samples", "sum", "sum_squares"},
+		pgx.CopyFromSlice(len(sums), func(i int) ([]any, error) {
+			s := sums[i]
+			return []any{s.Variable, s.DayOfYear, s.Latitude, s.Longitude, s.Samples, s.Sum, s.SumSquares}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("error copying climatology: %w", err)
+	}
+
+	// sums of the same cell and day are combined first, as ON CONFLICT can't update the same row twice
+	_, err = tx.Exec(ctx, `
+        INSERT INTO climatology (dataset, variable, day_of_year, latitude, longitude, samples, sum, sum_squares)
+        SELECT
+            $1::TEXT, variable, day_of_year,
+            ROUND(latitude::numeric, 6)::FLOAT,
+            ROUND(longitude::numeric, 6)::FLOAT,
+            SUM(samples), SUM(sum), SUM(sum_squares)
+        FROM `+climatologyStagingTable+`
+        GROUP BY 2, 3, 4, 5
+        `+climatologyMerge, ds.Name)
+	if err != nil {
+		return fmt.Errorf("error adding to the climatology: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing climatology: %w", err)
+	}
+	return nil
+}
+
+// GetClimatology returns the climatology of the dataset within the bounding box for each of
+// days, pooling the sums of the windowDays before and after every day, wrapping around the
+// end of the year.
+func (s *service) GetClimatology(ctx context.Context, ds *datasets.Dataset, days []int, windowDays int, minLat, minLon, maxLat, maxLon float64) ([]models.ClimatologyCell, error) {
+	// the bounding box is padded by the rounding of the stored coordinates
+	rows, err := s.db.Query(ctx, `
+        SELECT
+            c.variable,
+            d.day,
+            c.latitude,
+            c.longitude,
+            SUM(c.samples) AS samples,
+            SUM(c.sum) / SUM(c.samples) AS mean,
+            CASE WHEN SUM(c.samples) > 1
+                THEN SQRT(GREATEST((SUM(c.sum_squares) - SUM(c.sum) ^ 2 / SUM(c.samples)) / (SUM(c.samples) - 1), 0))
+                ELSE 0
+            END AS std
+        FROM unnest($2::INTEGER[]) AS d(day)
+        JOIN climatology c ON
+            LEAST(ABS(c.day_of_year - d.day), 365 - ABS(c.day_of_year - d.day)) <= $3
+        WHERE
+            c.dataset = $1
+            AND c.latitude BETWEEN $4::FLOAT - 1e-6 AND $6::FLOAT + 1e-6
+            AND c.longitude BETWEEN $5::FLOAT - 1e-6 AND $7::FLOAT + 1e-6
+        GROUP BY c.variable, d.day, c.latitude, c.longitude
+    `, ds.Name, days, windowDays, minLat, minLon, maxLat, maxLon)
+	if err != nil {
+		return nil, fmt.Errorf("error querying %s climatology: %w", ds.Name, err)
+	}
+	defer rows.Close()
+
+	var cells []models.ClimatologyCell
+	for rows.Next() {
+		var c models.ClimatologyCell
+		if err := rows.Scan(&c.Variable, &c.DayOfYear, &c.Latitude, &c.Longitude, &c.Samples, &c.Mean, &c.Std); err != nil {
+			return nil, fmt.Errorf("error scanning climatology: %w", err)
+		}
+		cells = append(cells, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through climatology: %w", err)
+	}
+	return cells, nil
+}
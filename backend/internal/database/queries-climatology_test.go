@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestClimatology(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "sst", "sst_climatology")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+	if err := resetTables(ctx, srv, "climatology", "climatology_updates"); err != nil {
+		t.Fatalf("could not reset tables: %v", err)
+	}
+
+	if latest, err := srv.GetLatestClimatologyUpdate(ctx, ds); err != nil || latest.Year() != 1970 {
+		t.Fatalf("expected no update yet, got %v, %v", latest, err)
+	}
+
+	// March 1 of a leap year and the next, then March 2, a missing value is left out
+	nan := float32(math.NaN())
+	times := []time.Time{
+		time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC),
+	}
+	var data []models.GridData
+	for i, v := range []float32{14, 16, 18} {
+		data = append(data,
+			models.GridData{MeasurementTime: times[i], Latitude: 41.025, Longitude: 2.025, Values: []float32{v}},
+			models.GridData{MeasurementTime: times[i], Latitude: 41.075, Longitude: 2.025, Values: []float32{nan}},
+		)
+	}
+	if _, err := srv.IngestDatasetData(ctx, ds, data); err != nil {
+		t.Fatalf("IngestDatasetData() returned error: %v", err)
+	}
+	for _, ts := range times {
+		if n, err := srv.AccumulateClimatology(ctx, ds, ts); err != nil || n != 1 {
+			t.Fatalf("expected a single value to be added at %v, got %d, %v", ts, n, err)
+		}
+	}
+	// adding a time again doesn't count it twice
+	if n, err := srv.AccumulateClimatology(ctx, ds, times[0]); err != nil || n != 0 {
+		t.Errorf("expected the time to be skipped, got %d, %v", n, err)
+	}
+	if latest, err := srv.GetLatestClimatologyUpdate(ctx, ds); err != nil || !latest.Equal(times[2]) {
+		t.Errorf("expected the last time added, got %v, %v", latest, err)
+	}
+
+	climatology := func(days []int, window int) []models.ClimatologyCell {
+		t.Helper()
+		cells, err := srv.GetClimatology(ctx, ds, days, window, 41, 2, 41.1, 2.1)
+		if err != nil {
+			t.Fatalf("GetClimatology() returned error: %v", err)
+		}
+		return cells
+	}
+	cells := climatology([]int{60}, 0)
+	if len(cells) != 1 || cells[0].Samples != 2 || cells[0].Mean != 15 || math.Abs(cells[0].Std-math.Sqrt2) > 1e-9 {
+		t.Fatalf("expected March 1 of both years, got %+v", cells)
+	}
+	if c := cells[0]; c.Variable != "sst" || c.DayOfYear != 60 || c.Latitude != 41.025 || c.Longitude != 2.025 {
+		t.Errorf("unexpected cell %+v", c)
+	}
+	// the window pools March 2
+	if cells := climatology([]int{60}, 1); len(cells) != 1 || cells[0].Samples != 3 || cells[0].Mean != 16 || cells[0].Std != 2 {
+		t.Errorf("expected the pooled days, got %+v", cells)
+	}
+	// the window wraps around the turn of the year
+	if cells := climatology([]int{1}, 365); len(cells) != 1 || cells[0].DayOfYear != 1 || cells[0].Samples != 3 {
+		t.Errorf("expected every day, got %+v", cells)
+	}
+	if cells := climatology([]int{200}, 7); len(cells) != 0 {
+		t.Errorf("expected no climatology in summer, got %+v", cells)
+	}
+
+	// imported sums are added to the accumulated ones, rounding coordinates to the grid
+	imported := []models.ClimatologyTime{
+		{MeasurementTime: time.Date(2015, 3, 1, 12, 0, 0, 0, time.UTC), Samples: 2},
+		{MeasurementTime: time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC), Samples: 1},
+	}
+	sums := []models.ClimatologySums{
+		{Variable: "sst", DayOfYear: 60, Latitude: 41.0250000001, Longitude: 2.025, Samples: 2, Sum: 30, SumSquares: 452},
+		{Variable: "sst", DayOfYear: 60, Latitude: 41.025, Longitude: 2.025, Samples: 1, Sum: 15, SumSquares: 225},
+	}
+	if err := srv.AddClimatology(ctx, ds, imported, sums); err != nil {
+		t.Fatalf("AddClimatology() returned error: %v", err)
+	}
+	if cells := climatology([]int{60}, 0); len(cells) != 1 || cells[0].Samples != 5 || cells[0].Mean != 15 {
+		t.Errorf("expected the imported sums to be added, got %+v", cells)
+	}
+	// imported times don't move the last accumulated one
+	if latest, err := srv.GetLatestClimatologyUpdate(ctx, ds); err != nil || !latest.Equal(times[2]) {
+		t.Errorf("expected the last accumulated time, got %v, %v", latest, err)
+	}
+	if added, err := srv.GetClimatologyTimes(ctx, ds); err != nil || len(added) != 5 {
+		t.Errorf("expected the accumulated and imported times, got %v, %v", added, err)
+	}
+
+	// importing a time again, or one the updater accumulated, adds nothing
+	for _, overlap := range []time.Time{imported[0].MeasurementTime, times[0]} {
+		err := srv.AddClimatology(ctx, ds, []models.ClimatologyTime{{MeasurementTime: overlap, Samples: 2}}, sums)
+		if !errors.Is(err, ErrClimatologyTimeAdded) {
+			t.Errorf("expected ErrClimatologyTimeAdded for %v, got %v", overlap, err)
+		}
+	}
+	if cells := climatology([]int{60}, 0); len(cells) != 1 || cells[0].Samples != 5 {
+		t.Errorf("expected the climatology to be unchanged, got %+v", cells)
+	}
+}
@@ -0,0 +1,571 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// stagingTable is the temporary table data is copied into before merging it into a dataset table.
+const stagingTable = "grid_data_staging"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// selectColumns returns the column list shared by all dataset SELECT queries.
+// Raw tables hold observed data only and have no provenance columns.
+func selectColumns(ds *datasets.Dataset, rawData bool) string {
+	provenance := "provenance, COALESCE(interpolation_method, ''), interpolation_variance"
+	if rawData {
+		provenance = fmt.Sprintf("'%s', '', NULL::FLOAT", models.ProvenanceObserved)
+	}
+	return fmt.Sprintf(`
+                id,
+                measurement_time,
+                ST_Y(location::geometry) as latitude,
+                ST_X(location::geometry) as longitude,
+                %s,
+                %s,
+                created_at`, strings.Join(ds.Columns(), ", "), provenance)
+}
+
+func scanGridData(row rowScanner, ds *datasets.Dataset) (models.GridData, error) {
+	d := models.GridData{Values: make([]float32, len(ds.Variables))}
+	dest := []any{&d.ID, &d.MeasurementTime, &d.Latitude, &d.Longitude}
+	for i := range d.Values {
+		dest = append(dest, &d.Values[i])
+	}
+	dest = append(dest, &d.Provenance, &d.InterpolationMethod, &d.InterpolationVariance, &d.CreatedAt)
+	err := row.Scan(dest...)
+	return d, err
+}
+
+// IngestDatasetData saves downloaded data to both dataset tables and records the
+// ingestion run in a single transaction, so the tables never diverge when a save fails.
+func (s *service) IngestDatasetData(ctx context.Context, ds *datasets.Dataset, data []models.GridData) (models.IngestionRun, error) {
+	run := models.IngestionRun{Dataset: ds.Name, Points: len(data)}
+	for i, d := range data {
+		if i == 0 || d.MeasurementTime.Before(run.StartTime) {
+			run.StartTime = d.MeasurementTime
+		}
+		if i == 0 || d.MeasurementTime.After(run.EndTime) {
+			run.EndTime = d.MeasurementTime
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return run, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := copyToStaging(ctx, tx, ds, data); err != nil {
+		return run, err
+	}
+	if err := mergeStaging(ctx, tx, ds, ds.Table); err != nil {
+		return run, err
+	}
+	if err := mergeStaging(ctx, tx, ds, ds.RawTable); err != nil {
+		return run, err
+	}
+
+	query := `
+        INSERT INTO ingestion_runs (dataset, start_time, end_time, points)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at
+    `
+	err = tx.QueryRow(ctx, query, run.Dataset, run.StartTime, run.EndTime, run.Points).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return run, fmt.Errorf("error recording ingestion run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return run, fmt.Errorf("error commiting transaction: %w", err)
+	}
+	return run, nil
+}
+
+// copyToStaging creates the staging table of the transaction and copies data into it.
+func copyToStaging(ctx context.Context, tx pgx.Tx, ds *datasets.Dataset, data []models.GridData) error {
+	columns := ds.Columns()
+	definitions := make([]string, len(columns))
+	for i, column := range columns {
+		definitions[i] = column + " FLOAT"
+	}
+	if _, err := tx.Exec(ctx, "DROP TABLE IF EXISTS "+stagingTable); err != nil {
+		return fmt.Errorf("error dropping staging table: %w", err)
+	}
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+        CREATE TEMPORARY TABLE %s (
+            measurement_time TIMESTAMP WITH TIME ZONE NOT NULL,
+            longitude FLOAT NOT NULL,
+            latitude FLOAT NOT NULL,
+            %s
+        ) ON COMMIT DROP
+        `, stagingTable, strings.Join(definitions, ",\n            ")))
+	if err != nil {
+		return fmt.Errorf("error creating staging table: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{stagingTable},
+		append([]string{"measurement_time", "longitude", "latitude"}, columns...),
+		pgx.CopyFromSlice(len(data), func(i int) ([]any, error) {
+			row := make([]any, 0, 3+len(data[i].Values))
+			row = append(row, data[i].MeasurementTime, data[i].Longitude, data[i].Latitude)
+			for _, v := range data[i].Values {
+				row = append(row, v)
+			}
+			return row, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("error copying data: %w", err)
+	}
+	return nil
+}
+
+// mergeStaging upserts the staging table into table.
+func mergeStaging(ctx context.Context, tx pgx.Tx, ds *datasets.Dataset, table string) error {
+	columns := ds.Columns()
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", column, column)
+	}
+	if table == ds.Table {
+		// downloaded values replace interpolated ones, so they are observed again
+		updates = append(updates, "provenance = EXCLUDED.provenance", "interpolation_method = NULL", "interpolation_variance = NULL")
+	}
+	// DISTINCT ON keeps a single row per time and location, as ON CONFLICT can't
+	// update the same row twice in one statement
+	_, err := tx.Exec(ctx, fmt.Sprintf(`
+        INSERT INTO %s (measurement_time, location, %s)
+        SELECT DISTINCT ON (measurement_time, longitude, latitude)
+            measurement_time,
+            ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography,
+            %s
+        FROM %s
+        ON CONFLICT (measurement_time, location) DO UPDATE
+        SET %s
+        `, table, strings.Join(columns, ", "), strings.Join(columns, ", "), stagingTable, strings.Join(updates, ", ")))
+	if err != nil {
+		return fmt.Errorf("error merging data into %s: %w", table, err)
+	}
+	return nil
+}
+
+// locationFilter returns the condition selecting rows within the bounding box and, if it's
+// not nil, the area, leaving out rows on land if maskLand is set. Its placeholders are
+// numbered from firstParam on and take the returned arguments.
+func locationFilter(firstParam int, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, maskLand bool) (string, []any, error) {
+	n := firstParam
+	filter := fmt.Sprintf(`ST_Intersects(
+                    location::geometry,
+                    ST_MakeEnvelope(
+                        $%d, $%d, $%d, $%d, 4326
+                    )
+                )`, n, n+1, n+2, n+3)
+	args := []any{minLon, minLat, maxLon, maxLat}
+	if area != nil {
+		geom, err := geojson.NewGeometry(area).MarshalJSON()
+		if err != nil {
+			return "", nil, fmt.Errorf("error encoding area: %w", err)
+		}
+		// outlines drawn by hand may cross themselves, which the intersection can't handle
+		filter += fmt.Sprintf(`
+                AND ST_Intersects(
+                    location::geometry,
+                    ST_MakeValid(ST_SetSRID(ST_GeomFromGeoJSON($%d), 4326))
+                )`, n+4)
+		args = append(args, string(geom))
+	}
+	if maskLand {
+		filter += "\n                AND " + notOnLand
+	}
+	return filter, args, nil
+}
+
+// GetDatasetData returns the rows of the time range within the bounding box and, if it's
+// not nil, the area, ordered by measurement time.
+func (s *service) GetDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.GridData, error) {
+	var result []models.GridData
+	err := s.StreamDatasetData(ctx, ds, startTime, endTime, minLat, minLon, maxLat, maxLon, area, rawData, maskLand, func(d models.GridData) error {
+		result = append(result, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamDatasetData calls fn for every row GetDatasetData would return, as they are read
+// from the database cursor. Iteration stops at the first error fn returns.
+func (s *service) StreamDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool, fn func(models.GridData) error) error {
+	table := ds.Table
+	if rawData {
+		table = ds.RawTable
+	}
+	filter, args, err := locationFilter(3, minLat, minLon, maxLat, maxLon, area, maskLand)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+            SELECT %s
+            FROM
+                %s
+            WHERE
+                measurement_time BETWEEN $1 AND $2
+                AND %s
+            ORDER BY
+                measurement_time
+            `, selectColumns(ds, rawData), table, filter)
+	rows, err := s.db.Query(ctx, query, append([]any{startTime, endTime}, args...)...)
+	if err != nil {
+		return fmt.Errorf("error quering for %s data: %w", ds.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d, err := scanGridData(rows, ds)
+		if err != nil {
+			return fmt.Errorf("error scanning %s data: %w", ds.Name, err)
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating through %s rows: %w", ds.Name, err)
+	}
+	return nil
+}
+
+func (s *service) GetLatestDatasetTimestamp(ctx context.Context, ds *datasets.Dataset) (time.Time, error) {
+	query := fmt.Sprintf(`
+        SELECT
+            COALESCE(MAX(measurement_time), '1970-01-01'::timestamp)
+        FROM
+            %s
+    `, ds.Table)
+	var result time.Time
+	row := s.db.QueryRow(ctx, query)
+	if err := row.Scan(&result); err != nil {
+		return time.Time{}, fmt.Errorf("error scanning row: %w", err)
+	}
+	return result, nil
+}
+
+func (s *service) GetAllDatasetLocations(ctx context.Context, ds *datasets.Dataset) ([]orb.Point, error) {
+	query := fmt.Sprintf(`
+        SELECT DISTINCT ST_AsBinary(location) as geom
+        FROM %s
+    `, ds.Table)
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error finding locations: %w", err)
+	}
+	return scanLocations(rows)
+}
+
+// scanLocations reads rows holding a single WKB point each.
+func scanLocations(rows pgx.Rows) ([]orb.Point, error) {
+	defer rows.Close()
+
+	var locations []orb.Point
+	for rows.Next() {
+		var geomBytes []byte
+		if err := rows.Scan(&geomBytes); err != nil {
+			return nil, fmt.Errorf("error scanning location with gap: %w", err)
+		}
+		geom, err := wkb.Unmarshal(geomBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling location point: %w", err)
+		}
+		point, ok := geom.(orb.Point)
+		if !ok {
+			return nil, fmt.Errorf("expected geometry point, got %T", geom)
+		}
+		locations = append(locations, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through locations: %w", err)
+	}
+	return locations, nil
+}
+
+func (s *service) GetAllDatasetTimestamps(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error) {
+	query := fmt.Sprintf(`
+        SELECT DISTINCT measurement_time
+        FROM %s
+        ORDER BY measurement_time
+    `, ds.Table)
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error finding timestamps: %w", err)
+	}
+	defer rows.Close()
+
+	var timestamps []time.Time
+	for rows.Next() {
+		var ts time.Time
+		if err := rows.Scan(&ts); err != nil {
+			return nil, fmt.Errorf("failed to scan timestamp: %w", err)
+		}
+		timestamps = append(timestamps, ts)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return timestamps, nil
+}
+
+func (s *service) GetDatasetDataAtLocation(ctx context.Context, ds *datasets.Dataset, point orb.Point) ([]models.GridData, error) {
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM
+            %s
+        WHERE
+            ST_Equals(
+                location::geometry,
+                ST_SetSRID(
+                    ST_MakePoint($1, $2),
+                    4326
+                )
+            )
+        ORDER BY
+            measurement_time
+    `, selectColumns(ds, false), ds.Table)
+	rows, err := s.db.Query(ctx, query, point[0], point[1])
+	if err != nil {
+		return nil, fmt.Errorf("error finding %s data at point (%f, %f): %w",
+			ds.Name, point[0], point[1], err)
+	}
+	defer rows.Close()
+
+	var results []models.GridData
+	for rows.Next() {
+		data, err := scanGridData(rows, ds)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning %s data: %w", ds.Name, err)
+		}
+
+		results = append(results, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// maxTimeSeriesDistance is how far in meters the grid cell of a time series may be from the
+// requested point, about a cell of the coarsest dataset, the 0.25° currents.
+const maxTimeSeriesDistance = 30000
+
+// GetDatasetTimeSeries returns the data between startTime and endTime at the grid cell
+// of the dataset nearest to point, nothing if no cell is within maxTimeSeriesDistance.
+func (s *service) GetDatasetTimeSeries(ctx context.Context, ds *datasets.Dataset, point orb.Point, startTime, endTime time.Time, rawData bool) ([]models.GridData, error) {
+	table := ds.Table
+	if rawData {
+		table = ds.RawTable
+	}
+	query := fmt.Sprintf(`
+        WITH nearest AS (
+            SELECT location
+            FROM %s
+            WHERE ST_DWithin(location, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, $5)
+            ORDER BY location <-> ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography
+            LIMIT 1
+        )
+        SELECT %s
+        FROM
+            %s
+        WHERE
+            ST_Equals(location::geometry, (SELECT location::geometry FROM nearest))
+            AND measurement_time BETWEEN $3 AND $4
+        ORDER BY
+            measurement_time
+    `, table, selectColumns(ds, rawData), table)
+	rows, err := s.db.Query(ctx, query, point[0], point[1], startTime, endTime, maxTimeSeriesDistance)
+	if err != nil {
+		return nil, fmt.Errorf("error finding %s time series near point (%f, %f): %w",
+			ds.Name, point[0], point[1], err)
+	}
+	defer rows.Close()
+
+	var results []models.GridData
+	for rows.Next() {
+		data, err := scanGridData(rows, ds)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning %s data: %w", ds.Name, err)
+		}
+		results = append(results, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return results, nil
+}
+
+func (s *service) GetDatasetDataAtTimestamp(ctx context.Context, ds *datasets.Dataset, timestamp time.Time) ([][]models.GridData, error) {
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM
+            %s
+        WHERE
+            measurement_time = $1
+        ORDER BY
+            latitude DESC, longitude ASC -- Order by latitude (descending) and longitude (ascending)
+    `, selectColumns(ds, false), ds.Table)
+	resultRows, err := s.db.Query(ctx, query, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving %s data at timestamp %s: %w",
+			ds.Name, timestamp.Format(time.RFC3339), err)
+	}
+	defer resultRows.Close()
+
+	var dataList []models.GridData
+	for resultRows.Next() {
+		data, err := scanGridData(resultRows, ds)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning %s data: %w", ds.Name, err)
+		}
+
+		dataList = append(dataList, data)
+	}
+
+	if err = resultRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return buildGrid(dataList, len(ds.Variables)), nil
+}
+
+// buildGrid organizes a flat list of data into a 2D grid based on latitude and longitude.
+// This assumes the data points form a somewhat regular grid.
+// Cells without data hold zero values for all of the dataset variables.
+func buildGrid(dataList []models.GridData, variables int) [][]models.GridData {
+	// 1. Get unique latitudes and longitudes and their sorted order
+	uniqueLatitudesMap := make(map[float64]struct{})
+	uniqueLongitudesMap := make(map[float64]struct{})
+	for _, data := range dataList {
+		uniqueLatitudesMap[data.Latitude] = struct{}{}
+		uniqueLongitudesMap[data.Longitude] = struct{}{}
+	}
+
+	var uniqueLatitudes []float64
+	for lat := range uniqueLatitudesMap {
+		uniqueLatitudes = append(uniqueLatitudes, lat)
+	}
+	// Sort latitudes in descending order
+	// This assumes higher latitudes are "further north" in grid
+	sort.Slice(uniqueLatitudes, func(i, j int) bool {
+		return uniqueLatitudes[i] > uniqueLatitudes[j]
+	})
+
+	var uniqueLongitudes []float64
+	for lon := range uniqueLongitudesMap {
+		uniqueLongitudes = append(uniqueLongitudes, lon)
+	}
+	// Sort longitudes in ascending order
+	sort.Float64s(uniqueLongitudes)
+
+	// Create a map to quickly find the index of a latitude or longitude
+	latIndexMap := make(map[float64]int)
+	for i, lat := range uniqueLatitudes {
+		latIndexMap[lat] = i
+	}
+
+	lonIndexMap := make(map[float64]int)
+	for i, lon := range uniqueLongitudes {
+		lonIndexMap[lon] = i
+	}
+
+	// Initialize the 2D grid
+	rows := len(uniqueLatitudes)
+	cols := len(uniqueLongitudes)
+	grid := make([][]models.GridData, rows)
+	for i := range grid {
+		grid[i] = make([]models.GridData, cols)
+		for j := range grid[i] {
+			grid[i][j].Values = make([]float32, variables)
+		}
+	}
+
+	// Populate the grid, points whose coordinates match no row or column (NaN) are dropped
+	var dropped int
+	for _, data := range dataList {
+		latIndex, latOk := latIndexMap[data.Latitude]
+		lonIndex, lonOk := lonIndexMap[data.Longitude]
+
+		if latOk && lonOk {
+			// Place the data at the calculated position in the grid
+			grid[latIndex][lonIndex] = data
+		} else {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		slog.Warn("Dropped data points with unexpected coordinates", "points", dropped)
+	}
+
+	return grid
+}
+
+func (s *service) UpdateDatasetData(ctx context.Context, ds *datasets.Dataset, data []models.GridData) error {
+	columns := ds.Columns()
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	n := len(columns)
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET %s, provenance = $%d, interpolation_method = NULLIF($%d, ''), interpolation_variance = $%d
+        WHERE id = $%d
+    `, ds.Table, strings.Join(assignments, ", "), n+1, n+2, n+3, n+4)
+
+	args := make([]any, n+4)
+	for _, d := range data {
+		for i, v := range d.Values {
+			args[i] = v
+		}
+		args[n], args[n+1], args[n+2], args[n+3] = d.Provenance, d.InterpolationMethod, d.InterpolationVariance, d.ID
+		_, err := s.db.Exec(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("error updating %s data: %w", ds.Name, err)
+		}
+	}
+	return nil
+}
+
+// CleanupDatasetData removes data older than the dataset retention from both dataset tables.
+func (s *service) CleanupDatasetData(ctx context.Context, ds *datasets.Dataset) error {
+	cutoff := time.Now().UTC().Add(-ds.Retention)
+	for _, table := range []string{ds.Table, ds.RawTable} {
+		query := fmt.Sprintf(`
+            DELETE FROM %s
+            WHERE measurement_time < $1
+        `, table)
+		if _, err := s.db.Exec(ctx, query, cutoff); err != nil {
+			return fmt.Errorf("error cleaning up %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+// newGridData returns a days x size x size grid of random values starting at start.
+func newGridData(ds *datasets.Dataset, start time.Time, days, size int) []models.GridData {
+	var data []models.GridData
+	for d := 0; d < days; d++ {
+		for lat := 0; lat < size; lat++ {
+			for lon := 0; lon < size; lon++ {
+				values := make([]float32, len(ds.Variables))
+				for i := range values {
+					values[i] = rand.Float32()
+				}
+				data = append(data, models.GridData{
+					MeasurementTime: start.Add(time.Duration(d) * 24 * time.Hour),
+					Latitude:        40.5 + float64(lat)*0.01,
+					Longitude:       1.1 + float64(lon)*0.01,
+					Values:          values,
+				})
+			}
+		}
+	}
+	return data
+}
+
+// ingestPerRow is the previous way of saving downloaded data, inserting one row
+// at a time in a transaction per table, kept to compare against copying through the staging table.
+func (s *service) ingestPerRow(ctx context.Context, ds *datasets.Dataset, data []models.GridData) error {
+	for _, table := range []string{ds.Table, ds.RawTable} {
+		if err := s.insertPerRow(ctx, table, ds.Columns(), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) insertPerRow(ctx context.Context, table string, columns []string, data []models.GridData) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+4)
+	}
+	query := fmt.Sprintf(`
+        INSERT INTO %s (measurement_time, location, %s)
+        VALUES ($1, ST_SetSRID(ST_MakePoint($2, $3), 4326)::geography, %s)
+        `, table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+
+	args := make([]any, 3+len(columns))
+	for _, d := range data {
+		args[0], args[1], args[2] = d.MeasurementTime, d.Longitude, d.Latitude
+		for i, v := range d.Values {
+			args[3+i] = v
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func countRows(ctx context.Context, s *service, table string) (int, error) {
+	var count int
+	err := s.db.QueryRow(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count)
+	return count, err
+}
+
+func TestIngestDatasetDataIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "currents", "currents_idempotent")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := newGridData(ds, start, 2, 10)
+	for range 2 {
+		if _, err := srv.IngestDatasetData(ctx, ds, data); err != nil {
+			t.Fatalf("IngestDatasetData() returned error: %v", err)
+		}
+	}
+
+	for _, rawData := range []bool{false, true} {
+		stored, err := srv.GetDatasetData(ctx, ds, start, start.Add(48*time.Hour), 40, 1, 42, 3, nil, rawData, false)
+		if err != nil {
+			t.Fatalf("GetDatasetData() returned error: %v", err)
+		}
+		if len(stored) != len(data) {
+			t.Fatalf("expected %d rows (raw: %t), got %d", len(data), rawData, len(stored))
+		}
+	}
+}
+
+func TestIngestDatasetDataRollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "currents", "currents_failing")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+	// saving to the raw table fails after the interpolated table was written
+	if _, err := srv.db.Exec(ctx, "DROP TABLE "+ds.RawTable); err != nil {
+		t.Fatalf("could not drop raw table: %v", err)
+	}
+
+	data := newGridData(ds, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 1, 10)
+	if _, err := srv.IngestDatasetData(ctx, ds, data); err == nil {
+		t.Fatal("expected IngestDatasetData() to fail")
+	}
+
+	count, err := countRows(ctx, srv, ds.Table)
+	if err != nil {
+		t.Fatalf("could not count rows: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected interpolated table to be rolled back, got %d rows", count)
+	}
+	var runs int
+	err = srv.db.QueryRow(ctx, "SELECT COUNT(*) FROM ingestion_runs WHERE dataset = $1", ds.Name).Scan(&runs)
+	if err != nil {
+		t.Fatalf("could not count ingestion runs: %v", err)
+	}
+	if runs != 0 {
+		t.Errorf("expected no ingestion run to be recorded, got %d", runs)
+	}
+}
+
+func TestGetDatasetTimeSeries(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "sst", "sst_time_series")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := srv.IngestDatasetData(ctx, ds, newGridData(ds, start, 2, 10)); err != nil {
+		t.Fatalf("IngestDatasetData() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		point orb.Point
+		want  int
+	}{
+		{name: "Cell nearest to the point", point: orb.Point{1.152, 40.548}, want: 2},
+		{name: "35 km off the grid", point: orb.Point{1.6, 40.55}, want: 0},
+		{name: "Far away", point: orb.Point{0, 0}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := srv.GetDatasetTimeSeries(ctx, ds, tt.point, start, start.Add(24*time.Hour), false)
+			if err != nil {
+				t.Fatalf("GetDatasetTimeSeries() returned error: %v", err)
+			}
+			if len(data) != tt.want {
+				t.Fatalf("expected %d rows, got %d", tt.want, len(data))
+			}
+			for _, d := range data {
+				if math.Abs(d.Latitude-40.55) > 1e-6 || math.Abs(d.Longitude-1.15) > 1e-6 {
+					t.Errorf("expected the cell at 40.55, 1.15, got %v, %v", d.Latitude, d.Longitude)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkIngest(b *testing.B, ingest func(*service, context.Context, *datasets.Dataset, []models.GridData) error) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "chlorophyll", "chlorophyll_benchmark")
+	if err != nil {
+		b.Fatalf("could not create tables: %v", err)
+	}
+	// one day of a 100x100 grid, roughly the size of a daily OBSEA download
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b.ResetTimer()
+	rows := 0
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		data := newGridData(ds, start.Add(time.Duration(i)*24*time.Hour), 1, 100)
+		b.StartTimer()
+		if err := ingest(srv, ctx, ds, data); err != nil {
+			b.Fatalf("error saving data: %v", err)
+		}
+		rows += len(data)
+	}
+	b.ReportMetric(float64(rows)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func BenchmarkIngestCopy(b *testing.B) {
+	benchmarkIngest(b, func(s *service, ctx context.Context, ds *datasets.Dataset, data []models.GridData) error {
+		_, err := s.IngestDatasetData(ctx, ds, data)
+		return err
+	})
+}
+
+func BenchmarkIngestPerRow(b *testing.B) {
+	benchmarkIngest(b, (*service).ingestPerRow)
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// notOnLand filters out rows whose location is covered by any land mask.
+const notOnLand = `NOT EXISTS (
+                    SELECT 1 FROM land_mask m
+                    WHERE ST_Intersects(m.geom, location::geometry)
+                )`
+
+// SaveLandMask stores the mask under name, replacing a previously saved mask of the same name.
+func (s *service) SaveLandMask(ctx context.Context, name string, mask orb.MultiPolygon) error {
+	geom, err := geojson.NewGeometry(mask).MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("error encoding land mask: %w", err)
+	}
+	_, err = s.db.Exec(ctx, `
+        INSERT INTO land_mask (name, geom)
+        VALUES ($1, ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)))
+        ON CONFLICT (name) DO UPDATE
+        SET geom = EXCLUDED.geom, created_at = NOW()
+    `, name, string(geom))
+	if err != nil {
+		return fmt.Errorf("error saving land mask %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetDatasetLandLocations returns the locations of the dataset covered by any land mask.
+func (s *service) GetDatasetLandLocations(ctx context.Context, ds *datasets.Dataset) ([]orb.Point, error) {
+	query := fmt.Sprintf(`
+        SELECT DISTINCT ST_AsBinary(d.location) as geom
+        FROM %s d
+        JOIN land_mask m ON ST_Intersects(m.geom, d.location::geometry)
+    `, ds.Table)
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error finding land locations: %w", err)
+	}
+	return scanLocations(rows)
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+func TestLandMask(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "sst", "sst_land_mask")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := srv.IngestDatasetData(ctx, ds, newGridData(ds, start, 1, 10)); err != nil {
+		t.Fatalf("IngestDatasetData() returned error: %v", err)
+	}
+
+	// covers the 5 westernmost columns of the 10 x 10 grid
+	mask := orb.MultiPolygon{{{{1.095, 40.4}, {1.145, 40.4}, {1.145, 40.7}, {1.095, 40.7}, {1.095, 40.4}}}}
+	// saving twice replaces the mask
+	for range 2 {
+		if err := srv.SaveLandMask(ctx, "test", mask); err != nil {
+			t.Fatalf("SaveLandMask() returned error: %v", err)
+		}
+	}
+
+	land, err := srv.GetDatasetLandLocations(ctx, ds)
+	if err != nil {
+		t.Fatalf("GetDatasetLandLocations() returned error: %v", err)
+	}
+	if len(land) != 50 {
+		t.Errorf("expected 50 land locations, got %d", len(land))
+	}
+
+	for _, tt := range []struct {
+		maskLand bool
+		want     int
+	}{{false, 100}, {true, 50}} {
+		data, err := srv.GetDatasetData(ctx, ds, start, start, 40, 1, 42, 3, nil, false, tt.maskLand)
+		if err != nil {
+			t.Fatalf("GetDatasetData() returned error: %v", err)
+		}
+		if len(data) != tt.want {
+			t.Errorf("expected %d rows (mask land: %t), got %d", tt.want, tt.maskLand, len(data))
+		}
+	}
+}
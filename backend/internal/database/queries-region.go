@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/geojson"
+)
+
+var (
+	ErrRegionNotFound = errors.New("region not found")
+	ErrRegionExists   = errors.New("a region with this name already exists")
+)
+
+// uniqueViolation is the PostgreSQL error code of inserts breaking a UNIQUE constraint.
+const uniqueViolation = "23505"
+
+// SaveRegion stores area under name, failing with ErrRegionExists if the name is taken.
+// Self-intersecting outlines are repaired before they are stored.
+func (s *service) SaveRegion(ctx context.Context, name string, area orb.MultiPolygon) (models.Region, error) {
+	geom, err := geojson.NewGeometry(area).MarshalJSON()
+	if err != nil {
+		return models.Region{}, fmt.Errorf("error encoding region %s: %w", name, err)
+	}
+	row := s.db.QueryRow(ctx, `
+        INSERT INTO regions (name, geom)
+        VALUES ($1, ST_Multi(ST_CollectionExtract(ST_MakeValid(ST_SetSRID(ST_GeomFromGeoJSON($2), 4326)), 3)))
+        RETURNING id, name, ST_AsBinary(geom), created_at
+    `, name, string(geom))
+	region, err := scanRegion(row)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return models.Region{}, ErrRegionExists
+	}
+	if err != nil {
+		return models.Region{}, fmt.Errorf("error saving region %s: %w", name, err)
+	}
+	return region, nil
+}
+
+// GetRegion returns the region with id, ErrRegionNotFound if there is none.
+func (s *service) GetRegion(ctx context.Context, id int) (models.Region, error) {
+	row := s.db.QueryRow(ctx, `
+        SELECT id, name, ST_AsBinary(geom), created_at
+        FROM regions
+        WHERE id = $1
+    `, id)
+	region, err := scanRegion(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Region{}, ErrRegionNotFound
+	}
+	if err != nil {
+		return models.Region{}, fmt.Errorf("error querying region %d: %w", id, err)
+	}
+	return region, nil
+}
+
+// GetRegions returns every saved region ordered by name.
+func (s *service) GetRegions(ctx context.Context) ([]models.Region, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT id, name, ST_AsBinary(geom), created_at
+        FROM regions
+        ORDER BY name
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("error querying regions: %w", err)
+	}
+	defer rows.Close()
+
+	var regions []models.Region
+	for rows.Next() {
+		region, err := scanRegion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning region: %w", err)
+		}
+		regions = append(regions, region)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through regions: %w", err)
+	}
+	return regions, nil
+}
+
+// DeleteRegion removes the region with id, ErrRegionNotFound if there is none.
+func (s *service) DeleteRegion(ctx context.Context, id int) error {
+	tag, err := s.db.Exec(ctx, "DELETE FROM regions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting region %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRegionNotFound
+	}
+	return nil
+}
+
+func scanRegion(row rowScanner) (models.Region, error) {
+	var region models.Region
+	var geomBytes []byte
+	if err := row.Scan(&region.ID, &region.Name, &geomBytes, &region.CreatedAt); err != nil {
+		return models.Region{}, err
+	}
+	geom, err := wkb.Unmarshal(geomBytes)
+	if err != nil {
+		return models.Region{}, fmt.Errorf("error unmarshalling region geometry: %w", err)
+	}
+	area, ok := geom.(orb.MultiPolygon)
+	if !ok {
+		return models.Region{}, fmt.Errorf("expected geometry multipolygon, got %T", geom)
+	}
+	region.Geometry = area
+	return region, nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+func TestRegions(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	ds, err := newTestDataset(ctx, srv, "sst", "sst_regions")
+	if err != nil {
+		t.Fatalf("could not create tables: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := srv.IngestDatasetData(ctx, ds, newGridData(ds, start, 1, 10)); err != nil {
+		t.Fatalf("IngestDatasetData() returned error: %v", err)
+	}
+
+	// a triangle over the south-western half of the 10 x 10 grid, its diagonal between cells
+	area := orb.MultiPolygon{{{{1.095, 40.495}, {1.2, 40.495}, {1.095, 40.6}, {1.095, 40.495}}}}
+	region, err := srv.SaveRegion(ctx, "triangle", area)
+	if err != nil {
+		t.Fatalf("SaveRegion() returned error: %v", err)
+	}
+	if _, err := srv.SaveRegion(ctx, "triangle", area); !errors.Is(err, ErrRegionExists) {
+		t.Errorf("expected ErrRegionExists saving a taken name, got %v", err)
+	}
+
+	stored, err := srv.GetRegion(ctx, region.ID)
+	if err != nil {
+		t.Fatalf("GetRegion() returned error: %v", err)
+	}
+	if stored.Name != "triangle" || stored.Geometry.Bound() != area.Bound() {
+		t.Errorf("unexpected region %+v", stored)
+	}
+
+	bound := area.Bound()
+	data, err := srv.GetDatasetData(ctx, ds, start, start, bound.Min.Lat(), bound.Min.Lon(), bound.Max.Lat(), bound.Max.Lon(), stored.Geometry, false, false)
+	if err != nil {
+		t.Fatalf("GetDatasetData() returned error: %v", err)
+	}
+	// cells on or below the diagonal of the triangle
+	if len(data) != 55 {
+		t.Errorf("expected 55 rows within the region, got %d", len(data))
+	}
+
+	if err := srv.DeleteRegion(ctx, region.ID); err != nil {
+		t.Fatalf("DeleteRegion() returned error: %v", err)
+	}
+	if _, err := srv.GetRegion(ctx, region.ID); !errors.Is(err, ErrRegionNotFound) {
+		t.Errorf("expected ErrRegionNotFound after deleting, got %v", err)
+	}
+}
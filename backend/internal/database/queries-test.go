@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 )
@@ -13,7 +14,7 @@ func (s *service) GetCount() int {
     LIMIT 1;
     `
 	var count int
-	err := s.db.QueryRow(query).Scan(&count)
+	err := s.db.QueryRow(context.Background(), query).Scan(&count)
 	if err != nil {
 		slog.Error("Error quering test for count")
 		return 0
@@ -27,7 +28,7 @@ func (s *service) UpdateCount(newCount int) error {
     SET count = $1
     WHERE id = (SELECT MAX(id) FROM test)
     `
-	_, err := s.db.Exec(query, newCount)
+	_, err := s.db.Exec(context.Background(), query, newCount)
 	if err != nil {
 		return fmt.Errorf("error updating count: %w", err)
 	}
@@ -41,7 +42,7 @@ func (s *service) NewCount() (int, error) {
     RETURNING id
     `
 	var id int
-	err := s.db.QueryRow(query, 0).Scan(&id)
+	err := s.db.QueryRow(context.Background(), query, 0).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
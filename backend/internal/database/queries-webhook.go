@@ -0,0 +1,156 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const webhookColumns = `id, url, events, secret, created_at`
+
+// CreateWebhook subscribes url to events, signing the payloads with secret.
+func (s *service) CreateWebhook(ctx context.Context, url string, events []string, secret string) (models.Webhook, error) {
+	row := s.db.QueryRow(ctx, `
+        INSERT INTO webhooks (url, events, secret)
+        VALUES ($1, $2, $3)
+        RETURNING `+webhookColumns,
+		url, events, secret)
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("error saving webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// GetWebhook returns the webhook with id, ErrWebhookNotFound if there is none.
+func (s *service) GetWebhook(ctx context.Context, id int) (models.Webhook, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id)
+	webhook, err := scanWebhook(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("error querying webhook %d: %w", id, err)
+	}
+	return webhook, nil
+}
+
+// GetWebhooks returns every webhook ordered by ID.
+func (s *service) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY id`)
+}
+
+// GetWebhooksForEvent returns the webhooks subscribed to event ordered by ID.
+func (s *service) GetWebhooksForEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	return s.queryWebhooks(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE $1 = ANY(events) ORDER BY id`, event)
+}
+
+func (s *service) queryWebhooks(ctx context.Context, query string, args ...any) ([]models.Webhook, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook with id and its delivery history, ErrWebhookNotFound if there is none.
+func (s *service) DeleteWebhook(ctx context.Context, id int) error {
+	tag, err := s.db.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting webhook %d: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+	var w models.Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.Events, &w.Secret, &w.CreatedAt)
+	return w, err
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event, payload, attempts, status_code, error, delivered_at, created_at`
+
+// CreateWebhookDelivery records a delivery of the JSON payload of event to a webhook before its first attempt.
+func (s *service) CreateWebhookDelivery(ctx context.Context, webhookID int, event string, payload []byte) (models.WebhookDelivery, error) {
+	row := s.db.QueryRow(ctx, `
+        INSERT INTO webhook_deliveries (webhook_id, event, payload)
+        VALUES ($1, $2, $3)
+        RETURNING `+webhookDeliveryColumns,
+		webhookID, event, string(payload))
+	delivery, err := scanWebhookDelivery(row)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("error saving webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// UpdateWebhookDelivery saves the attempts, the outcome of the last one and the delivery time of d.
+func (s *service) UpdateWebhookDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := s.db.Exec(ctx, `
+        UPDATE webhook_deliveries
+        SET attempts = $1, status_code = $2, error = $3, delivered_at = $4
+        WHERE id = $5
+    `, d.Attempts, d.StatusCode, d.Error, d.DeliveredAt, d.ID)
+	if err != nil {
+		return fmt.Errorf("error updating webhook delivery %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveries returns the last limit deliveries to a webhook, newest first.
+func (s *service) GetWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.Query(ctx, `
+        SELECT `+webhookDeliveryColumns+`
+        FROM webhook_deliveries
+        WHERE webhook_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2
+    `, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating through webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func scanWebhookDelivery(row rowScanner) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Attempts, &d.StatusCode, &d.Error, &d.DeliveredAt, &d.CreatedAt)
+	d.Payload = payload
+	return d, err
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	srv := New().(*service)
+	if err := resetTables(ctx, srv, "webhooks", "webhook_deliveries"); err != nil {
+		t.Fatalf("could not reset tables: %v", err)
+	}
+
+	blooms, err := srv.CreateWebhook(ctx, "https://example.com/blooms", []string{models.EventBloomDetected}, "secret-1")
+	if err != nil {
+		t.Fatalf("CreateWebhook() returned error: %v", err)
+	}
+	all, err := srv.CreateWebhook(ctx, "https://example.com/all", models.WebhookEvents, "secret-2")
+	if err != nil {
+		t.Fatalf("CreateWebhook() returned error: %v", err)
+	}
+	if blooms.ID == 0 || blooms.CreatedAt.IsZero() || blooms.Secret != "secret-1" {
+		t.Errorf("expected the saved webhook, got %+v", blooms)
+	}
+
+	got, err := srv.GetWebhook(ctx, all.ID)
+	if err != nil || !reflect.DeepEqual(got.Events, models.WebhookEvents) {
+		t.Errorf("expected the events of the webhook, got %+v, %v", got, err)
+	}
+	if webhooks, err := srv.GetWebhooks(ctx); err != nil || len(webhooks) != 2 {
+		t.Errorf("expected 2 webhooks, got %d, %v", len(webhooks), err)
+	}
+	subscribed, err := srv.GetWebhooksForEvent(ctx, models.EventIngestionFailed)
+	if err != nil || len(subscribed) != 1 || subscribed[0].ID != all.ID {
+		t.Errorf("expected only the webhook of every event, got %+v, %v", subscribed, err)
+	}
+
+	payload := []byte(`{"event":"bloom.detected","data":{"type":"FeatureCollection","features":[]}}`)
+	first, err := srv.CreateWebhookDelivery(ctx, blooms.ID, models.EventBloomDetected, payload)
+	if err != nil {
+		t.Fatalf("CreateWebhookDelivery() returned error: %v", err)
+	}
+	if first.Attempts != 0 || first.DeliveredAt != nil || !json.Valid(first.Payload) {
+		t.Errorf("expected a pending delivery, got %+v", first)
+	}
+	delivered := time.Now().UTC().Truncate(time.Microsecond)
+	first.Attempts, first.StatusCode, first.DeliveredAt = 2, 200, &delivered
+	if err := srv.UpdateWebhookDelivery(ctx, first); err != nil {
+		t.Fatalf("UpdateWebhookDelivery() returned error: %v", err)
+	}
+	second, err := srv.CreateWebhookDelivery(ctx, blooms.ID, models.EventBloomDetected, payload)
+	if err != nil {
+		t.Fatalf("CreateWebhookDelivery() returned error: %v", err)
+	}
+
+	deliveries, err := srv.GetWebhookDeliveries(ctx, blooms.ID, 10)
+	if err != nil {
+		t.Fatalf("GetWebhookDeliveries() returned error: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != second.ID {
+		t.Fatalf("expected 2 deliveries, newest first, got %+v", deliveries)
+	}
+	if d := deliveries[1]; d.Attempts != 2 || d.StatusCode != 200 || d.DeliveredAt == nil || !d.DeliveredAt.Equal(delivered) {
+		t.Errorf("expected the updated delivery, got %+v", d)
+	}
+	if deliveries, _ := srv.GetWebhookDeliveries(ctx, blooms.ID, 1); len(deliveries) != 1 {
+		t.Errorf("expected the limit to apply, got %d deliveries", len(deliveries))
+	}
+
+	if err := srv.DeleteWebhook(ctx, blooms.ID); err != nil {
+		t.Fatalf("DeleteWebhook() returned error: %v", err)
+	}
+	if deliveries, _ := srv.GetWebhookDeliveries(ctx, blooms.ID, 10); len(deliveries) != 0 {
+		t.Errorf("expected the deliveries to be deleted with the webhook, got %d", len(deliveries))
+	}
+	if _, err := srv.GetWebhook(ctx, blooms.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+	if err := srv.DeleteWebhook(ctx, blooms.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected ErrWebhookNotFound, got %v", err)
+	}
+}
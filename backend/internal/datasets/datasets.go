@@ -0,0 +1,327 @@
+package datasets
+
+import (
+	"fmt"
+	"time"
+)
+
+// Names of the dimensions that are constrained by the downloader. Any other
+// dimension of an ERDDAP variable (altitude, depth, ...) is expected to hold
+// a single level and is requested at 0.0.
+const (
+	DimTime      = "time"
+	DimLatitude  = "latitude"
+	DimLongitude = "longitude"
+)
+
+// InterpolationStrategy names a gap filling method run by the interpolator.
+type InterpolationStrategy string
+
+const (
+	// InterpolationArea fills NaN groups enclosed by valid data in the grid of a single timestamp.
+	InterpolationArea InterpolationStrategy = "area"
+	// InterpolationTime fills gaps in the time series of a single location.
+	InterpolationTime InterpolationStrategy = "time"
+	// InterpolationIDW fills enclosed NaN groups in the grid of a single timestamp with
+	// inverse distance weighted averages of nearby valid data.
+	InterpolationIDW InterpolationStrategy = "idw"
+	// InterpolationKriging fills enclosed NaN groups in the grid of a single timestamp with
+	// ordinary kriging and stores the kriging variance of each filled value.
+	InterpolationKriging InterpolationStrategy = "kriging"
+)
+
+// Defaults used by InterpolationIDW when the dataset doesn't set IDWOptions.
+const (
+	DefaultIDWPower  = 2.0
+	DefaultIDWRadius = 3
+)
+
+// IDWOptions configures InterpolationIDW. Zero values use the defaults.
+type IDWOptions struct {
+	// exponent of the inverse distance weights, higher values favour closer cells
+	Power float64
+	// search radius in grid cells
+	Radius int
+}
+
+// VariogramModel names the model fitted to the empirical variogram by InterpolationKriging.
+type VariogramModel string
+
+const (
+	VariogramSpherical   VariogramModel = "spherical"
+	VariogramExponential VariogramModel = "exponential"
+)
+
+// Defaults used by InterpolationKriging when the dataset doesn't set KrigingOptions.
+const (
+	DefaultVariogramModel   = VariogramSpherical
+	DefaultKrigingMaxLag    = 10
+	DefaultKrigingNeighbors = 16
+)
+
+// KrigingOptions configures InterpolationKriging. Zero values use the defaults.
+type KrigingOptions struct {
+	Model VariogramModel
+	// largest distance in grid cells of the empirical variogram, also the search radius
+	MaxLag int
+	// number of nearest valid cells used for each estimate
+	Neighbors int
+}
+
+// KrigingParameters are the resolved KrigingOptions of a dataset.
+type KrigingParameters struct {
+	Model     VariogramModel
+	MaxLag    int
+	Neighbors int
+}
+
+// Variable maps a variable of the ERDDAP dataset to the column it is stored in.
+// Units, StandardName and LongName are the CF attributes of exported files, optional.
+type Variable struct {
+	Name         string
+	Column       string
+	Units        string
+	StandardName string
+	LongName     string
+}
+
+// VectorInterpolation names how the interpolator treats the components of a vector field.
+type VectorInterpolation string
+
+const (
+	// VectorComponentwise interpolates each component on its own like any other variable.
+	VectorComponentwise VectorInterpolation = "components"
+	// VectorSpeedDirection takes the direction of filled vectors from the interpolated
+	// components and their length from the separately interpolated speed, so averaging
+	// vectors pointing different ways doesn't shrink them.
+	VectorSpeedDirection VectorInterpolation = "speed_direction"
+)
+
+// VectorComponents marks two columns of a dataset as the eastward and northward
+// components of a single vector field. Angle and Magnitude are the names of the
+// derived GeoJSON properties.
+type VectorComponents struct {
+	U         string
+	V         string
+	Angle     string
+	Magnitude string
+	// VectorComponentwise if empty
+	Interpolation VectorInterpolation
+}
+
+// Dataset describes everything needed to download, store, interpolate and serve
+// a gridded ERDDAP dataset.
+type Dataset struct {
+	// Name used in logs and to look the dataset up
+	Name string
+	// griddap dataset ID on the ERDDAP server
+	ERDDAPID  string
+	Variables []Variable
+	// dimensions of the variables in the order ERDDAP returns them
+	Dimensions []string
+	// value used by the dataset for missing data, NaN if missing data is already NaN
+	FillValue     float64
+	Table         string
+	RawTable      string
+	Interpolation []InterpolationStrategy
+	// options of InterpolationIDW, defaults are used if nil
+	IDW *IDWOptions
+	// options of InterpolationKriging, defaults are used if nil
+	Kriging *KrigingOptions
+	// data older than Retention is removed from both tables
+	Retention time.Duration
+	// HTTP route the dataset is served on
+	Route  string
+	Vector *VectorComponents
+}
+
+var registry []*Dataset
+
+func init() {
+	for i := range builtin {
+		if err := Register(builtin[i]); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register validates a dataset and adds it to the registry.
+func Register(d Dataset) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if _, ok := Get(d.Name); ok {
+		return fmt.Errorf("dataset %q already registered", d.Name)
+	}
+	registry = append(registry, &d)
+	return nil
+}
+
+// All returns registered datasets in registration order.
+func All() []*Dataset {
+	return registry
+}
+
+// Get returns the dataset registered under name.
+func Get(name string) (*Dataset, bool) {
+	for _, d := range registry {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// FindColumn returns the registered dataset storing a variable in column.
+func FindColumn(column string) (*Dataset, bool) {
+	for _, d := range registry {
+		if d.ColumnIndex(column) >= 0 {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func (d *Dataset) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("dataset name is required")
+	}
+	if d.ERDDAPID == "" {
+		return fmt.Errorf("dataset %s: ERDDAP id is required", d.Name)
+	}
+	if d.Table == "" || d.RawTable == "" {
+		return fmt.Errorf("dataset %s: table and raw table are required", d.Name)
+	}
+	if len(d.Variables) == 0 {
+		return fmt.Errorf("dataset %s: at least one variable is required", d.Name)
+	}
+	for _, v := range d.Variables {
+		if v.Name == "" || v.Column == "" {
+			return fmt.Errorf("dataset %s: variable name and column are required", d.Name)
+		}
+	}
+	for _, dim := range []string{DimTime, DimLatitude, DimLongitude} {
+		if d.dimensionIndex(dim) < 0 {
+			return fmt.Errorf("dataset %s: missing %s dimension", d.Name, dim)
+		}
+	}
+	for _, strategy := range d.Interpolation {
+		switch strategy {
+		case InterpolationArea, InterpolationTime, InterpolationIDW, InterpolationKriging:
+		default:
+			return fmt.Errorf("dataset %s: unknown interpolation strategy %q", d.Name, strategy)
+		}
+	}
+	if d.IDW != nil && (d.IDW.Power < 0 || d.IDW.Radius < 0) {
+		return fmt.Errorf("dataset %s: IDW power and radius must not be negative", d.Name)
+	}
+	if d.Kriging != nil {
+		switch d.Kriging.Model {
+		case "", VariogramSpherical, VariogramExponential:
+		default:
+			return fmt.Errorf("dataset %s: unknown variogram model %q", d.Name, d.Kriging.Model)
+		}
+		if d.Kriging.MaxLag < 0 || d.Kriging.Neighbors < 0 {
+			return fmt.Errorf("dataset %s: kriging max lag and neighbors must not be negative", d.Name)
+		}
+	}
+	if d.Vector != nil {
+		if d.ColumnIndex(d.Vector.U) < 0 || d.ColumnIndex(d.Vector.V) < 0 {
+			return fmt.Errorf("dataset %s: vector components must be dataset columns", d.Name)
+		}
+		switch d.Vector.Interpolation {
+		case "", VectorComponentwise, VectorSpeedDirection:
+		default:
+			return fmt.Errorf("dataset %s: unknown vector interpolation %q", d.Name, d.Vector.Interpolation)
+		}
+	}
+	return nil
+}
+
+// Columns returns the table columns of the dataset variables.
+func (d *Dataset) Columns() []string {
+	columns := make([]string, len(d.Variables))
+	for i, v := range d.Variables {
+		columns[i] = v.Column
+	}
+	return columns
+}
+
+// ColumnIndex returns the index of the variable stored in column or -1.
+func (d *Dataset) ColumnIndex(column string) int {
+	for i, v := range d.Variables {
+		if v.Column == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// IDWParameters returns the power and search radius of InterpolationIDW for the dataset.
+func (d *Dataset) IDWParameters() (float64, int) {
+	power, radius := DefaultIDWPower, DefaultIDWRadius
+	if d.IDW != nil {
+		if d.IDW.Power > 0 {
+			power = d.IDW.Power
+		}
+		if d.IDW.Radius > 0 {
+			radius = d.IDW.Radius
+		}
+	}
+	return power, radius
+}
+
+// KrigingParameters returns the options of InterpolationKriging for the dataset.
+func (d *Dataset) KrigingParameters() KrigingParameters {
+	p := KrigingParameters{
+		Model:     DefaultVariogramModel,
+		MaxLag:    DefaultKrigingMaxLag,
+		Neighbors: DefaultKrigingNeighbors,
+	}
+	if d.Kriging != nil {
+		if d.Kriging.Model != "" {
+			p.Model = d.Kriging.Model
+		}
+		if d.Kriging.MaxLag > 0 {
+			p.MaxLag = d.Kriging.MaxLag
+		}
+		if d.Kriging.Neighbors > 0 {
+			p.Neighbors = d.Kriging.Neighbors
+		}
+	}
+	return p
+}
+
+// AnomalyDataset returns the dataset served by the anomaly mode of the dataset routes. Each
+// variable is followed by its difference to the climatology, suffixed _anomaly, and that
+// difference in standard deviations of the climatology, suffixed _zscore.
+func (d *Dataset) AnomalyDataset() *Dataset {
+	a := *d
+	a.Variables = make([]Variable, 0, 3*len(d.Variables))
+	for _, v := range d.Variables {
+		a.Variables = append(a.Variables, v,
+			Variable{
+				Name:     v.Name + "_anomaly",
+				Column:   v.Column + "_anomaly",
+				Units:    v.Units,
+				LongName: v.LongName + " Anomaly",
+			},
+			Variable{
+				Name:     v.Name + "_zscore",
+				Column:   v.Column + "_zscore",
+				Units:    "1",
+				LongName: v.LongName + " Standardized Anomaly",
+			},
+		)
+	}
+	return &a
+}
+
+func (d *Dataset) dimensionIndex(dim string) int {
+	for i, name := range d.Dimensions {
+		if name == dim {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,218 @@
+package datasets
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinDatasetsAreRegistered(t *testing.T) {
+	for _, d := range builtin {
+		got, ok := Get(d.Name)
+		if !ok {
+			t.Fatalf("expected dataset %s to be registered", d.Name)
+		}
+		if got.Route == "" {
+			t.Errorf("expected dataset %s to have a route", d.Name)
+		}
+	}
+	if len(All()) != len(builtin) {
+		t.Errorf("expected %d registered datasets, got %d", len(builtin), len(All()))
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	if err := Register(builtin[0]); err == nil {
+		t.Fatal("expected registering a duplicate dataset to fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Dataset{
+		Name:       "test",
+		ERDDAPID:   "testDataset",
+		Variables:  []Variable{{Name: "u", Column: "u"}, {Name: "v", Column: "v"}},
+		Dimensions: []string{DimTime, DimLatitude, DimLongitude},
+		Table:      "test_data",
+		RawTable:   "test_data_raw",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(d *Dataset)
+		wantErr bool
+	}{
+		{
+			name:    "Valid dataset",
+			modify:  func(d *Dataset) {},
+			wantErr: false,
+		},
+		{
+			name:    "Missing name",
+			modify:  func(d *Dataset) { d.Name = "" },
+			wantErr: true,
+		},
+		{
+			name:    "Missing raw table",
+			modify:  func(d *Dataset) { d.RawTable = "" },
+			wantErr: true,
+		},
+		{
+			name:    "No variables",
+			modify:  func(d *Dataset) { d.Variables = nil },
+			wantErr: true,
+		},
+		{
+			name:    "Missing longitude dimension",
+			modify:  func(d *Dataset) { d.Dimensions = []string{DimTime, DimLatitude} },
+			wantErr: true,
+		},
+		{
+			name:    "Vector components are columns",
+			modify:  func(d *Dataset) { d.Vector = &VectorComponents{U: "u", V: "v"} },
+			wantErr: false,
+		},
+		{
+			name:    "Vector component is not a column",
+			modify:  func(d *Dataset) { d.Vector = &VectorComponents{U: "u", V: "w"} },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown vector interpolation",
+			modify:  func(d *Dataset) { d.Vector = &VectorComponents{U: "u", V: "v", Interpolation: "polar"} },
+			wantErr: true,
+		},
+		{
+			name:    "Known interpolation strategies",
+			modify:  func(d *Dataset) { d.Interpolation = []InterpolationStrategy{InterpolationIDW, InterpolationTime} },
+			wantErr: false,
+		},
+		{
+			name:    "Unknown interpolation strategy",
+			modify:  func(d *Dataset) { d.Interpolation = []InterpolationStrategy{"nearest"} },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown variogram model",
+			modify:  func(d *Dataset) { d.Kriging = &KrigingOptions{Model: "gaussian"} },
+			wantErr: true,
+		},
+		{
+			name:    "Kriging options",
+			modify:  func(d *Dataset) { d.Kriging = &KrigingOptions{Model: VariogramExponential, MaxLag: 8} },
+			wantErr: false,
+		},
+		{
+			name:    "Negative IDW radius",
+			modify:  func(d *Dataset) { d.IDW = &IDWOptions{Power: 2, Radius: -1} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := valid
+			tt.modify(&d)
+			err := d.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIDWParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    *IDWOptions
+		wantPower  float64
+		wantRadius int
+	}{
+		{name: "Defaults without options", options: nil, wantPower: DefaultIDWPower, wantRadius: DefaultIDWRadius},
+		{name: "Zero values use defaults", options: &IDWOptions{}, wantPower: DefaultIDWPower, wantRadius: DefaultIDWRadius},
+		{name: "Configured options", options: &IDWOptions{Power: 3, Radius: 7}, wantPower: 3, wantRadius: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dataset{IDW: tt.options}
+			power, radius := d.IDWParameters()
+			if power != tt.wantPower || radius != tt.wantRadius {
+				t.Errorf("IDWParameters() = %v, %v, want %v, %v", power, radius, tt.wantPower, tt.wantRadius)
+			}
+		})
+	}
+}
+
+func TestKrigingParameters(t *testing.T) {
+	defaults := KrigingParameters{Model: DefaultVariogramModel, MaxLag: DefaultKrigingMaxLag, Neighbors: DefaultKrigingNeighbors}
+
+	tests := []struct {
+		name    string
+		options *KrigingOptions
+		want    KrigingParameters
+	}{
+		{name: "Defaults without options", options: nil, want: defaults},
+		{name: "Zero values use defaults", options: &KrigingOptions{}, want: defaults},
+		{
+			name:    "Configured options",
+			options: &KrigingOptions{Model: VariogramExponential, MaxLag: 6, Neighbors: 12},
+			want:    KrigingParameters{Model: VariogramExponential, MaxLag: 6, Neighbors: 12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Dataset{Kriging: tt.options}
+			if got := d.KrigingParameters(); got != tt.want {
+				t.Errorf("KrigingParameters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindColumn(t *testing.T) {
+	tests := []struct {
+		column  string
+		dataset string
+		found   bool
+	}{
+		{column: "chlor_a", dataset: "chlorophyll", found: true},
+		{column: "v_current", dataset: "currents", found: true},
+		{column: "sst", dataset: "sst", found: true},
+		{column: "analysed_sst", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			d, ok := FindColumn(tt.column)
+			if ok != tt.found {
+				t.Fatalf("FindColumn(%q) found = %t, want %t", tt.column, ok, tt.found)
+			}
+			if ok && d.Name != tt.dataset {
+				t.Errorf("FindColumn(%q) = %s, want %s", tt.column, d.Name, tt.dataset)
+			}
+		})
+	}
+}
+
+func TestAnomalyDataset(t *testing.T) {
+	ds, _ := Get("currents")
+	a := ds.AnomalyDataset()
+
+	want := []string{
+		"u_current", "u_current_anomaly", "u_current_zscore",
+		"v_current", "v_current_anomaly", "v_current_zscore",
+	}
+	if got := a.Columns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected columns %v, got %v", want, got)
+	}
+	if a.Variables[1].Units != "m s-1" || a.Variables[2].Units != "1" || a.Variables[1].StandardName != "" {
+		t.Errorf("unexpected anomaly attributes %+v and %+v", a.Variables[1], a.Variables[2])
+	}
+	if a.ColumnIndex(a.Vector.U) != 0 || a.ColumnIndex(a.Vector.V) != 3 {
+		t.Errorf("expected the vector components to be kept")
+	}
+	if len(ds.Variables) != 2 {
+		t.Errorf("expected the dataset to be left unchanged, got %d variables", len(ds.Variables))
+	}
+}
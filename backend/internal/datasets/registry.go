@@ -0,0 +1,88 @@
+package datasets
+
+import (
+	"math"
+	"time"
+)
+
+// builtin datasets registered on startup. Adding a new gridded ERDDAP source
+// only requires a new entry here and a migration creating its tables.
+var builtin = []Dataset{
+	// kriging fills cloud gaps with an uncertainty estimate, IDW fills what kriging couldn't
+	{
+		Name:     "chlorophyll",
+		ERDDAPID: "noaacwNPPVIIRSchlaDaily",
+		Variables: []Variable{{
+			Name:         "chlor_a",
+			Column:       "chlor_a",
+			Units:        "mg m-3",
+			StandardName: "mass_concentration_of_chlorophyll_a_in_sea_water",
+			LongName:     "Chlorophyll Concentration",
+		}},
+		Dimensions:    []string{DimTime, "altitude", DimLatitude, DimLongitude},
+		FillValue:     math.NaN(),
+		Table:         "chlorophyll_data",
+		RawTable:      "chlorophyll_data_raw",
+		Interpolation: []InterpolationStrategy{InterpolationKriging, InterpolationIDW, InterpolationTime},
+		IDW:           &IDWOptions{Power: 2, Radius: 5},
+		Kriging:       &KrigingOptions{Model: VariogramSpherical, MaxLag: 10, Neighbors: 16},
+		Retention:     120 * 24 * time.Hour,
+		Route:         "/chlorophyll",
+	},
+	{
+		Name:     "currents",
+		ERDDAPID: "noaacwBLENDEDNRTcurrentsDaily",
+		Variables: []Variable{
+			//surface geostrophic eastward sea water velocity in m/s
+			{
+				Name:         "u_current",
+				Column:       "u_current",
+				Units:        "m s-1",
+				StandardName: "surface_geostrophic_eastward_sea_water_velocity",
+				LongName:     "Eastward Surface Geostrophic Current",
+			},
+			//surface geostrophic northward sea water velocity in m/s
+			{
+				Name:         "v_current",
+				Column:       "v_current",
+				Units:        "m s-1",
+				StandardName: "surface_geostrophic_northward_sea_water_velocity",
+				LongName:     "Northward Surface Geostrophic Current",
+			},
+		},
+		Dimensions:    []string{DimTime, DimLatitude, DimLongitude},
+		FillValue:     -214748.3648,
+		Table:         "currents_data",
+		RawTable:      "currents_data_raw",
+		Interpolation: []InterpolationStrategy{InterpolationArea, InterpolationTime},
+		Retention:     120 * 24 * time.Hour,
+		Route:         "/currents",
+		Vector: &VectorComponents{
+			U:         "u_current",
+			V:         "v_current",
+			Angle:     "current_angle",
+			Magnitude: "magnitude",
+			// interpolating components alone distorts speed and direction in gaps
+			Interpolation: VectorSpeedDirection,
+		},
+	},
+	{
+		Name:     "sst",
+		ERDDAPID: "noaacwBLENDEDsstDNDaily",
+		// NOAA Geo-polar Blended day+night analysed sea surface temperature in degrees Celsius
+		Variables: []Variable{{
+			Name:         "analysed_sst",
+			Column:       "sst",
+			Units:        "degree_C",
+			StandardName: "sea_surface_temperature",
+			LongName:     "Analysed Sea Surface Temperature",
+		}},
+		Dimensions:    []string{DimTime, DimLatitude, DimLongitude},
+		FillValue:     -327.68,
+		Table:         "sst_data",
+		RawTable:      "sst_data_raw",
+		Interpolation: []InterpolationStrategy{InterpolationArea, InterpolationTime},
+		Retention:     120 * 24 * time.Hour,
+		Route:         "/sst",
+	},
+}
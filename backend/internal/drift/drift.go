@@ -0,0 +1,189 @@
+package drift
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Status tells why a particle stopped.
+type Status string
+
+const (
+	// StatusActive particles drifted for the whole duration.
+	StatusActive Status = "active"
+	// StatusStranded particles reached a cell without currents, usually the coast.
+	StatusStranded Status = "stranded"
+	// StatusOutside particles left the area covered by the currents.
+	StatusOutside Status = "outside"
+)
+
+// earthRadius is the mean radius of the Earth in meters, used to turn displacements into degrees.
+const earthRadius = 6371008.8
+
+// Release is a point particles are released at.
+type Release struct {
+	Point orb.Point
+	Time  time.Time
+}
+
+// Options configure a simulation.
+type Options struct {
+	Duration time.Duration
+	// Step is the time step of the integration, the last step is shortened to end at Duration
+	Step time.Duration
+	// Diffusivity is the horizontal eddy diffusivity in m²/s of the random walk added to
+	// every step, modelling the mixing the daily currents don't resolve. 0 disables it.
+	Diffusivity float64
+	// Particles is the number of particles released at every point, at least 1
+	Particles int
+	// Seed makes the random walk reproducible
+	Seed int64
+	// Backward integrates back in time from the releases, tracing where particles came from
+	Backward bool
+}
+
+// Trajectory is the path of a single particle, with a position at the release time and after every
+// step. Backward trajectories start at the release and go back in time.
+type Trajectory struct {
+	// Release is the index of the release the particle started from
+	Release  int
+	Particle int
+	Points   []orb.Point
+	Times    []time.Time
+	Status   Status
+}
+
+// Simulate advects the particles of every release through the field with fourth order
+// Runge-Kutta steps. Particles stop early when they strand or leave the field.
+func Simulate(f *Field, releases []Release, opts Options) []Trajectory {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	particles := max(opts.Particles, 1)
+	trajectories := make([]Trajectory, 0, len(releases)*particles)
+	for i, r := range releases {
+		for p := 0; p < particles; p++ {
+			traj := track(f, r, opts, rng)
+			traj.Release, traj.Particle = i, p
+			trajectories = append(trajectories, traj)
+		}
+	}
+	return trajectories
+}
+
+// track follows a single particle from its release.
+func track(f *Field, r Release, opts Options, rng *rand.Rand) Trajectory {
+	t, p := r.Time, r.Point
+	traj := Trajectory{Points: []orb.Point{p}, Times: []time.Time{t}, Status: StatusActive}
+	step, end := opts.Step, t.Add(opts.Duration)
+	if opts.Backward {
+		step, end = -opts.Step, t.Add(-opts.Duration)
+	}
+	for !t.Equal(end) {
+		dt := step
+		if remaining := end.Sub(t); remaining.Abs() < step.Abs() {
+			dt = remaining
+		}
+		next, status := rk4(f, t, p, dt)
+		if status != StatusActive {
+			traj.Status = status
+			return traj
+		}
+		if opts.Diffusivity > 0 {
+			next = randomWalk(next, opts.Diffusivity, dt, rng)
+		}
+		t, p = t.Add(dt), next
+		traj.Points = append(traj.Points, p)
+		traj.Times = append(traj.Times, t)
+	}
+	// the random walk of the last step may have left the currents
+	if _, _, status := f.Velocity(t, p); status != StatusActive {
+		traj.Status = status
+	}
+	return traj
+}
+
+// rk4 returns the position of a particle at p and t after dt, before it if dt is negative.
+func rk4(f *Field, t time.Time, p orb.Point, dt time.Duration) (orb.Point, Status) {
+	h := dt.Seconds()
+	k1, status := rate(f, t, p)
+	if status != StatusActive {
+		return p, status
+	}
+	k2, status := rate(f, t.Add(dt/2), offset(p, k1, h/2))
+	if status != StatusActive {
+		return p, status
+	}
+	k3, status := rate(f, t.Add(dt/2), offset(p, k2, h/2))
+	if status != StatusActive {
+		return p, status
+	}
+	k4, status := rate(f, t.Add(dt), offset(p, k3, h))
+	if status != StatusActive {
+		return p, status
+	}
+	return orb.Point{
+		p.Lon() + h/6*(k1.Lon()+2*k2.Lon()+2*k3.Lon()+k4.Lon()),
+		p.Lat() + h/6*(k1.Lat()+2*k2.Lat()+2*k3.Lat()+k4.Lat()),
+	}, StatusActive
+}
+
+// rate returns the velocity of the field at p and t in degrees of longitude and latitude per second.
+func rate(f *Field, t time.Time, p orb.Point) (orb.Point, Status) {
+	u, v, status := f.Velocity(t, p)
+	if status != StatusActive {
+		return orb.Point{}, status
+	}
+	return metersToDegrees(p, u, v), StatusActive
+}
+
+// metersToDegrees converts an eastward and northward distance at p to degrees of longitude and latitude.
+func metersToDegrees(p orb.Point, east, north float64) orb.Point {
+	lat := p.Lat() * math.Pi / 180
+	return orb.Point{
+		east / (earthRadius * math.Cos(lat)) * 180 / math.Pi,
+		north / earthRadius * 180 / math.Pi,
+	}
+}
+
+func offset(p, rate orb.Point, seconds float64) orb.Point {
+	return orb.Point{p.Lon() + rate.Lon()*seconds, p.Lat() + rate.Lat()*seconds}
+}
+
+// randomWalk displaces p by a random step of a diffusion with diffusivity in m²/s over dt,
+// normally distributed with a standard deviation of sqrt(2 K dt) along each axis.
+func randomWalk(p orb.Point, diffusivity float64, dt time.Duration, rng *rand.Rand) orb.Point {
+	sigma := math.Sqrt(2 * diffusivity * dt.Abs().Seconds())
+	d := metersToDegrees(p, rng.NormFloat64()*sigma, rng.NormFloat64()*sigma)
+	return orb.Point{p.Lon() + d.Lon(), p.Lat() + d.Lat()}
+}
+
+// ToGeoJSON returns the trajectories as LineString features carrying the release and
+// particle index, the status and the time of every position. Particles that couldn't
+// move have a line of zero length, as a LineString needs two positions.
+func ToGeoJSON(trajectories []Trajectory) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, traj := range trajectories {
+		line := orb.LineString(traj.Points)
+		if len(line) == 1 {
+			line = append(line, line[0])
+		}
+		times := make([]string, len(traj.Times))
+		for i, t := range traj.Times {
+			times[i] = t.UTC().Format(time.RFC3339)
+		}
+		feature := geojson.NewFeature(line)
+		feature.Properties = map[string]interface{}{
+			"release":      traj.Release,
+			"particle":     traj.Particle,
+			"release_time": times[0],
+			"end_time":     times[len(times)-1],
+			"status":       traj.Status,
+			"times":        times,
+		}
+		fc.Append(feature)
+	}
+	return fc
+}
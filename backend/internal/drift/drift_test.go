@@ -0,0 +1,205 @@
+package drift
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestField returns a field on a 0.25° grid over 40-42 N and 1-3 E with a snapshot
+// at every time, velocity gives the components of a cell.
+func newTestField(t *testing.T, times []time.Time, velocity func(t time.Time, p orb.Point) (float32, float32)) *Field {
+	t.Helper()
+	ds, _ := datasets.Get("currents")
+	var data []models.GridData
+	for _, tm := range times {
+		for lat := 40.0; lat <= 42; lat += 0.25 {
+			for lon := 1.0; lon <= 3; lon += 0.25 {
+				u, v := velocity(tm, orb.Point{lon, lat})
+				data = append(data, models.GridData{MeasurementTime: tm, Latitude: lat, Longitude: lon, Values: []float32{u, v}})
+			}
+		}
+	}
+	f, err := NewField(ds, data)
+	if err != nil {
+		t.Fatalf("NewField() returned error: %v", err)
+	}
+	return f
+}
+
+func TestFieldVelocity(t *testing.T) {
+	// u grows eastwards and over time, v grows northwards
+	f := newTestField(t, []time.Time{start, start.Add(24 * time.Hour)}, func(tm time.Time, p orb.Point) (float32, float32) {
+		return float32(p.Lon() + tm.Sub(start).Hours()/24), float32(p.Lat() - 40)
+	})
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		p      orb.Point
+		u, v   float64
+		status Status
+	}{
+		{name: "Grid node", t: start, p: orb.Point{1.5, 41}, u: 1.5, v: 1, status: StatusActive},
+		{name: "Between nodes and snapshots", t: start.Add(6 * time.Hour), p: orb.Point{1.6, 41.1}, u: 1.85, v: 1.1, status: StatusActive},
+		{name: "Last row and column", t: start, p: orb.Point{3, 42}, u: 3, v: 2, status: StatusActive},
+		{name: "After the last snapshot", t: start.Add(48 * time.Hour), p: orb.Point{2, 41}, u: 3, v: 1, status: StatusActive},
+		{name: "Outside of the grid", t: start, p: orb.Point{3.1, 41}, status: StatusOutside},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, v, status := f.Velocity(tt.t, tt.p)
+			if status != tt.status {
+				t.Fatalf("expected status %s; got %s", tt.status, status)
+			}
+			if math.Abs(u-tt.u) > 1e-6 || math.Abs(v-tt.v) > 1e-6 {
+				t.Errorf("expected velocity (%g, %g); got (%g, %g)", tt.u, tt.v, u, v)
+			}
+		})
+	}
+}
+
+func TestSimulateIntegratesTimeVaryingCurrents(t *testing.T) {
+	// eastward current speeding up from 0 to 0.2 m/s within a day
+	f := newTestField(t, []time.Time{start, start.Add(24 * time.Hour)}, func(tm time.Time, p orb.Point) (float32, float32) {
+		return float32(0.2 * tm.Sub(start).Hours() / 24), 0
+	})
+
+	trajectories := Simulate(f, []Release{{Point: orb.Point{1.5, 41}, Time: start}}, Options{Duration: 24 * time.Hour, Step: 6 * time.Hour})
+	if len(trajectories) != 1 {
+		t.Fatalf("expected a single trajectory; got %d", len(trajectories))
+	}
+	traj := trajectories[0]
+	if traj.Status != StatusActive || len(traj.Points) != 5 {
+		t.Fatalf("expected an active trajectory of 5 positions; got %s with %d", traj.Status, len(traj.Points))
+	}
+
+	// the mean speed of 0.1 m/s is exact for Runge-Kutta, forward Euler would fall short by a quarter
+	distance := 0.1 * 86400.0
+	want := 1.5 + distance/(earthRadius*math.Cos(41*math.Pi/180))*180/math.Pi
+	end := traj.Points[len(traj.Points)-1]
+	// within a centimetre, the speeds are stored as float32
+	if math.Abs(end.Lon()-want) > 1e-7 || end.Lat() != 41 {
+		t.Errorf("expected to end at (%g, 41); got %v", want, end)
+	}
+	if !traj.Times[4].Equal(start.Add(24 * time.Hour)) {
+		t.Errorf("expected to end after a day; got %v", traj.Times[4])
+	}
+}
+
+func TestSimulateStopsParticles(t *testing.T) {
+	// 0.5 m/s eastward, with land east of 2.5 E
+	f := newTestField(t, []time.Time{start}, func(tm time.Time, p orb.Point) (float32, float32) {
+		if p.Lon() > 2.5 {
+			return float32(math.NaN()), float32(math.NaN())
+		}
+		return 0.5, 0
+	})
+	// 0.5 m/s northward everywhere
+	north := newTestField(t, []time.Time{start}, func(tm time.Time, p orb.Point) (float32, float32) {
+		return 0, 0.5
+	})
+
+	tests := []struct {
+		name   string
+		field  *Field
+		want   Status
+		maxLon float64
+		maxLat float64
+	}{
+		{name: "Stranded at the coast", field: f, want: StatusStranded, maxLon: 2.5, maxLat: 41},
+		{name: "Left the grid", field: north, want: StatusOutside, maxLon: 1.5, maxLat: 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			traj := Simulate(tt.field, []Release{{Point: orb.Point{1.5, 41}, Time: start}}, Options{Duration: 5 * 24 * time.Hour, Step: time.Hour})[0]
+			if traj.Status != tt.want {
+				t.Fatalf("expected status %s; got %s", tt.want, traj.Status)
+			}
+			end := traj.Points[len(traj.Points)-1]
+			if end.Lon() > tt.maxLon || end.Lat() > tt.maxLat {
+				t.Errorf("expected to stop within %g E, %g N; got %v", tt.maxLon, tt.maxLat, end)
+			}
+			if len(traj.Times) >= 5*24 {
+				t.Errorf("expected to stop before the end of the simulation; got %d positions", len(traj.Times))
+			}
+		})
+	}
+}
+
+func TestSimulateDiffusion(t *testing.T) {
+	still := newTestField(t, []time.Time{start}, func(tm time.Time, p orb.Point) (float32, float32) {
+		return 0, 0
+	})
+	opts := Options{Duration: time.Hour, Step: 10 * time.Minute, Diffusivity: 10, Particles: 2000, Seed: 42}
+	release := []Release{{Point: orb.Point{2, 41}, Time: start}}
+	trajectories := Simulate(still, release, opts)
+
+	// the spread of a random walk grows with sqrt(2 K t)
+	var sum float64
+	for _, traj := range trajectories {
+		end := traj.Points[len(traj.Points)-1]
+		north := (end.Lat() - 41) * math.Pi / 180 * earthRadius
+		sum += north * north
+	}
+	spread := math.Sqrt(sum / float64(len(trajectories)))
+	want := math.Sqrt(2 * 10 * 3600.0)
+	if math.Abs(spread-want)/want > 0.1 {
+		t.Errorf("expected a spread of %.0f m; got %.0f m", want, spread)
+	}
+
+	if again := Simulate(still, release, opts); !reflect.DeepEqual(again, trajectories) {
+		t.Errorf("expected the same seed to give the same trajectories")
+	}
+}
+
+func TestToGeoJSON(t *testing.T) {
+	fc := ToGeoJSON([]Trajectory{{
+		Release:  1,
+		Particle: 0,
+		Points:   []orb.Point{{2, 41}},
+		Times:    []time.Time{start},
+		Status:   StatusStranded,
+	}})
+	if len(fc.Features) != 1 {
+		t.Fatalf("expected a single feature; got %d", len(fc.Features))
+	}
+	line, ok := fc.Features[0].Geometry.(orb.LineString)
+	if !ok || len(line) != 2 {
+		t.Errorf("expected a zero length line; got %v", fc.Features[0].Geometry)
+	}
+	if status := fc.Features[0].Properties["status"]; status != StatusStranded {
+		t.Errorf("expected status stranded; got %v", status)
+	}
+}
+
+func TestSimulateBackward(t *testing.T) {
+	// eastward current of 0.1 m/s
+	f := newTestField(t, []time.Time{start}, func(tm time.Time, p orb.Point) (float32, float32) {
+		return 0.1, 0
+	})
+	sighting := start.Add(48 * time.Hour)
+
+	traj := Simulate(f, []Release{{Point: orb.Point{2, 41}, Time: sighting}}, Options{Duration: 25 * time.Hour, Step: 2 * time.Hour, Backward: true})[0]
+	if traj.Status != StatusActive || len(traj.Points) != 14 {
+		t.Fatalf("expected an active trajectory of 14 positions; got %s with %d", traj.Status, len(traj.Points))
+	}
+	if got := traj.Times[len(traj.Times)-1]; !got.Equal(sighting.Add(-25 * time.Hour)) {
+		t.Errorf("expected to end 25 hours before the sighting; got %v", got)
+	}
+	// the particle came from the west
+	want := 2 - 0.1*25*3600/(earthRadius*math.Cos(41*math.Pi/180))*180/math.Pi
+	if end := traj.Points[len(traj.Points)-1]; math.Abs(end.Lon()-want) > 1e-7 || end.Lat() != 41 {
+		t.Errorf("expected to start from (%g, 41); got %v", want, end)
+	}
+}
@@ -0,0 +1,193 @@
+// Package drift tracks virtual particles carried by the stored surface currents,
+// answering where something released at sea will go.
+package drift
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+// ErrNoCurrents is returned when a field is built without any current vectors.
+var ErrNoCurrents = errors.New("no current data")
+
+// Field is the velocity of a vector dataset, interpolated bilinearly in space within
+// each snapshot and linearly in time between snapshots. Before the first and after
+// the last snapshot the nearest one is held constant.
+type Field struct {
+	times []time.Time
+	lats  []float64
+	lons  []float64
+	// u and v in m/s by snapshot, latitude and longitude index, NaN where missing
+	u, v [][][]float64
+}
+
+// NewField returns the field of the vector components of ds held by data.
+func NewField(ds *datasets.Dataset, data []models.GridData) (*Field, error) {
+	if ds.Vector == nil {
+		return nil, errors.New("dataset " + ds.Name + " has no vector components")
+	}
+	if len(data) == 0 {
+		return nil, ErrNoCurrents
+	}
+	ui, vi := ds.ColumnIndex(ds.Vector.U), ds.ColumnIndex(ds.Vector.V)
+
+	f := &Field{}
+	timeIndex := make(map[int64]int)
+	latIndex := make(map[float64]int)
+	lonIndex := make(map[float64]int)
+	for _, d := range data {
+		if _, ok := timeIndex[d.MeasurementTime.Unix()]; !ok {
+			timeIndex[d.MeasurementTime.Unix()] = 0
+			f.times = append(f.times, d.MeasurementTime.UTC())
+		}
+		if _, ok := latIndex[d.Latitude]; !ok {
+			latIndex[d.Latitude] = 0
+			f.lats = append(f.lats, d.Latitude)
+		}
+		if _, ok := lonIndex[d.Longitude]; !ok {
+			lonIndex[d.Longitude] = 0
+			f.lons = append(f.lons, d.Longitude)
+		}
+	}
+	sort.Slice(f.times, func(i, j int) bool { return f.times[i].Before(f.times[j]) })
+	sort.Float64s(f.lats)
+	sort.Float64s(f.lons)
+	for i, t := range f.times {
+		timeIndex[t.Unix()] = i
+	}
+	for i, lat := range f.lats {
+		latIndex[lat] = i
+	}
+	for i, lon := range f.lons {
+		lonIndex[lon] = i
+	}
+
+	f.u, f.v = newCube(len(f.times), len(f.lats), len(f.lons)), newCube(len(f.times), len(f.lats), len(f.lons))
+	for _, d := range data {
+		t, y, x := timeIndex[d.MeasurementTime.Unix()], latIndex[d.Latitude], lonIndex[d.Longitude]
+		f.u[t][y][x] = float64(d.Values[ui])
+		f.v[t][y][x] = float64(d.Values[vi])
+	}
+	// a single snapshot is held constant like the ones at either end
+	if len(f.times) == 1 {
+		f.times = append(f.times, f.times[0])
+		f.u, f.v = append(f.u, f.u[0]), append(f.v, f.v[0])
+	}
+	return f, nil
+}
+
+func newCube(nt, nlat, nlon int) [][][]float64 {
+	cube := make([][][]float64, nt)
+	for t := range cube {
+		cube[t] = make([][]float64, nlat)
+		for y := range cube[t] {
+			cube[t][y] = make([]float64, nlon)
+			for x := range cube[t][y] {
+				cube[t][y][x] = math.NaN()
+			}
+		}
+	}
+	return cube
+}
+
+// Start and End return the times of the first and last snapshot.
+func (f *Field) Start() time.Time { return f.times[0] }
+func (f *Field) End() time.Time   { return f.times[len(f.times)-1] }
+
+// Velocity returns the eastward and northward velocity in m/s at p and t. It fails with
+// StatusOutside if p is outside of the grid and StatusStranded if a surrounding cell has
+// no velocity, which is the case on land.
+func (f *Field) Velocity(t time.Time, p orb.Point) (u, v float64, status Status) {
+	y, wy, ok := bracket(f.lats, p.Lat())
+	if !ok {
+		return 0, 0, StatusOutside
+	}
+	x, wx, ok := bracket(f.lons, p.Lon())
+	if !ok {
+		return 0, 0, StatusOutside
+	}
+
+	k, wt := f.timeBracket(t)
+	u = lerp(func(i int) float64 { return bilinear(f.u[i], y, x, wy, wx) }, k, wt)
+	v = lerp(func(i int) float64 { return bilinear(f.v[i], y, x, wy, wx) }, k, wt)
+	if math.IsNaN(u) || math.IsNaN(v) {
+		return 0, 0, StatusStranded
+	}
+	return u, v, StatusActive
+}
+
+// timeBracket returns the snapshot k before t and the weight of snapshot k+1,
+// times outside of the snapshots get the nearest one.
+func (f *Field) timeBracket(t time.Time) (int, float64) {
+	n := len(f.times)
+	if !t.After(f.times[0]) {
+		return 0, 0
+	}
+	if !t.Before(f.times[n-1]) {
+		return n - 2, 1
+	}
+	k := sort.Search(n, func(i int) bool { return f.times[i].After(t) }) - 1
+	span := f.times[k+1].Sub(f.times[k])
+	return k, float64(t.Sub(f.times[k])) / float64(span)
+}
+
+// bracket returns the index i of the sorted coordinates with coords[i] <= c <= coords[i+1]
+// and the weight of coords[i+1], false if c is outside of them. A single coordinate only
+// brackets itself.
+func bracket(coords []float64, c float64) (int, float64, bool) {
+	n := len(coords)
+	if c < coords[0] || c > coords[n-1] {
+		return 0, 0, false
+	}
+	if n == 1 {
+		return 0, 0, true
+	}
+	i := sort.SearchFloat64s(coords, c) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i > n-2 {
+		i = n - 2
+	}
+	return i, (c - coords[i]) / (coords[i+1] - coords[i]), true
+}
+
+// lerp interpolates linearly between at(i) and at(i+1), leaving out a value with zero
+// weight so a missing value of the snapshot that isn't used doesn't propagate.
+func lerp(at func(int) float64, i int, w float64) float64 {
+	switch w {
+	case 0:
+		return at(i)
+	case 1:
+		return at(i + 1)
+	}
+	return (1-w)*at(i) + w*at(i+1)
+}
+
+// bilinear interpolates the grid between rows y, y+1 and columns x, x+1. Neighbours
+// with zero weight are left out, so points on the last row or column don't read past it.
+func bilinear(grid [][]float64, y, x int, wy, wx float64) float64 {
+	val := 0.0
+	for _, c := range []struct {
+		dy, dx int
+		w      float64
+	}{
+		{0, 0, (1 - wy) * (1 - wx)},
+		{0, 1, (1 - wy) * wx},
+		{1, 0, wy * (1 - wx)},
+		{1, 1, wy * wx},
+	} {
+		if c.w == 0 {
+			continue
+		}
+		val += c.w * grid[y+c.dy][x+c.dx]
+	}
+	return val
+}
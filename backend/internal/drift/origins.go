@@ -0,0 +1,89 @@
+package drift
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// ReleasesWithin returns n releases at time t spread uniformly at random over area, the
+// same seed gives the same points. Fewer are returned if area encloses next to nothing.
+func ReleasesWithin(area orb.MultiPolygon, t time.Time, n int, seed int64) []Release {
+	rng := rand.New(rand.NewSource(seed))
+	bound := area.Bound()
+	releases := make([]Release, 0, n)
+	for attempts := 0; len(releases) < n && attempts < 1000*n; attempts++ {
+		p := orb.Point{
+			bound.Min.Lon() + rng.Float64()*(bound.Max.Lon()-bound.Min.Lon()),
+			bound.Min.Lat() + rng.Float64()*(bound.Max.Lat()-bound.Min.Lat()),
+		}
+		if planar.MultiPolygonContains(area, p) {
+			releases = append(releases, Release{Point: p, Time: t})
+		}
+	}
+	return releases
+}
+
+// DensityCell counts the trajectories ending within a cell.
+type DensityCell struct {
+	Bound orb.Bound
+	Count int
+}
+
+// Density bins the last position of the trajectories into cells of cellSize degrees aligned
+// to multiples of it, for backward trajectories the probable origins. Trajectories that left
+// the currents are left out, their origin is unknown. The cells are ordered by count, the
+// most probable first.
+func Density(trajectories []Trajectory, cellSize float64) []DensityCell {
+	type key struct{ x, y int }
+	counts := make(map[key]int)
+	for _, traj := range trajectories {
+		if traj.Status == StatusOutside {
+			continue
+		}
+		end := traj.Points[len(traj.Points)-1]
+		counts[key{int(math.Floor(end.Lon() / cellSize)), int(math.Floor(end.Lat() / cellSize))}]++
+	}
+
+	cells := make([]DensityCell, 0, len(counts))
+	for k, count := range counts {
+		cells = append(cells, DensityCell{
+			Bound: orb.Bound{
+				Min: orb.Point{float64(k.x) * cellSize, float64(k.y) * cellSize},
+				Max: orb.Point{float64(k.x+1) * cellSize, float64(k.y+1) * cellSize},
+			},
+			Count: count,
+		})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Bound.Min.Lat() != b.Bound.Min.Lat() {
+			return a.Bound.Min.Lat() < b.Bound.Min.Lat()
+		}
+		return a.Bound.Min.Lon() < b.Bound.Min.Lon()
+	})
+	return cells
+}
+
+// DensityToGeoJSON returns the cells as Polygon features carrying their count and the
+// fraction of the total particles that ended within them.
+func DensityToGeoJSON(cells []DensityCell, total int) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, cell := range cells {
+		feature := geojson.NewFeature(cell.Bound.ToPolygon())
+		feature.Properties = map[string]interface{}{
+			"count":    cell.Count,
+			"fraction": float64(cell.Count) / float64(total),
+		}
+		fc.Append(feature)
+	}
+	return fc
+}
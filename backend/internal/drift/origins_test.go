@@ -0,0 +1,57 @@
+package drift
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+func TestReleasesWithin(t *testing.T) {
+	triangle := orb.MultiPolygon{{{{1, 40}, {2, 40}, {1, 41}, {1, 40}}}}
+	releases := ReleasesWithin(triangle, start, 200, 7)
+	if len(releases) != 200 {
+		t.Fatalf("expected 200 releases; got %d", len(releases))
+	}
+	for _, r := range releases {
+		if !planar.MultiPolygonContains(triangle, r.Point) || !r.Time.Equal(start) {
+			t.Fatalf("expected releases within the triangle at the start; got %v", r)
+		}
+	}
+	if again := ReleasesWithin(triangle, start, 200, 7); !reflect.DeepEqual(again, releases) {
+		t.Errorf("expected the same seed to give the same releases")
+	}
+}
+
+func TestDensity(t *testing.T) {
+	ending := func(status Status, p orb.Point) Trajectory {
+		return Trajectory{Points: []orb.Point{{2, 41}, p}, Status: status}
+	}
+	cells := Density([]Trajectory{
+		ending(StatusActive, orb.Point{1.52, 40.77}),
+		ending(StatusStranded, orb.Point{1.58, 40.79}),
+		ending(StatusActive, orb.Point{1.26, 40.71}),
+		// unknown origin
+		ending(StatusOutside, orb.Point{1.53, 40.76}),
+	}, 0.1)
+
+	if len(cells) != 2 {
+		t.Fatalf("expected 2 cells; got %v", cells)
+	}
+	if cells[0].Count != 2 || cells[1].Count != 1 {
+		t.Errorf("expected the cells ordered by count; got %v", cells)
+	}
+	if b := cells[0].Bound; !nearly(b.Min, orb.Point{1.5, 40.7}) || !nearly(b.Max, orb.Point{1.6, 40.8}) {
+		t.Errorf("expected the cell 1.5-1.6 E, 40.7-40.8 N; got %v", b)
+	}
+
+	fc := DensityToGeoJSON(cells, 4)
+	if fraction := fc.Features[0].Properties["fraction"]; fraction != 0.5 {
+		t.Errorf("expected half of the particles in the first cell; got %v", fraction)
+	}
+}
+
+func nearly(a, b orb.Point) bool {
+	return planar.Distance(a, b) < 1e-9
+}
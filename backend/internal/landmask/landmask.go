@@ -0,0 +1,56 @@
+// Package landmask provides the polygons of land within the area the application
+// covers. Grid cells on land hold no ocean data, so they are neither interpolated
+// nor served.
+package landmask
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Name of the bundled mask in the database.
+const Name = "coastline"
+
+//go:embed coastline.geojson
+var coastline []byte
+
+// Load returns the bundled coastline mask.
+func Load() (orb.MultiPolygon, error) {
+	return Parse(coastline)
+}
+
+// LoadFile returns the mask read from a GeoJSON file.
+func LoadFile(path string) (orb.MultiPolygon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading land mask file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse returns the polygons of a GeoJSON feature collection as a single multipolygon.
+func Parse(data []byte) (orb.MultiPolygon, error) {
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing land mask: %w", err)
+	}
+	var mask orb.MultiPolygon
+	for _, f := range fc.Features {
+		switch g := f.Geometry.(type) {
+		case orb.Polygon:
+			mask = append(mask, g)
+		case orb.MultiPolygon:
+			mask = append(mask, g...)
+		default:
+			return nil, fmt.Errorf("unexpected land mask geometry %s", f.Geometry.GeoJSONType())
+		}
+	}
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("land mask has no polygons")
+	}
+	return mask, nil
+}
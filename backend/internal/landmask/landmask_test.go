@@ -0,0 +1,78 @@
+package landmask
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+func TestBundledMask(t *testing.T) {
+	mask, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		point orb.Point
+		land  bool
+	}{
+		{name: "OBSEA observatory", point: orb.Point{1.752, 41.182}, land: false},
+		{name: "Open sea", point: orb.Point{2.5, 40.8}, land: false},
+		{name: "Barcelona port", point: orb.Point{2.17, 41.34}, land: false},
+		{name: "Vilanova i la Geltru", point: orb.Point{1.725, 41.225}, land: true},
+		{name: "Barcelona", point: orb.Point{2.15, 41.4}, land: true},
+		{name: "Tarragona", point: orb.Point{1.25, 41.13}, land: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := planar.MultiPolygonContains(mask, tt.point); got != tt.land {
+				t.Errorf("point %v on land = %t, want %t", tt.point, got, tt.land)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		polygons int
+		wantErr  bool
+	}{
+		{
+			name:     "Polygon and multipolygon",
+			data:     `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0]]]}},{"type":"Feature","properties":{},"geometry":{"type":"MultiPolygon","coordinates":[[[[2,2],[3,2],[3,3],[2,2]]],[[[4,4],[5,4],[5,5],[4,4]]]]}}]}`,
+			polygons: 3,
+		},
+		{
+			name:    "Point geometry",
+			data:    `{"type":"FeatureCollection","features":[{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[0,0]}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "No features",
+			data:    `{"type":"FeatureCollection","features":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(mask) != tt.polygons {
+				t.Errorf("Parse() returned %d polygons, want %d", len(mask), tt.polygons)
+			}
+		})
+	}
+}
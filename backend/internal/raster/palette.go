@@ -0,0 +1,156 @@
+package raster
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Scale names how values are spread between the stops of a palette.
+type Scale string
+
+const (
+	ScaleLinear Scale = "linear"
+	// ScaleLog interpolates between stops on log10 of the values, its stops must be positive.
+	ScaleLog Scale = "log"
+)
+
+// Stop is a value and the colour it is drawn with, the colour is a #rrggbb hex string
+// as used by the frontend ColorScaleLegend.vue.
+type Stop struct {
+	Value float64
+	Color string
+}
+
+// Palette maps values to colours by interpolating between its stops. Values below the
+// first stop or above the last one get the colour of the nearest stop.
+type Palette struct {
+	Name  string
+	Scale Scale
+	// sorted by ascending value
+	Stops []Stop
+}
+
+// palettes that can be selected for any layer. The colours match the frontend legends.
+var palettes = []*Palette{
+	{
+		Name:  "chlorophyll",
+		Scale: ScaleLog,
+		Stops: []Stop{{0.05, "#ffffcc"}, {0.4, "#41b6c4"}, {3, "#0c2c84"}},
+	},
+	{
+		Name:  "chlorophyll_linear",
+		Scale: ScaleLinear,
+		Stops: []Stop{{0, "#ffffcc"}, {0.4, "#41b6c4"}, {3, "#0c2c84"}},
+	},
+	{
+		Name:  "currents",
+		Scale: ScaleLinear,
+		Stops: []Stop{
+			{0, "#ffffbb"},
+			{0.005, "#c9efdc"},
+			{0.02, "#41b6c4"},
+			{0.05, "#418ac4"},
+			{0.14, "#3136bb"},
+			{0.5, "#1e0755"},
+		},
+	},
+}
+
+func init() {
+	for _, p := range palettes {
+		if err := p.Validate(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// GetPalette returns the palette registered under name.
+func GetPalette(name string) (*Palette, bool) {
+	for _, p := range palettes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// PaletteNames returns the names of the palettes in registration order.
+func PaletteNames() []string {
+	names := make([]string, len(palettes))
+	for i, p := range palettes {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func (p *Palette) Validate() error {
+	if len(p.Stops) < 2 {
+		return fmt.Errorf("palette %s: at least two stops are required", p.Name)
+	}
+	for i, s := range p.Stops {
+		if _, err := parseHexColor(s.Color); err != nil {
+			return fmt.Errorf("palette %s: %w", p.Name, err)
+		}
+		if i > 0 && s.Value <= p.Stops[i-1].Value {
+			return fmt.Errorf("palette %s: stop values must be ascending", p.Name)
+		}
+		if p.Scale == ScaleLog && s.Value <= 0 {
+			return fmt.Errorf("palette %s: stops of a log scale must be positive", p.Name)
+		}
+	}
+	return nil
+}
+
+// Color returns the colour of val, transparent if val is NaN.
+func (p *Palette) Color(val float64) color.NRGBA {
+	if math.IsNaN(val) {
+		return color.NRGBA{}
+	}
+	first, last := p.Stops[0], p.Stops[len(p.Stops)-1]
+	if val <= first.Value {
+		c, _ := parseHexColor(first.Color)
+		return c
+	}
+	if val >= last.Value {
+		c, _ := parseHexColor(last.Color)
+		return c
+	}
+	for i := 1; i < len(p.Stops); i++ {
+		lower, upper := p.Stops[i-1], p.Stops[i]
+		if val > upper.Value {
+			continue
+		}
+		t := (val - lower.Value) / (upper.Value - lower.Value)
+		if p.Scale == ScaleLog {
+			t = math.Log10(val/lower.Value) / math.Log10(upper.Value/lower.Value)
+		}
+		from, _ := parseHexColor(lower.Color)
+		to, _ := parseHexColor(upper.Color)
+		return color.NRGBA{
+			R: mix(from.R, to.R, t),
+			G: mix(from.G, to.G, t),
+			B: mix(from.B, to.B, t),
+			A: 255,
+		}
+	}
+	c, _ := parseHexColor(last.Color)
+	return c
+}
+
+func mix(from, to uint8, t float64) uint8 {
+	return uint8(math.Round(float64(from) + (float64(to)-float64(from))*t))
+}
+
+// parseHexColor parses an opaque #rrggbb colour.
+func parseHexColor(s string) (color.NRGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+	rgb, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q", s)
+	}
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
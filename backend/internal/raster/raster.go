@@ -0,0 +1,153 @@
+// Package raster renders dataset grids into XYZ image tiles coloured with a palette,
+// so they can be shown by any web map without a vector renderer.
+package raster
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb/maptile"
+)
+
+// TileSize is the width and height of rendered tiles in pixels.
+const TileSize = 256
+
+// Layer is a quantity of a dataset that can be rendered.
+type Layer struct {
+	Name    string
+	Dataset string
+	Title   string
+	Unit    string
+	// palette used unless another one is requested
+	Palette string
+	// value returns the rendered quantity of a grid cell of the dataset
+	value func(ds *datasets.Dataset, d models.GridData) float64
+}
+
+var layers = []*Layer{
+	{
+		Name:    "chlorophyll",
+		Dataset: "chlorophyll",
+		Title:   "Chlorophyll concentration",
+		Unit:    "mg m-3",
+		Palette: "chlorophyll",
+		value: func(ds *datasets.Dataset, d models.GridData) float64 {
+			return float64(d.Values[ds.ColumnIndex("chlor_a")])
+		},
+	},
+	{
+		Name:    "currents",
+		Dataset: "currents",
+		Title:   "Current Speed",
+		Unit:    "m/s",
+		Palette: "currents",
+		value: func(ds *datasets.Dataset, d models.GridData) float64 {
+			u := float64(d.Values[ds.ColumnIndex(ds.Vector.U)])
+			v := float64(d.Values[ds.ColumnIndex(ds.Vector.V)])
+			return math.Hypot(u, v)
+		},
+	},
+}
+
+// GetLayer returns the layer registered under name.
+func GetLayer(name string) (*Layer, bool) {
+	for _, l := range layers {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// Value returns the rendered quantity of a grid cell of the layer dataset, NaN if it's missing.
+func (l *Layer) Value(ds *datasets.Dataset, d models.GridData) float64 {
+	return l.value(ds, d)
+}
+
+// Render draws the grid cells of the layer dataset into a tile. Every pixel takes the colour
+// of the grid cell its centre falls into, pixels outside of the cells or on cells with
+// missing values are transparent. The cells must form a regular grid.
+func (l *Layer) Render(ds *datasets.Dataset, data []models.GridData, palette *Palette, tile maptile.Tile) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+	g := newGrid(data, func(d models.GridData) float64 { return l.value(ds, d) })
+	if g == nil {
+		return img
+	}
+
+	n := float64(uint64(1) << tile.Z)
+	for py := 0; py < TileSize; py++ {
+		// inverse web mercator of the pixel centre
+		y := (float64(tile.Y) + (float64(py)+0.5)/TileSize) / n
+		lat := math.Atan(math.Sinh(math.Pi*(1-2*y))) * 180 / math.Pi
+		for px := 0; px < TileSize; px++ {
+			lon := (float64(tile.X)+(float64(px)+0.5)/TileSize)/n*360 - 180
+			if val, ok := g.at(lat, lon); ok {
+				img.SetNRGBA(px, py, palette.Color(val))
+			}
+		}
+	}
+	return img
+}
+
+// grid looks up the values of a regular grid by location.
+type grid struct {
+	originLat, originLon float64
+	cellLat, cellLon     float64
+	values               map[[2]int]float64
+}
+
+// newGrid returns the grid of the cells, nil if the cell size can't be told from them.
+func newGrid(data []models.GridData, value func(models.GridData) float64) *grid {
+	lats := make([]float64, len(data))
+	lons := make([]float64, len(data))
+	for i, d := range data {
+		lats[i], lons[i] = d.Latitude, d.Longitude
+	}
+	cellLat, cellLon := minSpacing(lats), minSpacing(lons)
+	// a single row or column of cells is assumed to have square cells
+	if cellLat == 0 {
+		cellLat = cellLon
+	}
+	if cellLon == 0 {
+		cellLon = cellLat
+	}
+	if cellLat == 0 {
+		return nil
+	}
+
+	g := &grid{originLat: lats[0], originLon: lons[0], cellLat: cellLat, cellLon: cellLon, values: make(map[[2]int]float64)}
+	for _, d := range data {
+		val := value(d)
+		if math.IsNaN(val) {
+			continue
+		}
+		g.values[g.index(d.Latitude, d.Longitude)] = val
+	}
+	return g
+}
+
+func (g *grid) index(lat, lon float64) [2]int {
+	return [2]int{int(math.Round((lat - g.originLat) / g.cellLat)), int(math.Round((lon - g.originLon) / g.cellLon))}
+}
+
+func (g *grid) at(lat, lon float64) (float64, bool) {
+	val, ok := g.values[g.index(lat, lon)]
+	return val, ok
+}
+
+// minSpacing returns the smallest distance between distinct values, 0 if they are all the same.
+// It sorts values in place.
+func minSpacing(values []float64) float64 {
+	sort.Float64s(values)
+	spacing := 0.0
+	for i := 1; i < len(values); i++ {
+		if d := values[i] - values[i-1]; d > 1e-9 && (spacing == 0 || d < spacing) {
+			spacing = d
+		}
+	}
+	return spacing
+}
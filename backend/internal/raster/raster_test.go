@@ -0,0 +1,104 @@
+package raster
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb/maptile"
+)
+
+func TestPaletteColor(t *testing.T) {
+	chlorophyll, _ := GetPalette("chlorophyll")
+	currents, _ := GetPalette("currents")
+
+	tests := []struct {
+		name    string
+		palette *Palette
+		value   float64
+		want    color.NRGBA
+	}{
+		{name: "Stop", palette: currents, value: 0.02, want: color.NRGBA{0x41, 0xb6, 0xc4, 255}},
+		{name: "Below first stop", palette: currents, value: -1, want: color.NRGBA{0xff, 0xff, 0xbb, 255}},
+		{name: "Above last stop", palette: currents, value: 2, want: color.NRGBA{0x1e, 0x07, 0x55, 255}},
+		{name: "Linear between stops", palette: currents, value: 0.035, want: color.NRGBA{0x41, 0xa0, 0xc4, 255}},
+		// sqrt(0.4*3) is half way between 0.4 and 3 on a log scale
+		{name: "Log between stops", palette: chlorophyll, value: math.Sqrt(0.4 * 3), want: color.NRGBA{0x27, 0x71, 0xa4, 255}},
+		{name: "Missing value", palette: chlorophyll, value: math.NaN(), want: color.NRGBA{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.palette.Color(tt.value); got != tt.want {
+				t.Errorf("expected colour %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPaletteValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		palette Palette
+	}{
+		{name: "Single stop", palette: Palette{Name: "p", Scale: ScaleLinear, Stops: []Stop{{0, "#000000"}}}},
+		{name: "Descending stops", palette: Palette{Name: "p", Scale: ScaleLinear, Stops: []Stop{{1, "#000000"}, {0, "#ffffff"}}}},
+		{name: "Zero on log scale", palette: Palette{Name: "p", Scale: ScaleLog, Stops: []Stop{{0, "#000000"}, {1, "#ffffff"}}}},
+		{name: "Invalid colour", palette: Palette{Name: "p", Scale: ScaleLinear, Stops: []Stop{{0, "black"}, {1, "#ffffff"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.palette.Validate(); err == nil {
+				t.Errorf("expected palette to be rejected")
+			}
+		})
+	}
+}
+
+// pixel returns the pixel of a tile a location falls into.
+func pixel(tile maptile.Tile, lat, lon float64) (int, int) {
+	n := float64(uint64(1) << tile.Z)
+	x := (lon + 180) / 360 * n
+	y := (1 - math.Log(math.Tan(lat*math.Pi/180)+1/math.Cos(lat*math.Pi/180))/math.Pi) / 2 * n
+	return int((x - float64(tile.X)) * TileSize), int((y - float64(tile.Y)) * TileSize)
+}
+
+func TestLayerRender(t *testing.T) {
+	ds, _ := datasets.Get("currents")
+	layer, _ := GetLayer("currents")
+	palette, _ := GetPalette("currents")
+	tile := maptile.New(129, 95, 8)
+
+	// a 2x2 grid of 0.25 degree cells, the north eastern cell is missing
+	data := []models.GridData{
+		{Latitude: 41, Longitude: 1.75, Values: []float32{0.03, 0.04}},
+		{Latitude: 41, Longitude: 2, Values: []float32{0, 0.5}},
+		{Latitude: 41.25, Longitude: 1.75, Values: []float32{0, 0}},
+		{Latitude: 41.25, Longitude: 2, Values: []float32{float32(math.NaN()), 0.1}},
+	}
+	img := layer.Render(ds, data, palette, tile)
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     color.NRGBA
+	}{
+		{name: "Cell centre", lat: 41, lon: 1.75, want: palette.Color(0.05)},
+		{name: "Within cell", lat: 41.1, lon: 2.1, want: palette.Color(0.5)},
+		{name: "Missing cell", lat: 41.25, lon: 2, want: color.NRGBA{}},
+		{name: "Outside of the grid", lat: 41, lon: 1.5, want: color.NRGBA{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := pixel(tile, tt.lat, tt.lon)
+			if got := img.NRGBAAt(x, y); got != tt.want {
+				t.Errorf("expected colour %v at pixel (%d, %d); got %v", tt.want, x, y, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+// aggregateQuery holds the parameters of the aggregate endpoints.
+type aggregateQuery struct {
+	datasetQuery
+	period string
+	stat   string
+}
+
+// parseAggregateQuery validates the query parameters of the aggregate endpoints. Composites
+// span several days, so without start_time the longest allowed time range is aggregated.
+func parseAggregateQuery(values url.Values, body []byte, now time.Time) (aggregateQuery, error) {
+	p := newQueryParser(values)
+	var q aggregateQuery
+	q.startTime, q.endTime = p.timeRangeWithDefault(now, maxTimeWindow)
+	q.minLat, q.minLon, q.maxLat, q.maxLon = p.boundingBox()
+	p.area(&q.datasetQuery, body)
+	q.rawData = p.bool("raw_data", false)
+	q.maskLand = p.bool("mask_land", false)
+	q.format = p.oneOf("format", "", formatGeoJSON, formatCSV)
+	q.period = p.oneOf("period", models.PeriodWeek, models.AggregatePeriods...)
+	q.stat = p.oneOf("stat", models.StatMean, models.AggregateStats...)
+	return q, p.err()
+}
+
+// GetDatasetAggregateHandler returns a handler serving per cell statistics of the dataset
+// over days, weeks or months as GeoJSON or CSV. POST requests restrict the cells to the
+// GeoJSON polygon of their body.
+func (s *Server) GetDatasetAggregateHandler(ds *datasets.Dataset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := readAreaBody(w, r)
+		if err != nil {
+			s.respondWithError(w, http.StatusBadRequest, "Error reading request body: "+err.Error())
+			return
+		}
+		q, err := parseAggregateQuery(r.URL.Query(), body, time.Now().UTC())
+		if err != nil {
+			s.respondWithValidationError(w, err)
+			return
+		}
+		if !s.loadRegion(w, r, &q.datasetQuery) {
+			return
+		}
+
+		data, err := s.db.AggregateDatasetData(r.Context(), ds, q.period, q.stat, q.startTime, q.endTime, q.minLat, q.minLon, q.maxLat, q.maxLon, q.area, q.rawData, q.maskLand)
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, "Error aggregating "+ds.Name+" data: "+err.Error())
+			return
+		}
+
+		// NetCDF isn't offered for aggregates, an Accept header asking for it gets the default
+		if responseFormat(r, q.datasetQuery) != formatCSV {
+			s.respondWithJSON(w, http.StatusOK, models.AggregateDataToGeoJSON(ds, q.period, q.stat, data))
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+ds.Name+"_"+q.period+"_"+q.stat+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := models.WriteAggregateDataCSV(w, ds, q.period, q.stat, data); err != nil {
+			slog.Error("Error writing aggregates", "dataset", ds.Name, "err", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestParseAggregateQuery(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		query      string
+		want       aggregateQuery
+		wantFields []string
+	}{
+		{
+			name:  "Defaults",
+			query: "",
+			want: aggregateQuery{
+				datasetQuery: datasetQuery{
+					startTime: now.Add(-maxTimeWindow), endTime: now,
+					minLat: defaultMinLat, minLon: defaultMinLon, maxLat: defaultMaxLat, maxLon: defaultMaxLon,
+				},
+				period: models.PeriodWeek,
+				stat:   models.StatMean,
+			},
+		},
+		{
+			name:  "Monthly median as CSV",
+			query: "period=month&stat=median&format=csv&start_time=2025-03-01&end_time=2025-05-31",
+			want: aggregateQuery{
+				datasetQuery: datasetQuery{
+					startTime: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), endTime: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+					minLat: defaultMinLat, minLon: defaultMinLon, maxLat: defaultMaxLat, maxLon: defaultMaxLon,
+					format: formatCSV,
+				},
+				period: models.PeriodMonth,
+				stat:   models.StatMedian,
+			},
+		},
+		{
+			name:       "Unknown period, statistic and format",
+			query:      "period=year&stat=min&format=netcdf",
+			wantFields: []string{"format", "period", "stat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := parseAggregateQuery(values, nil, now)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected query %+v; got %+v", tt.want, got)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestGetDatasetAggregateHandler(t *testing.T) {
+	week := time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC)
+	aggregates := []models.AggregateData{
+		{PeriodStart: week, Latitude: 41.125, Longitude: 1.875, Values: []float32{0, 0.5}, Samples: 7},
+		// no valid values during the week
+		{PeriodStart: week, Latitude: 41.125, Longitude: 2, Values: []float32{float32(math.NaN()), float32(math.NaN())}, Samples: 7},
+	}
+	s := &Server{db: &fakeDB{aggregates: aggregates}}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	t.Run("GeoJSON", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/currents/aggregate?period=week&stat=mean")
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+
+		var fc struct {
+			Features []struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		if len(fc.Features) != 1 {
+			t.Fatalf("expected the cell with missing values to be skipped; got %d features", len(fc.Features))
+		}
+		want := map[string]any{
+			"period": "week", "period_start": "2025-01-27T00:00:00Z", "stat": "mean", "samples": 7.0,
+			"u_current": 0.0, "v_current": 0.5, "current_angle": 0.0, "magnitude": 0.5,
+		}
+		if got := fc.Features[0].Properties; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected properties %v; got %v", want, got)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/currents/aggregate?stat=count&format=csv")
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+		if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="currents_week_count.csv"` {
+			t.Errorf("unexpected Content-Disposition %q", cd)
+		}
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		want := [][]string{
+			// counts have no direction
+			{"period", "period_start", "stat", "latitude", "longitude", "samples", "u_current", "v_current"},
+			{"week", "2025-01-27T00:00:00Z", "count", "41.125", "1.875", "7", "0", "0.5"},
+			{"week", "2025-01-27T00:00:00Z", "count", "41.125", "2", "7", "", ""},
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Errorf("expected records %v; got %v", want, records)
+		}
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/chlorophyll/aggregate?stat=sum")
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status Bad Request; got %v", resp.Status)
+		}
+	})
+}
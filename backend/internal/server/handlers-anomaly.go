@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+// anomalyEncoder writes the anomalies of the grid cells with the wrapped encoder, which
+// must encode the dataset of the climatology.
+type anomalyEncoder struct {
+	gridDataEncoder
+	climatology *models.Climatology
+}
+
+func (a anomalyEncoder) Write(d models.GridData) error {
+	return a.gridDataEncoder.Write(a.climatology.Anomalies(d))
+}
+
+// loadClimatology returns the climatology of the days and bounding box of q, responding
+// with 404 if the dataset has none there.
+func (s *Server) loadClimatology(w http.ResponseWriter, r *http.Request, ds *datasets.Dataset, q datasetQuery) (*models.Climatology, bool) {
+	cells, err := s.db.GetClimatology(r.Context(), ds, climatologyDays(q.startTime, q.endTime), models.ClimatologyWindowDays, q.minLat, q.minLon, q.maxLat, q.maxLon)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" climatology: "+err.Error())
+		return nil, false
+	}
+	if len(cells) == 0 {
+		s.respondWithError(w, http.StatusNotFound, "No "+ds.Name+" climatology in the requested area")
+		return nil, false
+	}
+	return models.NewClimatology(ds, cells), true
+}
+
+// climatologyDays returns the days of the year of every UTC day from start to end, sorted.
+func climatologyDays(start, end time.Time) []int {
+	seen := make(map[int]bool)
+	var days []int
+	for t := start.UTC().Truncate(24 * time.Hour); !t.After(end); t = t.Add(24 * time.Hour) {
+		day := models.DayOfYear(t)
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
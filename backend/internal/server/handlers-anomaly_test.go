@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+func TestGetDatasetDataHandlerAnomalies(t *testing.T) {
+	// regionTestData is measured on the first day of the year
+	db := &fakeDB{data: regionTestData, climatology: []models.ClimatologyCell{
+		{Variable: "chlor_a", DayOfYear: 1, Latitude: 41.1, Longitude: 1.9, Samples: 20, Mean: 0.3, Std: 0.1},
+		// too few samples for an anomaly
+		{Variable: "chlor_a", DayOfYear: 1, Latitude: 41.3, Longitude: 2.1, Samples: 5, Mean: 0.5, Std: 0.1},
+	}}
+	s := &Server{db: db}
+	ds, _ := datasets.Get("chlorophyll")
+	server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+	defer server.Close()
+
+	get := func(query string) *http.Response {
+		t.Helper()
+		resp, err := http.Get(server.URL + "?start_time=2024-12-31&end_time=2025-01-01T12:00:00Z&" + query)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		resp := get("anomaly=true&format=csv")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+		if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="chlorophyll_anomaly.csv"` {
+			t.Errorf("unexpected content disposition %q", cd)
+		}
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("error reading CSV body. Err: %v", err)
+		}
+		want := [][]string{
+			{"measurement_time", "latitude", "longitude", "chlor_a", "chlor_a_anomaly", "chlor_a_zscore", "provenance", "interpolation_method", "interpolation_variance"},
+			{"2025-01-01T00:00:00Z", "41.1", "1.9", "0.5", "0.2", "2", "observed", "", ""},
+			{"2025-01-01T00:00:00Z", "41.3", "2.1", "0.7", "", "", "observed", "", ""},
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Errorf("expected records %v; got %v", want, records)
+		}
+		// the last day of 2024 and the first of 2025
+		if !reflect.DeepEqual(db.days, []int{1, 365}) {
+			t.Errorf("expected the days of the time range; got %v", db.days)
+		}
+	})
+
+	t.Run("GeoJSON", func(t *testing.T) {
+		resp := get("anomaly=true")
+		var fc struct {
+			Features []struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		// cells without a climatology are left out like those without a value
+		if len(fc.Features) != 1 {
+			t.Fatalf("expected the single cell with a climatology; got %d", len(fc.Features))
+		}
+		props := fc.Features[0].Properties
+		if props["chlor_a"] != 0.5 || props["chlor_a_zscore"] != 2.0 {
+			t.Errorf("unexpected properties %v", props)
+		}
+	})
+
+	t.Run("No climatology", func(t *testing.T) {
+		db.climatology = nil
+		if resp := get("anomaly=true"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status Not Found; got %v", resp.Status)
+		}
+		if resp := get("anomaly=false"); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status OK without anomalies; got %v", resp.Status)
+		}
+	})
+
+	t.Run("Invalid parameter", func(t *testing.T) {
+		if resp := get("anomaly=maybe"); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status Bad Request; got %v", resp.Status)
+		}
+	})
+}
+
+func TestClimatologyDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       []int
+	}{
+		{
+			name:  "Single day",
+			start: time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC),
+			want:  []int{60},
+		},
+		{
+			name:  "Partial days at both ends",
+			start: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 1, 3, 6, 0, 0, 0, time.UTC),
+			want:  []int{1, 2, 3},
+		},
+		{
+			name:  "Leap day",
+			start: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			want:  []int{59, 60},
+		},
+		{
+			name:  "Turn of the year",
+			start: time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+			end:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:  []int{1, 364, 365},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := climatologyDays(tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected days %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
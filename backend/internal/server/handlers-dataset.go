@@ -0,0 +1,270 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+// Response formats of the dataset endpoints, selected with the format parameter or the Accept header.
+const (
+	formatGeoJSON = "geojson"
+	formatCSV     = "csv"
+	formatNetCDF  = "netcdf"
+)
+
+// mediaTypeFormats maps the media types accepted in the Accept header to response formats.
+var mediaTypeFormats = map[string]string{
+	"application/json":     formatGeoJSON,
+	"application/geo+json": formatGeoJSON,
+	"text/csv":             formatCSV,
+	"application/x-netcdf": formatNetCDF,
+	"application/netcdf":   formatNetCDF,
+}
+
+// responseFormat returns the format requested by the format parameter, or else the first
+// supported media type of the Accept header. GeoJSON is the default.
+func responseFormat(r *http.Request, q datasetQuery) string {
+	if q.format != "" {
+		return q.format
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if format, ok := mediaTypeFormats[mediaType]; ok {
+			return format
+		}
+	}
+	return formatGeoJSON
+}
+
+// maxAreaBodySize limits the GeoJSON polygons POSTed to the dataset endpoints.
+const maxAreaBodySize = 1 << 20
+
+// readAreaBody returns the body of POST requests, which holds the GeoJSON polygon the
+// request is restricted to, and nil for any other method.
+func readAreaBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if r.Method != http.MethodPost {
+		return nil, nil
+	}
+	return io.ReadAll(http.MaxBytesReader(w, r.Body, maxAreaBodySize))
+}
+
+// maxJSONBodySize limits the JSON objects POSTed to create resources.
+const maxJSONBodySize = 64 << 10
+
+// readJSONBody returns the body of a request holding a JSON object, rejecting an empty,
+// oversized or unreadable body as a *ValidationError of the body field.
+func readJSONBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	p := newQueryParser(nil)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJSONBodySize))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		p.fail("body", "must not exceed %d bytes", tooLarge.Limit)
+	case err != nil:
+		p.fail("body", "could not be read: %s", err)
+	case len(bytes.TrimSpace(body)) == 0:
+		p.fail("body", "is required")
+	}
+	return body, p.err()
+}
+
+// loadRegion restricts q to its saved region, if it has one, responding with 404 if the region doesn't exist.
+func (s *Server) loadRegion(w http.ResponseWriter, r *http.Request, q *datasetQuery) bool {
+	if q.regionID == 0 {
+		return true
+	}
+	region, err := s.db.GetRegion(r.Context(), q.regionID)
+	if errors.Is(err, database.ErrRegionNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Unknown region "+strconv.Itoa(q.regionID))
+		return false
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving region: "+err.Error())
+		return false
+	}
+	q.setArea(region.Geometry)
+	return true
+}
+
+// GetDatasetDataHandler returns a handler serving the dataset as GeoJSON, CSV or NetCDF.
+// POST requests restrict the data to the GeoJSON polygon of their body.
+func (s *Server) GetDatasetDataHandler(ds *datasets.Dataset) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := readAreaBody(w, r)
+		if err != nil {
+			s.respondWithError(w, http.StatusBadRequest, "Error reading request body: "+err.Error())
+			return
+		}
+		q, err := parseDatasetQuery(r.URL.Query(), body, time.Now().UTC())
+		if err != nil {
+			s.respondWithValidationError(w, err)
+			return
+		}
+		if !s.loadRegion(w, r, &q) {
+			return
+		}
+
+		// served describes the values written, which differ from the stored ones in anomaly mode
+		served, filename := ds, ds.Name
+		var climatology *models.Climatology
+		if q.anomaly {
+			var ok bool
+			if climatology, ok = s.loadClimatology(w, r, ds, q); !ok {
+				return
+			}
+			served, filename = climatology.Dataset(), ds.Name+"_anomaly"
+		}
+		encoder := func(enc gridDataEncoder) gridDataEncoder {
+			if climatology == nil {
+				return enc
+			}
+			return anomalyEncoder{enc, climatology}
+		}
+
+		switch responseFormat(r, q) {
+		case formatCSV:
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+			s.streamDataset(w, r, ds, q, encoder(models.NewGridDataCSVWriter(w, served)))
+		case formatNetCDF:
+			s.writeDatasetNetCDF(w, r, ds, q, climatology)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			s.streamDataset(w, r, ds, q, encoder(models.NewGridDataGeoJSONWriter(w, served)))
+		}
+	}
+}
+
+// gridDataEncoder writes grid cells in a streamed response format.
+type gridDataEncoder interface {
+	Write(models.GridData) error
+	Flush() error
+	// Close completes the document and flushes it
+	Close() error
+}
+
+const (
+	// streamFlushInterval is the number of rows written between flushes of a streamed response
+	streamFlushInterval = 1000
+	// streamWriteTimeout is the time a streamed response may take until the next flush,
+	// it replaces the WriteTimeout of the server for long responses
+	streamWriteTimeout = 30 * time.Second
+)
+
+// streamDataset writes the dataset rows with enc while they are read from the database,
+// flushing them to the client every streamFlushInterval rows. The content headers must
+// already be set. Errors after the first row was written can only be logged, as the
+// status was already sent.
+func (s *Server) streamDataset(w http.ResponseWriter, r *http.Request, ds *datasets.Dataset, q datasetQuery, enc gridDataEncoder) {
+	rc := http.NewResponseController(w)
+	started := false
+	rows := 0
+	start := func() {
+		if started {
+			return
+		}
+		started = true
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err := s.db.StreamDatasetData(r.Context(), ds, q.startTime, q.endTime, q.minLat, q.minLon, q.maxLat, q.maxLon, q.area, q.rawData, q.maskLand, func(d models.GridData) error {
+		start()
+		if err := enc.Write(d); err != nil {
+			return err
+		}
+		rows++
+		if rows%streamFlushInterval != 0 {
+			return nil
+		}
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		// the deadline can't be extended by every ResponseWriter, e.g. in tests
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err != nil && !started {
+		w.Header().Del("Content-Disposition")
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" data: "+err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("Error streaming data", "dataset", ds.Name, "err", err)
+		return
+	}
+
+	start()
+	if err := enc.Close(); err != nil {
+		slog.Error("Error encoding data", "dataset", ds.Name, "err", err)
+	}
+}
+
+// writeDatasetNetCDF responds with the dataset as a gridded NetCDF file, with the anomalies
+// of the climatology if it's not nil. The writer only writes to files, so the file is
+// built in a temporary directory and copied.
+func (s *Server) writeDatasetNetCDF(w http.ResponseWriter, r *http.Request, ds *datasets.Dataset, q datasetQuery, climatology *models.Climatology) {
+	data, err := s.db.GetDatasetData(r.Context(), ds, q.startTime, q.endTime, q.minLat, q.minLon, q.maxLat, q.maxLon, q.area, q.rawData, q.maskLand)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" data: "+err.Error())
+		return
+	}
+	if len(data) == 0 {
+		s.respondWithError(w, http.StatusNotFound, "No "+ds.Name+" data in the requested range")
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "netcdf-export-")
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error creating NetCDF file: "+err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	served, filename := ds, ds.Name
+	if climatology != nil {
+		served, filename = climatology.Dataset(), ds.Name+"_anomaly"
+		for i := range data {
+			data[i] = climatology.Anomalies(data[i])
+		}
+	}
+	path := filepath.Join(dir, filename+".nc")
+	if err := models.WriteGridDataNetCDF(path, served, data); err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error encoding "+ds.Name+" data: "+err.Error())
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error reading NetCDF file: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-netcdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.nc"`)
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, file); err != nil {
+		slog.Error("Error writing data", "dataset", ds.Name, "err", err)
+	}
+}
@@ -0,0 +1,457 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/batchatco/go-native-netcdf/netcdf"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// fakeDB serves fixed dataset rows, the methods it doesn't override panic.
+type fakeDB struct {
+	database.Service
+	data       []models.GridData
+	aggregates []models.AggregateData
+	latest     time.Time
+	regions    []models.Region
+	blooms     []models.BloomEvent
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+	// climatology cells served for every requested day of the year
+	climatology []models.ClimatologyCell
+	days        []int
+	// time range and area of the last GetDatasetData call
+	startTime, endTime time.Time
+	area               orb.MultiPolygon
+}
+
+// GetDatasetData returns the rows within area like the database, ignoring every other filter.
+func (f *fakeDB) GetDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.GridData, error) {
+	f.startTime, f.endTime, f.area = startTime, endTime, area
+	if area == nil {
+		return f.data, nil
+	}
+	var inside []models.GridData
+	for _, d := range f.data {
+		if planar.MultiPolygonContains(area, orb.Point{d.Longitude, d.Latitude}) {
+			inside = append(inside, d)
+		}
+	}
+	return inside, nil
+}
+
+func (f *fakeDB) GetDatasetTimeSeries(ctx context.Context, ds *datasets.Dataset, point orb.Point, startTime, endTime time.Time, rawData bool) ([]models.GridData, error) {
+	f.startTime, f.endTime = startTime, endTime
+	return f.data, nil
+}
+
+func (f *fakeDB) GetAllDatasetTimestamps(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error) {
+	if f.latest.IsZero() {
+		return nil, nil
+	}
+	return []time.Time{f.latest}, nil
+}
+
+func (f *fakeDB) GetLatestDatasetTimestamp(ctx context.Context, ds *datasets.Dataset) (time.Time, error) {
+	return f.latest, nil
+}
+
+func (f *fakeDB) AggregateDatasetData(ctx context.Context, ds *datasets.Dataset, period, stat string, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool) ([]models.AggregateData, error) {
+	f.startTime, f.endTime, f.area = startTime, endTime, area
+	return f.aggregates, nil
+}
+
+func (f *fakeDB) StreamDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rawData, maskLand bool, fn func(models.GridData) error) error {
+	data, _ := f.GetDatasetData(ctx, ds, startTime, endTime, minLat, minLon, maxLat, maxLon, area, rawData, maskLand)
+	for _, d := range data {
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBloomEvents returns the events of rule intersecting area, ignoring every other filter.
+func (f *fakeDB) GetBloomEvents(ctx context.Context, startTime, endTime time.Time, minLat, minLon, maxLat, maxLon float64, area orb.MultiPolygon, rule string) ([]models.BloomEvent, error) {
+	f.startTime, f.endTime, f.area = startTime, endTime, area
+	var events []models.BloomEvent
+	for _, e := range f.blooms {
+		if (rule == "" || e.Rule == rule) && (area == nil || area.Bound().Intersects(e.Geometry.Bound())) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeDB) SaveRegion(ctx context.Context, name string, area orb.MultiPolygon) (models.Region, error) {
+	for _, r := range f.regions {
+		if r.Name == name {
+			return models.Region{}, database.ErrRegionExists
+		}
+	}
+	region := models.Region{ID: len(f.regions) + 1, Name: name, Geometry: area}
+	f.regions = append(f.regions, region)
+	return region, nil
+}
+
+func (f *fakeDB) GetRegion(ctx context.Context, id int) (models.Region, error) {
+	for _, r := range f.regions {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return models.Region{}, database.ErrRegionNotFound
+}
+
+func (f *fakeDB) GetRegions(ctx context.Context) ([]models.Region, error) {
+	return f.regions, nil
+}
+
+func (f *fakeDB) DeleteRegion(ctx context.Context, id int) error {
+	for i, r := range f.regions {
+		if r.ID == id {
+			f.regions = append(f.regions[:i], f.regions[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrRegionNotFound
+}
+
+func (f *fakeDB) CreateWebhook(ctx context.Context, url string, events []string, secret string) (models.Webhook, error) {
+	webhook := models.Webhook{ID: len(f.webhooks) + 1, URL: url, Events: events, Secret: secret}
+	f.webhooks = append(f.webhooks, webhook)
+	return webhook, nil
+}
+
+func (f *fakeDB) GetWebhook(ctx context.Context, id int) (models.Webhook, error) {
+	for _, w := range f.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return models.Webhook{}, database.ErrWebhookNotFound
+}
+
+func (f *fakeDB) GetWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return f.webhooks, nil
+}
+
+func (f *fakeDB) DeleteWebhook(ctx context.Context, id int) error {
+	for i, w := range f.webhooks {
+		if w.ID == id {
+			f.webhooks = append(f.webhooks[:i], f.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrWebhookNotFound
+}
+
+// GetClimatology returns the cells of the requested days, ignoring the window and bounding box.
+func (f *fakeDB) GetClimatology(ctx context.Context, ds *datasets.Dataset, days []int, windowDays int, minLat, minLon, maxLat, maxLon float64) ([]models.ClimatologyCell, error) {
+	f.days = days
+	var cells []models.ClimatologyCell
+	for _, c := range f.climatology {
+		for _, day := range days {
+			if c.DayOfYear == day {
+				cells = append(cells, c)
+			}
+		}
+	}
+	return cells, nil
+}
+
+// GetWebhookDeliveries returns the first limit deliveries to the webhook, which are kept newest first.
+func (f *fakeDB) GetWebhookDeliveries(ctx context.Context, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, d := range f.deliveries {
+		if d.WebhookID == webhookID && len(deliveries) < limit {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func TestGetDatasetDataHandlerRejectsInvalidQuery(t *testing.T) {
+	s := &Server{}
+	ds, _ := datasets.Get("chlorophyll")
+	server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?start_time=bad&raw_data=maybe")
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request; got %v", resp.Status)
+	}
+	var body errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	if body.Error != "invalid query parameters" || len(body.Details) != 2 {
+		t.Errorf("expected an error with 2 rejected fields; got %+v", body)
+	}
+}
+
+func TestReadJSONBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    io.Reader
+		wantErr bool
+	}{
+		{name: "JSON object", body: strings.NewReader(`{"url":"https://example.com"}`)},
+		{name: "Empty", body: strings.NewReader(" \n"), wantErr: true},
+		{name: "Oversized", body: strings.NewReader(strings.Repeat(" ", maxJSONBodySize+1)), wantErr: true},
+		{name: "Unreadable", body: iotest.ErrReader(errors.New("connection reset")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", tt.body)
+			_, err := readJSONBody(httptest.NewRecorder(), r)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Message != "invalid request body" ||
+				len(validationErr.Fields) != 1 || validationErr.Fields[0].Field != "body" {
+				t.Errorf("expected the body to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetDatasetDataHandlerCSV(t *testing.T) {
+	variance := float32(0.25)
+	measured := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{db: &fakeDB{data: []models.GridData{
+		{MeasurementTime: measured, Latitude: 41.125, Longitude: 1.875, Values: []float32{0, 0.5}, Provenance: models.ProvenanceObserved},
+		{MeasurementTime: measured, Latitude: 41.125, Longitude: 2, Values: []float32{float32(math.NaN()), 0.5},
+			Provenance: models.ProvenanceAreaInterpolated, InterpolationMethod: "ordinary_kriging", InterpolationVariance: &variance},
+	}}}
+	ds, _ := datasets.Get("currents")
+	server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+	defer server.Close()
+
+	want := [][]string{
+		{"measurement_time", "latitude", "longitude", "u_current", "v_current", "current_angle", "magnitude", "provenance", "interpolation_method", "interpolation_variance"},
+		{"2025-01-01T00:00:00Z", "41.125", "1.875", "0", "0.5", "0", "0.5", "observed", "", ""},
+		{"2025-01-01T00:00:00Z", "41.125", "2", "", "0.5", "", "", "area_interpolated", "ordinary_kriging", "0.25"},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+	}{
+		{name: "Format parameter", query: "?format=csv"},
+		{name: "Accept header", accept: "text/csv;q=0.9, application/json;q=0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, server.URL+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status OK; got %v", resp.Status)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+				t.Errorf("expected CSV content type; got %q", ct)
+			}
+			records, err := csv.NewReader(resp.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("error reading CSV body. Err: %v", err)
+			}
+			if !reflect.DeepEqual(records, want) {
+				t.Errorf("expected records %v; got %v", want, records)
+			}
+		})
+	}
+}
+
+func TestGetDatasetDataHandlerDefaultsToGeoJSON(t *testing.T) {
+	s := &Server{db: &fakeDB{}}
+	ds, _ := datasets.Get("chlorophyll")
+	server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", "text/html, */*")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected GeoJSON content type; got %q", ct)
+	}
+}
+
+func TestGetDatasetDataHandlerStreamsGeoJSON(t *testing.T) {
+	measured := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// more rows than streamFlushInterval, so the response is flushed while streaming
+	var data []models.GridData
+	for i := 0; i < 2*streamFlushInterval+1; i++ {
+		u := float32(i) / 100
+		if i%10 == 0 {
+			u = float32(math.NaN())
+		}
+		data = append(data, models.GridData{ID: i, MeasurementTime: measured, Latitude: 41, Longitude: float64(i) / 1000,
+			Values: []float32{u, 0.5}, Provenance: models.ProvenanceObserved})
+	}
+
+	tests := []struct {
+		name string
+		data []models.GridData
+	}{
+		{name: "Many rows", data: data},
+		{name: "No rows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{db: &fakeDB{data: tt.data}}
+			ds, _ := datasets.Get("currents")
+			server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status OK; got %v", resp.Status)
+			}
+			var got, want any
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("error decoding response body. Err: %v", err)
+			}
+			expected, _ := json.Marshal(models.GridDataToGeoJSON(ds, tt.data))
+			if err := json.Unmarshal(expected, &want); err != nil {
+				t.Fatalf("error decoding expected body. Err: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected the document of GridDataToGeoJSON; got %v", got)
+			}
+		})
+	}
+}
+
+func TestGetDatasetDataHandlerNetCDF(t *testing.T) {
+	variance := float32(0.25)
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{db: &fakeDB{data: []models.GridData{
+		{MeasurementTime: day, Latitude: 41.125, Longitude: 1.875, Values: []float32{0.5}, Provenance: models.ProvenanceObserved},
+		{MeasurementTime: day, Latitude: 41, Longitude: 2, Values: []float32{0.75},
+			Provenance: models.ProvenanceAreaInterpolated, InterpolationMethod: "ordinary_kriging", InterpolationVariance: &variance},
+		{MeasurementTime: day.Add(24 * time.Hour), Latitude: 41, Longitude: 1.875, Values: []float32{float32(math.NaN())}, Provenance: models.ProvenanceObserved},
+	}}}
+	ds, _ := datasets.Get("chlorophyll")
+	server := httptest.NewServer(s.GetDatasetDataHandler(ds))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?format=netcdf")
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-netcdf" {
+		t.Errorf("expected NetCDF content type; got %q", ct)
+	}
+
+	path := filepath.Join(t.TempDir(), "chlorophyll.nc")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("error creating file. Err: %v", err)
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		t.Fatalf("error reading response body. Err: %v", err)
+	}
+	file.Close()
+
+	nc, err := netcdf.Open(path)
+	if err != nil {
+		t.Fatalf("error opening NetCDF file. Err: %v", err)
+	}
+	defer nc.Close()
+
+	if conventions, _ := nc.Attributes().Get("Conventions"); conventions != "CF-1.8" {
+		t.Errorf("expected CF-1.8 conventions; got %v", conventions)
+	}
+	lat, err := nc.GetVariable("latitude")
+	if err != nil {
+		t.Fatalf("error getting latitude. Err: %v", err)
+	}
+	if want := []float64{41, 41.125}; !reflect.DeepEqual(lat.Values, want) {
+		t.Errorf("expected latitudes %v; got %v", want, lat.Values)
+	}
+
+	chlor, err := nc.GetVariable("chlor_a")
+	if err != nil {
+		t.Fatalf("error getting chlor_a. Err: %v", err)
+	}
+	if want := []string{"time", "latitude", "longitude"}; !reflect.DeepEqual(chlor.Dimensions, want) {
+		t.Errorf("expected dimensions %v; got %v", want, chlor.Dimensions)
+	}
+	if units, _ := chlor.Attributes.Get("units"); units != "mg m-3" {
+		t.Errorf("expected units mg m-3; got %v", units)
+	}
+	fill := models.NetCDFFillValue
+	want := [][][]float32{
+		{{fill, 0.75}, {0.5, fill}},
+		{{fill, fill}, {fill, fill}},
+	}
+	if !reflect.DeepEqual(chlor.Values, want) {
+		t.Errorf("expected values %v; got %v", want, chlor.Values)
+	}
+
+	provenance, err := nc.GetVariable("provenance")
+	if err != nil {
+		t.Fatalf("error getting provenance. Err: %v", err)
+	}
+	wantProvenance := [][][]int8{
+		{{-1, 2}, {0, -1}},
+		{{0, -1}, {-1, -1}},
+	}
+	if !reflect.DeepEqual(provenance.Values, wantProvenance) {
+		t.Errorf("expected provenance %v; got %v", wantProvenance, provenance.Values)
+	}
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/paulmach/orb"
+)
+
+// Media types of the OGC API - Environmental Data Retrieval responses.
+const (
+	mediaTypeJSON         = "application/json"
+	mediaTypeCoverageJSON = "application/prs.coverage+json"
+)
+
+// edrConformance lists the conformance classes of OGC API - EDR the /edr routes implement.
+var edrConformance = []string{
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-common-2/1.0/conf/collections",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/collections",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/json",
+	"http://www.opengis.net/spec/ogcapi-edr-1/1.0/conf/covjson",
+}
+
+// edrQueryTypes are the data queries every collection supports.
+var edrQueryTypes = []string{"position", "area", "cube"}
+
+type edrLink struct {
+	Href      string                 `json:"href"`
+	Rel       string                 `json:"rel"`
+	Type      string                 `json:"type,omitempty"`
+	Title     string                 `json:"title,omitempty"`
+	Variables *edrDataQueryVariables `json:"variables,omitempty"`
+}
+
+type edrDataQueryVariables struct {
+	QueryType           string   `json:"query_type"`
+	OutputFormats       []string `json:"output_formats"`
+	DefaultOutputFormat string   `json:"default_output_format"`
+	CRSDetails          []edrCRS `json:"crs_details"`
+}
+
+type edrCRS struct {
+	CRS string `json:"crs"`
+	WKT string `json:"wkt"`
+}
+
+type edrDataQuery struct {
+	Link edrLink `json:"link"`
+}
+
+type edrExtent struct {
+	Spatial struct {
+		BBox [][]float64 `json:"bbox"`
+		CRS  string      `json:"crs"`
+	} `json:"spatial"`
+	Temporal struct {
+		// open ends are null
+		Interval [][]*string `json:"interval"`
+		TRS      string      `json:"trs"`
+	} `json:"temporal"`
+}
+
+type edrCollection struct {
+	ID             string                          `json:"id"`
+	Title          string                          `json:"title"`
+	Description    string                          `json:"description"`
+	Links          []edrLink                       `json:"links"`
+	Extent         edrExtent                       `json:"extent"`
+	DataQueries    map[string]edrDataQuery         `json:"data_queries"`
+	CRS            []string                        `json:"crs"`
+	OutputFormats  []string                        `json:"output_formats"`
+	ParameterNames map[string]models.CoverageParam `json:"parameter_names"`
+}
+
+// crs84WKT is the WKT of the only CRS the data queries accept.
+const crs84WKT = `GEOGCS["WGS 84",DATUM["WGS_1984",SPHEROID["WGS 84",6378137,298.257223563]],PRIMEM["Greenwich",0],UNIT["degree",0.0174532925199433],AXIS["Longitude",EAST],AXIS["Latitude",NORTH]]`
+
+// baseURL returns the scheme and host the request was made to, links of the EDR
+// responses are absolute as clients follow them from other documents.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// edrCollectionFor describes a dataset as an EDR collection, the temporal extent spans timestamps.
+func edrCollectionFor(base string, ds *datasets.Dataset, timestamps []time.Time) edrCollection {
+	href := base + "/edr/collections/" + ds.Name
+	c := edrCollection{
+		ID:            ds.Name,
+		Title:         ds.Name,
+		Description:   "Daily " + ds.Name + " grid of ERDDAP dataset " + ds.ERDDAPID + ", gaps filled by interpolation",
+		Links:         []edrLink{{Href: href, Rel: "self", Type: mediaTypeJSON}},
+		DataQueries:   make(map[string]edrDataQuery),
+		CRS:           []string{"CRS84"},
+		OutputFormats: []string{"CoverageJSON"},
+	}
+	c.Extent.Spatial.BBox = [][]float64{{defaultMinLon, defaultMinLat, defaultMaxLon, defaultMaxLat}}
+	c.Extent.Spatial.CRS = crs84WKT
+	c.Extent.Temporal.TRS = `TIMECRS["DateTime",TDATUM["Gregorian Calendar"],CS[TemporalDateTime,1],AXIS["Time (T)",future]]`
+	interval := []*string{nil, nil}
+	if len(timestamps) > 0 {
+		first := timestamps[0].UTC().Format(time.RFC3339)
+		last := timestamps[len(timestamps)-1].UTC().Format(time.RFC3339)
+		interval = []*string{&first, &last}
+	}
+	c.Extent.Temporal.Interval = [][]*string{interval}
+
+	for _, queryType := range edrQueryTypes {
+		c.DataQueries[queryType] = edrDataQuery{Link: edrLink{
+			Href:  href + "/" + queryType,
+			Rel:   "data",
+			Title: queryType + " query",
+			Variables: &edrDataQueryVariables{
+				QueryType:           queryType,
+				OutputFormats:       []string{"CoverageJSON"},
+				DefaultOutputFormat: "CoverageJSON",
+				CRSDetails:          []edrCRS{{CRS: "CRS84", WKT: crs84WKT}},
+			},
+		}}
+		c.Links = append(c.Links, edrLink{Href: href + "/" + queryType, Rel: "data", Type: mediaTypeCoverageJSON, Title: queryType + " query"})
+	}
+
+	c.ParameterNames = make(map[string]models.CoverageParam, len(ds.Variables))
+	for _, v := range ds.Variables {
+		c.ParameterNames[v.Column] = models.CoverageParameter(v)
+	}
+	return c
+}
+
+// GetEDRLandingHandler serves the landing page of the EDR interface.
+func (s *Server) GetEDRLandingHandler(w http.ResponseWriter, r *http.Request) {
+	base := baseURL(r) + "/edr"
+	s.respondWithJSON(w, http.StatusOK, map[string]any{
+		"title":       "Ocean Digital Twin",
+		"description": "OGC API - Environmental Data Retrieval of the gridded ocean datasets around OBSEA",
+		"links": []edrLink{
+			{Href: base, Rel: "self", Type: mediaTypeJSON, Title: "This document"},
+			{Href: base + "/conformance", Rel: "conformance", Type: mediaTypeJSON, Title: "Conformance classes"},
+			{Href: base + "/collections", Rel: "data", Type: mediaTypeJSON, Title: "Collections"},
+		},
+	})
+}
+
+// GetEDRConformanceHandler lists the implemented conformance classes.
+func (s *Server) GetEDRConformanceHandler(w http.ResponseWriter, r *http.Request) {
+	s.respondWithJSON(w, http.StatusOK, map[string][]string{"conformsTo": edrConformance})
+}
+
+// GetEDRCollectionsHandler describes every registered dataset as an EDR collection.
+func (s *Server) GetEDRCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	base := baseURL(r)
+	collections := []edrCollection{}
+	for _, ds := range datasets.All() {
+		timestamps, err := s.db.GetAllDatasetTimestamps(r.Context(), ds)
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" timestamps: "+err.Error())
+			return
+		}
+		collections = append(collections, edrCollectionFor(base, ds, timestamps))
+	}
+	s.respondWithJSON(w, http.StatusOK, map[string]any{
+		"links":       []edrLink{{Href: base + "/edr/collections", Rel: "self", Type: mediaTypeJSON}},
+		"collections": collections,
+	})
+}
+
+// GetEDRCollectionHandler describes a single dataset as an EDR collection.
+func (s *Server) GetEDRCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	ds, ok := s.edrDataset(w, r)
+	if !ok {
+		return
+	}
+	timestamps, err := s.db.GetAllDatasetTimestamps(r.Context(), ds)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" timestamps: "+err.Error())
+		return
+	}
+	s.respondWithJSON(w, http.StatusOK, edrCollectionFor(baseURL(r), ds, timestamps))
+}
+
+// edrDataset returns the dataset of the collectionId path parameter, responding with 404 if there is none.
+func (s *Server) edrDataset(w http.ResponseWriter, r *http.Request) (*datasets.Dataset, bool) {
+	ds, ok := datasets.Get(chi.URLParam(r, "collectionId"))
+	if !ok {
+		s.respondWithError(w, http.StatusNotFound, "Unknown collection "+chi.URLParam(r, "collectionId"))
+	}
+	return ds, ok
+}
+
+// edrQuery holds the parameters of the EDR data queries.
+type edrQuery struct {
+	startTime, endTime time.Time
+	columns            []string
+	// location of position queries
+	point orb.Point
+	// area of area queries
+	area orb.MultiPolygon
+	// bounding box of area and cube queries
+	minLat, minLon, maxLat, maxLon float64
+}
+
+// datetime parses an EDR datetime, a single time or an interval of two times separated
+// by a slash where either may be .. for an open end. Open starts default to
+// defaultTimeWindow before the end, open ends and a missing datetime to now.
+func (p *queryParser) datetime(field string, now time.Time) (time.Time, time.Time) {
+	raw := p.values.Get(field)
+	if raw == "" {
+		return now.Add(-defaultTimeWindow), now
+	}
+	start, end, isInterval := strings.Cut(raw, "/")
+	if !isInterval {
+		t, ok := parseTime(raw)
+		if !ok {
+			p.fail(field, "must be an ISO-8601 time or interval, e.g. 2025-01-01T00:00:00Z/2025-01-31T00:00:00Z, got %q", raw)
+		}
+		return t, t
+	}
+	endTime, ok := now, true
+	if end != ".." {
+		endTime, ok = parseTime(end)
+	}
+	startTime, startOk := endTime.Add(-defaultTimeWindow), true
+	if start != ".." {
+		startTime, startOk = parseTime(start)
+	}
+	if !ok || !startOk {
+		p.fail(field, "must be an ISO-8601 time or interval, e.g. 2025-01-01T00:00:00Z/2025-01-31T00:00:00Z, got %q", raw)
+		return startTime, endTime
+	}
+	p.checkTimeRange(field, field, startTime, endTime)
+	return startTime, endTime
+}
+
+// parameterNames returns the dataset columns listed in field, every column if it's not set.
+func (p *queryParser) parameterNames(field string, ds *datasets.Dataset) []string {
+	if p.values.Get(field) == "" {
+		return ds.Columns()
+	}
+	columns := p.list(field)
+	for _, column := range columns {
+		if ds.ColumnIndex(column) < 0 {
+			p.fail(field, "unknown parameter %q, expected one of %s", column, strings.Join(ds.Columns(), ", "))
+		}
+	}
+	return columns
+}
+
+// parseEDRQuery validates the parameters of an EDR data query of queryType.
+func parseEDRQuery(values url.Values, queryType string, ds *datasets.Dataset, now time.Time) (edrQuery, error) {
+	p := newQueryParser(values)
+	var q edrQuery
+	q.startTime, q.endTime = p.datetime("datetime", now)
+	q.columns = p.parameterNames("parameter-name", ds)
+
+	switch queryType {
+	case "position":
+		geom := p.wkt("coords")
+		if geom == nil {
+			break
+		}
+		point, ok := geom.(orb.Point)
+		if !ok {
+			p.fail("coords", "must be a WKT POINT, got %s", geom.GeoJSONType())
+			break
+		}
+		p.checkCoordinates("coords", point.Bound())
+		q.point = point
+	case "area":
+		if geom := p.wkt("coords"); geom != nil {
+			q.area = p.multiPolygon("coords", geom)
+		}
+		if q.area != nil {
+			bound := q.area.Bound()
+			q.minLat, q.minLon, q.maxLat, q.maxLon = bound.Min.Lat(), bound.Min.Lon(), bound.Max.Lat(), bound.Max.Lon()
+		}
+	case "cube":
+		raw := p.values.Get("bbox")
+		if raw == "" {
+			p.fail("bbox", "is required")
+			break
+		}
+		parts := strings.Split(raw, ",")
+		coords := make([]float64, len(parts))
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				parts = nil
+				break
+			}
+			coords[i] = v
+		}
+		if len(parts) != 4 {
+			p.fail("bbox", "must be minimum longitude, minimum latitude, maximum longitude and maximum latitude separated by commas, got %q", raw)
+			break
+		}
+		q.minLon, q.minLat, q.maxLon, q.maxLat = coords[0], coords[1], coords[2], coords[3]
+		if q.minLon >= q.maxLon || q.minLat >= q.maxLat {
+			p.fail("bbox", "minimums must be less than maximums")
+			break
+		}
+		p.checkCoordinates("bbox", orb.Bound{Min: orb.Point{q.minLon, q.minLat}, Max: orb.Point{q.maxLon, q.maxLat}})
+	}
+	return q, p.err()
+}
+
+// GetEDRDataHandler returns the handler of an EDR data query of queryType, responding with CoverageJSON.
+func (s *Server) GetEDRDataHandler(queryType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ds, ok := s.edrDataset(w, r)
+		if !ok {
+			return
+		}
+		q, err := parseEDRQuery(r.URL.Query(), queryType, ds, time.Now().UTC())
+		if err != nil {
+			s.respondWithValidationError(w, err)
+			return
+		}
+
+		var data []models.GridData
+		switch queryType {
+		case "position":
+			data, err = s.db.GetDatasetTimeSeries(r.Context(), ds, q.point, q.startTime, q.endTime, false)
+		default:
+			data, err = s.db.GetDatasetData(r.Context(), ds, q.startTime, q.endTime, q.minLat, q.minLon, q.maxLat, q.maxLon, q.area, false, false)
+		}
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" data: "+err.Error())
+			return
+		}
+		if len(data) == 0 {
+			s.respondWithError(w, http.StatusNotFound, "No "+ds.Name+" data matches the query")
+			return
+		}
+
+		s.respondWithMediaType(w, http.StatusOK, mediaTypeCoverageJSON, models.GridDataToCoverage(ds, q.columns, data))
+	}
+}
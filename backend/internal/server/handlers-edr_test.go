@@ -0,0 +1,255 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+func TestParseEDRQuery(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ds, _ := datasets.Get("currents")
+
+	tests := []struct {
+		name       string
+		queryType  string
+		query      string
+		want       edrQuery
+		wantFields []string
+	}{
+		{
+			name:      "Position with interval",
+			queryType: "position",
+			query:     "coords=POINT(1.75 41.18)&datetime=2025-01-01T00:00:00Z/2025-01-31&parameter-name=u_current",
+			want: edrQuery{
+				startTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				endTime:   time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+				columns:   []string{"u_current"},
+				point:     orb.Point{1.75, 41.18},
+			},
+		},
+		{
+			name:      "Cube with open interval start",
+			queryType: "cube",
+			query:     "bbox=1.5,41,2,41.5&datetime=../2025-01-31",
+			want: edrQuery{
+				startTime: time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC),
+				endTime:   time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+				columns:   []string{"u_current", "v_current"},
+				minLat:    41, minLon: 1.5, maxLat: 41.5, maxLon: 2,
+			},
+		},
+		{
+			name:      "Area sets the bounding box of the polygon",
+			queryType: "area",
+			query:     "coords=POLYGON((1.5 41,2 41,2 41.5,1.5 41))&datetime=2025-01-31",
+			want: edrQuery{
+				startTime: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+				endTime:   time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+				columns:   []string{"u_current", "v_current"},
+				area:      orb.MultiPolygon{{{{1.5, 41}, {2, 41}, {2, 41.5}, {1.5, 41}}}},
+				minLat:    41, minLon: 1.5, maxLat: 41.5, maxLon: 2,
+			},
+		},
+		{
+			name:       "Missing coordinates and unknown parameter",
+			queryType:  "position",
+			query:      "parameter-name=chlor_a",
+			wantFields: []string{"parameter-name", "coords"},
+		},
+		{
+			name:       "Wrong geometry",
+			queryType:  "area",
+			query:      "coords=POINT(1 41)",
+			wantFields: []string{"coords"},
+		},
+		{
+			name:       "Malformed bounding box and interval",
+			queryType:  "cube",
+			query:      "bbox=1,41,2&datetime=2025-01-31/yesterday",
+			wantFields: []string{"datetime", "bbox"},
+		},
+		{
+			name:       "Reversed interval",
+			queryType:  "cube",
+			query:      "bbox=1,41,2,42&datetime=2025-01-31/2025-01-01",
+			wantFields: []string{"datetime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := parseEDRQuery(values, tt.queryType, ds, now)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected query %+v; got %+v", tt.want, got)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestGetEDRDataHandler(t *testing.T) {
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []models.GridData{
+		{MeasurementTime: day, Latitude: 41, Longitude: 1.75, Values: []float32{0.5}},
+		{MeasurementTime: day, Latitude: 41, Longitude: 2, Values: []float32{0.25}},
+		{MeasurementTime: day, Latitude: 41.25, Longitude: 2, Values: []float32{0.75}},
+		{MeasurementTime: day.Add(24 * time.Hour), Latitude: 41, Longitude: 1.75, Values: []float32{float32(0.1)}},
+	}
+	series := []models.GridData{
+		{MeasurementTime: day, Latitude: 41.25, Longitude: 1.75, Values: []float32{0.5}},
+		{MeasurementTime: day.Add(24 * time.Hour), Latitude: 41.25, Longitude: 1.75, Values: []float32{0.6}},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		data           []models.GridData
+		wantStatus     int
+		wantDomainType string
+		wantShape      []int
+		wantValues     []any
+	}{
+		{
+			name:           "Cube",
+			path:           "/edr/collections/chlorophyll/cube?bbox=1.5,40.5,2.5,41.5",
+			data:           data,
+			wantStatus:     http.StatusOK,
+			wantDomainType: models.DomainGrid,
+			wantShape:      []int{2, 2, 2},
+		},
+		{
+			name:           "Area drops cells outside of the polygon",
+			path:           "/edr/collections/chlorophyll/area?coords=" + url.QueryEscape("POLYGON((1.9 40.9,2.1 40.9,2.1 41.1,1.9 41.1,1.9 40.9))"),
+			data:           data,
+			wantStatus:     http.StatusOK,
+			wantDomainType: models.DomainPointSeries,
+			wantShape:      []int{1},
+			wantValues:     []any{0.25},
+		},
+		{
+			name:           "Position",
+			path:           "/edr/collections/chlorophyll/position?coords=" + url.QueryEscape("POINT(1.8 41.2)"),
+			data:           series,
+			wantStatus:     http.StatusOK,
+			wantDomainType: models.DomainPointSeries,
+			wantShape:      []int{2},
+			wantValues:     []any{0.5, 0.6},
+		},
+		{name: "No data", path: "/edr/collections/chlorophyll/cube?bbox=1.5,40.5,2.5,41.5", wantStatus: http.StatusNotFound},
+		{name: "Unknown collection", path: "/edr/collections/plankton/cube?bbox=1.5,40.5,2.5,41.5", wantStatus: http.StatusNotFound},
+		{name: "Invalid query", path: "/edr/collections/chlorophyll/position", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{db: &fakeDB{data: tt.data}}
+			server := httptest.NewServer(s.RegisterRoutes())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d; got %v", tt.wantStatus, resp.Status)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != mediaTypeCoverageJSON {
+				t.Errorf("expected CoverageJSON content type; got %q", ct)
+			}
+			var cov struct {
+				Domain struct {
+					DomainType string `json:"domainType"`
+				} `json:"domain"`
+				Ranges map[string]struct {
+					Shape  []int `json:"shape"`
+					Values []any `json:"values"`
+				} `json:"ranges"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&cov); err != nil {
+				t.Fatalf("error decoding response body. Err: %v", err)
+			}
+			if cov.Domain.DomainType != tt.wantDomainType {
+				t.Errorf("expected %s domain; got %s", tt.wantDomainType, cov.Domain.DomainType)
+			}
+			chlor, ok := cov.Ranges["chlor_a"]
+			if !ok {
+				t.Fatalf("expected a chlor_a range; got %v", cov.Ranges)
+			}
+			if !reflect.DeepEqual(chlor.Shape, tt.wantShape) {
+				t.Errorf("expected shape %v; got %v", tt.wantShape, chlor.Shape)
+			}
+			if tt.wantValues != nil && !reflect.DeepEqual(chlor.Values, tt.wantValues) {
+				t.Errorf("expected values %v; got %v", tt.wantValues, chlor.Values)
+			}
+		})
+	}
+}
+
+func TestGetEDRCollectionsHandler(t *testing.T) {
+	latest := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	s := &Server{db: &fakeDB{latest: latest}}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/edr/collections")
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Collections []edrCollection `json:"collections"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	if len(body.Collections) != len(datasets.All()) {
+		t.Fatalf("expected a collection per dataset; got %d", len(body.Collections))
+	}
+	currents := body.Collections[1]
+	if currents.ID != "currents" {
+		t.Fatalf("expected the currents collection; got %s", currents.ID)
+	}
+	if _, ok := currents.ParameterNames["u_current"]; !ok {
+		t.Errorf("expected the u_current parameter; got %v", currents.ParameterNames)
+	}
+	if got := currents.DataQueries["cube"].Link.Href; got != server.URL+"/edr/collections/currents/cube" {
+		t.Errorf("expected absolute link to the cube query; got %s", got)
+	}
+	if interval := currents.Extent.Temporal.Interval[0]; *interval[0] != "2025-01-31T00:00:00Z" {
+		t.Errorf("expected the temporal extent to start at the first timestamp; got %v", *interval[0])
+	}
+}
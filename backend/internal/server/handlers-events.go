@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+// bloomEventsQuery holds the parameters of the bloom events endpoint.
+type bloomEventsQuery struct {
+	datasetQuery
+	rule string
+}
+
+// parseBloomEventsQuery validates the query parameters of the bloom events endpoint. Events
+// are rare, so without start_time the longest allowed time range is served.
+func parseBloomEventsQuery(values url.Values, body []byte, now time.Time) (bloomEventsQuery, error) {
+	p := newQueryParser(values)
+	var q bloomEventsQuery
+	q.startTime, q.endTime = p.timeRangeWithDefault(now, maxTimeWindow)
+	q.minLat, q.minLon, q.maxLat, q.maxLon = p.boundingBox()
+	p.area(&q.datasetQuery, body)
+	q.rule = strings.TrimSpace(values.Get("rule"))
+	return q, p.err()
+}
+
+// GetBloomEventsHandler serves the detected algal blooms intersecting the requested area as
+// a GeoJSON FeatureCollection of their outlines, newest first. POST requests restrict the
+// events to the GeoJSON polygon of their body.
+func (s *Server) GetBloomEventsHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readAreaBody(w, r)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Error reading request body: "+err.Error())
+		return
+	}
+	q, err := parseBloomEventsQuery(r.URL.Query(), body, time.Now().UTC())
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	if !s.loadRegion(w, r, &q.datasetQuery) {
+		return
+	}
+
+	events, err := s.db.GetBloomEvents(r.Context(), q.startTime, q.endTime, q.minLat, q.minLon, q.maxLat, q.maxLon, q.area, q.rule)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving bloom events: "+err.Error())
+		return
+	}
+	s.respondWithMediaType(w, http.StatusOK, "application/geo+json", models.BloomEventsToGeoJSON(events))
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/paulmach/orb"
+)
+
+func TestGetBloomEventsHandler(t *testing.T) {
+	measured := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+	z := 3.5
+	db := &fakeDB{blooms: []models.BloomEvent{
+		{
+			ID: 2, MeasurementTime: measured, Rule: "chlorophyll_anomaly",
+			Geometry: orb.MultiPolygon{orb.Bound{Min: orb.Point{2.1, 41.2}, Max: orb.Point{2.2, 41.3}}.ToPolygon()},
+			AreaKm2:  92.5, Cells: 100, MeanChlorA: 2.1, MaxChlorA: 4.2, MaxZScore: &z, DetectedAt: measured.Add(26 * time.Hour),
+		},
+		{
+			ID: 1, MeasurementTime: measured, Rule: "high_chlorophyll",
+			Geometry: orb.MultiPolygon{orb.Bound{Min: orb.Point{1.5, 40.8}, Max: orb.Point{1.6, 40.9}}.ToPolygon()},
+			AreaKm2:  23.1, Cells: 25, MeanChlorA: 3.4, MaxChlorA: 5, DetectedAt: measured.Add(26 * time.Hour),
+		},
+	}}
+	s := &Server{db: db}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	get := func(t *testing.T, method, path, body string) []map[string]any {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/geo+json" {
+			t.Errorf("expected GeoJSON content type; got %q", ct)
+		}
+		var fc struct {
+			Features []struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		var props []map[string]any
+		for _, f := range fc.Features {
+			props = append(props, f.Properties)
+		}
+		return props
+	}
+
+	t.Run("All events", func(t *testing.T) {
+		props := get(t, http.MethodGet, "/events/blooms?start_time=2025-05-01&end_time=2025-05-31", "")
+		if len(props) != 2 {
+			t.Fatalf("expected 2 events; got %d", len(props))
+		}
+		want := map[string]any{
+			"id": 2.0, "measurement_time": "2025-05-02T00:00:00Z", "rule": "chlorophyll_anomaly", "area_km2": 92.5, "cells": 100.0,
+			"mean_chlor_a": 2.1, "max_chlor_a": 4.2, "max_z_score": 3.5, "detected_at": "2025-05-03T02:00:00Z",
+		}
+		if !reflect.DeepEqual(props[0], want) {
+			t.Errorf("expected properties %v; got %v", want, props[0])
+		}
+		if props[1]["max_z_score"] != nil {
+			t.Errorf("expected no z-score without a climatology; got %v", props[1]["max_z_score"])
+		}
+		if !db.startTime.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the requested time range; got %v", db.startTime)
+		}
+	})
+
+	t.Run("By rule", func(t *testing.T) {
+		props := get(t, http.MethodGet, "/events/blooms?rule=high_chlorophyll", "")
+		if len(props) != 1 || props[0]["id"] != 1.0 {
+			t.Errorf("expected the high chlorophyll event; got %v", props)
+		}
+	})
+
+	t.Run("Within a polygon", func(t *testing.T) {
+		body := `{"type":"Polygon","coordinates":[[[2,41.1],[2.3,41.1],[2.3,41.4],[2,41.4],[2,41.1]]]}`
+		props := get(t, http.MethodPost, "/events/blooms", body)
+		if len(props) != 1 || props[0]["id"] != 2.0 {
+			t.Errorf("expected the event within the polygon; got %v", props)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/url"
+
+	"ocean-digital-twin/internal/datasets"
+	"ocean-digital-twin/internal/raster"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// rasterMargin is added in degrees around the bound of a raster tile, so pixels on its
+// edges find the grid cell they fall into. It must exceed half of any dataset cell.
+const rasterMargin = 0.25
+
+// rasterQuery holds the parameters of the raster tile endpoint.
+type rasterQuery struct {
+	tileQuery
+	palette *raster.Palette
+}
+
+// parseRasterQuery validates the tile coordinates of the path and the query parameters of the raster tile endpoint.
+func parseRasterQuery(values url.Values, z, x, y string, layer *raster.Layer) (rasterQuery, error) {
+	p := newTileParser(values, z, x, y)
+	q := rasterQuery{tileQuery: p.tileQuery()}
+	q.palette, _ = raster.GetPalette(p.oneOf("palette", layer.Palette, raster.PaletteNames()...))
+	return q, p.err()
+}
+
+// legend describes the colours a raster layer is drawn with. ColorStops has the
+// [value, colour] format of the colorStops of the frontend ColorScaleLegend.vue.
+type legend struct {
+	Layer      string  `json:"layer"`
+	Title      string  `json:"title"`
+	Unit       string  `json:"unit"`
+	Palette    string  `json:"palette"`
+	Scale      string  `json:"scale"`
+	ColorStops [][]any `json:"color_stops"`
+}
+
+// GetRasterTileHandler serves the data of a single day of a raster layer as PNG tiles.
+func (s *Server) GetRasterTileHandler(w http.ResponseWriter, r *http.Request) {
+	layer, ok := raster.GetLayer(chi.URLParam(r, "layer"))
+	if !ok {
+		s.respondWithError(w, http.StatusNotFound, "Unknown raster layer "+chi.URLParam(r, "layer"))
+		return
+	}
+	ds, ok := datasets.Get(layer.Dataset)
+	if !ok {
+		s.respondWithError(w, http.StatusInternalServerError, "Dataset "+layer.Dataset+" of raster layer "+layer.Name+" is not registered")
+		return
+	}
+	q, err := parseRasterQuery(r.URL.Query(), chi.URLParam(r, "z"), chi.URLParam(r, "x"), chi.URLParam(r, "y"), layer)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	data, err := s.getTileData(r.Context(), ds, q.tileQuery, rasterMargin)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" data: "+err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, layer.Render(ds, data, q.palette, q.tile)); err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error encoding "+layer.Name+" tile: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// GetRasterLegendHandler describes the palette the tiles of a raster layer are drawn with.
+func (s *Server) GetRasterLegendHandler(w http.ResponseWriter, r *http.Request) {
+	layer, ok := raster.GetLayer(chi.URLParam(r, "layer"))
+	if !ok {
+		s.respondWithError(w, http.StatusNotFound, "Unknown raster layer "+chi.URLParam(r, "layer"))
+		return
+	}
+	p := newQueryParser(r.URL.Query())
+	palette, _ := raster.GetPalette(p.oneOf("palette", layer.Palette, raster.PaletteNames()...))
+	if err := p.err(); err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	resp := legend{
+		Layer:   layer.Name,
+		Title:   layer.Title,
+		Unit:    layer.Unit,
+		Palette: palette.Name,
+		Scale:   string(palette.Scale),
+	}
+	for _, stop := range palette.Stops {
+		resp.ColorStops = append(resp.ColorStops, []any{stop.Value, stop.Color})
+	}
+	s.respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestGetRasterTileHandler(t *testing.T) {
+	latest := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	data := []models.GridData{
+		{MeasurementTime: latest, Latitude: 41, Longitude: 1.75, Values: []float32{0.5}},
+		{MeasurementTime: latest, Latitude: 41, Longitude: 2, Values: []float32{2}},
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Tile", path: "/raster/chlorophyll/8/129/95.png", wantStatus: http.StatusOK},
+		{name: "Tile with palette", path: "/raster/chlorophyll/8/129/95.png?palette=chlorophyll_linear", wantStatus: http.StatusOK},
+		{name: "Unknown palette", path: "/raster/chlorophyll/8/129/95.png?palette=rainbow", wantStatus: http.StatusBadRequest},
+		{name: "Unknown layer", path: "/raster/plankton/8/129/95.png", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{db: &fakeDB{data: data, latest: latest}}
+			server := httptest.NewServer(s.RegisterRoutes())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d; got %v", tt.wantStatus, resp.Status)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "image/png" {
+				t.Errorf("expected PNG content type; got %q", ct)
+			}
+			img, err := png.Decode(resp.Body)
+			if err != nil {
+				t.Fatalf("error decoding tile. Err: %v", err)
+			}
+			if size := img.Bounds().Size(); size.X != 256 || size.Y != 256 {
+				t.Errorf("expected a 256x256 tile; got %v", size)
+			}
+		})
+	}
+}
+
+func TestGetRasterLegendHandler(t *testing.T) {
+	s := &Server{}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/raster/currents/legend")
+	if err != nil {
+		t.Fatalf("error making request to server. Err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", resp.Status)
+	}
+	var got legend
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	want := legend{
+		Layer: "currents", Title: "Current Speed", Unit: "m/s", Palette: "currents", Scale: "linear",
+		ColorStops: [][]any{
+			{0.0, "#ffffbb"}, {0.005, "#c9efdc"}, {0.02, "#41b6c4"}, {0.05, "#418ac4"}, {0.14, "#3136bb"}, {0.5, "#1e0755"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected legend %+v; got %+v", want, got)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/paulmach/orb"
+)
+
+// maxRegionNameLength limits the names of saved regions.
+const maxRegionNameLength = 200
+
+// regionRequest holds a region to be saved.
+type regionRequest struct {
+	name string
+	area orb.MultiPolygon
+}
+
+// parseRegionRequest validates a region given as GeoJSON body, named by the name field or
+// else by the name property of a feature body.
+func parseRegionRequest(values url.Values, body []byte) (regionRequest, error) {
+	p := newQueryParser(values)
+	var req regionRequest
+	if len(body) == 0 {
+		p.fail("body", "is required")
+	} else if geom, err := geojsonArea(body); err != nil {
+		p.fail("body", "%s", err)
+	} else {
+		req.area = p.multiPolygon("body", geom)
+	}
+
+	req.name = strings.TrimSpace(values.Get("name"))
+	if req.name == "" {
+		var feature struct {
+			Properties struct {
+				Name string `json:"name"`
+			} `json:"properties"`
+		}
+		// the body was validated above
+		_ = json.Unmarshal(body, &feature)
+		req.name = strings.TrimSpace(feature.Properties.Name)
+	}
+	if req.name == "" {
+		p.fail("name", "is required, as parameter or as name property of a feature")
+	} else if len(req.name) > maxRegionNameLength {
+		p.fail("name", "must be at most %d characters long", maxRegionNameLength)
+	}
+	return req, p.err()
+}
+
+// GetRegionsHandler lists the saved regions as a GeoJSON FeatureCollection.
+func (s *Server) GetRegionsHandler(w http.ResponseWriter, r *http.Request) {
+	regions, err := s.db.GetRegions(r.Context())
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving regions: "+err.Error())
+		return
+	}
+	s.respondWithMediaType(w, http.StatusOK, "application/geo+json", models.RegionsToGeoJSON(regions))
+}
+
+// CreateRegionHandler saves the GeoJSON polygon of the body as a named region.
+func (s *Server) CreateRegionHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readAreaBody(w, r)
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Error reading request body: "+err.Error())
+		return
+	}
+	req, err := parseRegionRequest(r.URL.Query(), body)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	region, err := s.db.SaveRegion(r.Context(), req.name, req.area)
+	if errors.Is(err, database.ErrRegionExists) {
+		s.respondWithError(w, http.StatusConflict, "Region "+req.name+" already exists")
+		return
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error saving region: "+err.Error())
+		return
+	}
+	w.Header().Set("Location", "/regions/"+strconv.Itoa(region.ID))
+	s.respondWithMediaType(w, http.StatusCreated, "application/geo+json", models.RegionToGeoJSON(region))
+}
+
+// GetRegionHandler serves a saved region as a GeoJSON Feature.
+func (s *Server) GetRegionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.regionID(w, r)
+	if !ok {
+		return
+	}
+	region, err := s.db.GetRegion(r.Context(), id)
+	if errors.Is(err, database.ErrRegionNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Unknown region "+strconv.Itoa(id))
+		return
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving region: "+err.Error())
+		return
+	}
+	s.respondWithMediaType(w, http.StatusOK, "application/geo+json", models.RegionToGeoJSON(region))
+}
+
+// DeleteRegionHandler removes a saved region.
+func (s *Server) DeleteRegionHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.regionID(w, r)
+	if !ok {
+		return
+	}
+	err := s.db.DeleteRegion(r.Context(), id)
+	if errors.Is(err, database.ErrRegionNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Unknown region "+strconv.Itoa(id))
+		return
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error deleting region: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// regionID returns the id path parameter, responding with 404 if it isn't a region ID.
+func (s *Server) regionID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		s.respondWithError(w, http.StatusNotFound, "Unknown region "+chi.URLParam(r, "id"))
+		return 0, false
+	}
+	return id, true
+}
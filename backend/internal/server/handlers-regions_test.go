@@ -0,0 +1,137 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/paulmach/orb"
+)
+
+// reserve covers the first of the grid cells of regionTestData.
+const reserve = `{"type":"Feature","properties":{"name":"Cap de Creus"},"geometry":{"type":"Polygon","coordinates":[[[1.8,41],[2,41],[2,41.2],[1.8,41.2],[1.8,41]]]}}`
+
+var regionTestData = []models.GridData{
+	{MeasurementTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Latitude: 41.1, Longitude: 1.9, Values: []float32{0.5}, Provenance: models.ProvenanceObserved},
+	{MeasurementTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Latitude: 41.3, Longitude: 2.1, Values: []float32{0.7}, Provenance: models.ProvenanceObserved},
+}
+
+func TestRegionHandlers(t *testing.T) {
+	db := &fakeDB{data: regionTestData}
+	s := &Server{db: db}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/regions", reserve)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status Created; got %v", resp.Status)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/regions/1" {
+		t.Errorf("expected location /regions/1; got %q", loc)
+	}
+	if resp := do(http.MethodPost, "/regions", reserve); resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status Conflict for a taken name; got %v", resp.Status)
+	}
+	// the name parameter names geometries without properties
+	if resp := do(http.MethodPost, "/regions?name=Medes", `{"type":"Polygon","coordinates":[[[3,42],[3.1,42],[3.1,42.1],[3,42]]]}`); resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected status Created; got %v", resp.Status)
+	}
+	if resp := do(http.MethodPost, "/regions", `{"type":"Polygon","coordinates":[[[3,42],[3.1,42],[3.1,42.1],[3,42]]]}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request without a name; got %v", resp.Status)
+	}
+
+	resp = do(http.MethodGet, "/regions/1", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", resp.Status)
+	}
+	var feature struct {
+		Properties map[string]any `json:"properties"`
+		Geometry   struct {
+			Type string `json:"type"`
+		} `json:"geometry"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&feature); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	if feature.Properties["name"] != "Cap de Creus" || feature.Geometry.Type != "MultiPolygon" {
+		t.Errorf("unexpected region %+v", feature)
+	}
+
+	resp = do(http.MethodGet, "/chlorophyll/?region=1", "")
+	var fc struct {
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+		t.Fatalf("error decoding response body. Err: %v", err)
+	}
+	if len(fc.Features) != 1 {
+		t.Errorf("expected the single cell of the region; got %d", len(fc.Features))
+	}
+	if want := (orb.Bound{Min: orb.Point{1.8, 41}, Max: orb.Point{2, 41.2}}); db.area.Bound() != want {
+		t.Errorf("expected the area of the region; got %v", db.area.Bound())
+	}
+
+	if resp := do(http.MethodDelete, "/regions/1", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status No Content; got %v", resp.Status)
+	}
+	for _, path := range []string{"/regions/1", "/regions/first", "/chlorophyll/?region=1"} {
+		if resp := do(http.MethodGet, path, ""); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status Not Found for %s; got %v", path, resp.Status)
+		}
+	}
+}
+
+func TestGetDatasetDataHandlerArea(t *testing.T) {
+	polygon := `{"type":"Polygon","coordinates":[[[1.8,41],[2,41],[2,41.2],[1.8,41.2],[1.8,41]]]}`
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "WKT parameter", method: http.MethodGet, path: "/chlorophyll/?wkt=" + url.QueryEscape("POLYGON((1.8 41, 2 41, 2 41.2, 1.8 41.2, 1.8 41))")},
+		{name: "GeoJSON body", method: http.MethodPost, path: "/chlorophyll/?format=csv", body: polygon},
+		{name: "GeoJSON body of aggregates", method: http.MethodPost, path: "/chlorophyll/aggregate", body: polygon},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{data: regionTestData}
+			s := &Server{db: db}
+			server := httptest.NewServer(s.RegisterRoutes())
+			defer server.Close()
+
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status OK; got %v", resp.Status)
+			}
+			want := orb.MultiPolygon{{{{1.8, 41}, {2, 41}, {2, 41.2}, {1.8, 41.2}, {1.8, 41}}}}
+			if !reflect.DeepEqual(db.area, want) {
+				t.Errorf("expected area %v; got %v", want, db.area)
+			}
+		})
+	}
+}
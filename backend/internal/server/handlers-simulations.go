@@ -0,0 +1,389 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/datasets"
+	"ocean-digital-twin/internal/drift"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// Limits of the drift simulations, keeping a single request within a few seconds of work.
+const (
+	maxDriftReleases  = 100
+	maxDriftParticles = 1000
+	maxDriftDuration  = 7 * 24 * time.Hour
+	minDriftStep      = time.Minute
+	maxDriftStep      = 6 * time.Hour
+	defaultDriftStep  = 30 * time.Minute
+	// maxDriftDiffusivity in m²/s is well above the eddy diffusivities of coastal seas
+	maxDriftDiffusivity = 1000
+	// backward simulations release many particles over the sighting, spread by diffusion
+	defaultBacktrackParticles   = 500
+	defaultBacktrackDiffusivity = 10
+	defaultBacktrackSamples     = 20
+	// cell sizes in degrees of the origin density
+	defaultBacktrackCellSize = 0.05
+	minBacktrackCellSize     = 0.01
+	maxBacktrackCellSize     = 1.0
+	// driftDataMargin of currents is loaded around the simulated time, so the daily
+	// snapshots before the release and after the end are interpolated between
+	driftDataMargin = 24 * time.Hour
+)
+
+// driftOptionsBody holds the fields shared by the bodies of every simulation, optional fields are pointers.
+type driftOptionsBody struct {
+	DurationHours   *float64 `json:"duration_hours"`
+	TimeStepMinutes *float64 `json:"time_step_minutes"`
+	Diffusivity     *float64 `json:"diffusivity"`
+	Particles       *int     `json:"particles"`
+	Seed            int64    `json:"seed"`
+}
+
+// driftRequestBody is the JSON body of a drift simulation.
+type driftRequestBody struct {
+	ReleasePoints []struct {
+		Lat *float64 `json:"lat"`
+		Lon *float64 `json:"lon"`
+	} `json:"release_points"`
+	ReleaseTime string `json:"release_time"`
+	driftOptionsBody
+}
+
+// driftRequest holds a validated drift simulation, a zero releaseTime releases at the latest currents.
+type driftRequest struct {
+	points      []orb.Point
+	releaseTime time.Time
+	opts        drift.Options
+}
+
+// decodeSimulationBody decodes the JSON body of a simulation into v, rejecting unknown fields.
+func (p *queryParser) decodeSimulationBody(body []byte, v any) bool {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		p.fail("body", "must be a JSON object of a simulation: %s", err)
+		return false
+	}
+	return true
+}
+
+// bodyTime returns raw parsed as an ISO-8601 time, or the zero time if it's empty.
+func (p *queryParser) bodyTime(field, raw string) time.Time {
+	if raw == "" {
+		return time.Time{}
+	}
+	t, ok := parseTime(raw)
+	if !ok {
+		p.fail(field, "must be an ISO-8601 time, e.g. 2025-01-31T00:00:00Z, got %q", raw)
+	}
+	return t
+}
+
+// driftOptions validates the options shared by every simulation, releasing particles at
+// each of releases points.
+func (p *queryParser) driftOptions(b driftOptionsBody, releases, defaultParticles int, defaultDiffusivity float64) drift.Options {
+	var opts drift.Options
+	if b.DurationHours == nil {
+		p.fail("duration_hours", "is required")
+	} else if *b.DurationHours <= 0 || *b.DurationHours > maxDriftDuration.Hours() {
+		p.fail("duration_hours", "must be greater than 0 and at most %g", maxDriftDuration.Hours())
+	} else {
+		opts.Duration = time.Duration(*b.DurationHours * float64(time.Hour))
+	}
+
+	opts.Step = defaultDriftStep
+	if b.TimeStepMinutes != nil {
+		step := time.Duration(*b.TimeStepMinutes * float64(time.Minute))
+		if step < minDriftStep || step > maxDriftStep {
+			p.fail("time_step_minutes", "must be between %g and %g", minDriftStep.Minutes(), maxDriftStep.Minutes())
+		}
+		opts.Step = step
+	}
+
+	opts.Diffusivity = defaultDiffusivity
+	if b.Diffusivity != nil {
+		if *b.Diffusivity < 0 || *b.Diffusivity > maxDriftDiffusivity {
+			p.fail("diffusivity", "must be between 0 and %d", maxDriftDiffusivity)
+		}
+		opts.Diffusivity = *b.Diffusivity
+	}
+
+	opts.Particles = defaultParticles
+	if b.Particles != nil {
+		if *b.Particles < 1 || *b.Particles > maxDriftParticles {
+			p.fail("particles", "must be between 1 and %d", maxDriftParticles)
+		} else if total := releases * *b.Particles; total > maxDriftParticles {
+			p.fail("particles", "must be at most %d in total, got %d", maxDriftParticles, total)
+		}
+		opts.Particles = *b.Particles
+	}
+	opts.Seed = b.Seed
+	return opts
+}
+
+// parseDriftRequest validates the JSON body of a drift simulation.
+func parseDriftRequest(body []byte) (driftRequest, error) {
+	p := newQueryParser(nil)
+	var req driftRequest
+	var b driftRequestBody
+	if !p.decodeSimulationBody(body, &b) {
+		return req, p.err()
+	}
+
+	if len(b.ReleasePoints) == 0 {
+		p.fail("release_points", "is required")
+	} else if len(b.ReleasePoints) > maxDriftReleases {
+		p.fail("release_points", "must hold at most %d points", maxDriftReleases)
+	}
+	for i, rp := range b.ReleasePoints {
+		field := fmt.Sprintf("release_points[%d]", i)
+		switch {
+		case rp.Lat == nil || rp.Lon == nil:
+			p.fail(field, "lat and lon are required")
+		case *rp.Lat < -90 || *rp.Lat > 90 || *rp.Lon < -180 || *rp.Lon > 180:
+			p.fail(field, "lon must be between -180 and 180 and lat between -90 and 90")
+		default:
+			req.points = append(req.points, orb.Point{*rp.Lon, *rp.Lat})
+		}
+	}
+	req.releaseTime = p.bodyTime("release_time", b.ReleaseTime)
+	req.opts = p.driftOptions(b.driftOptionsBody, len(b.ReleasePoints), 1, 0)
+	return req, p.err()
+}
+
+// backtrackRequestBody is the JSON body of a backward simulation.
+type backtrackRequestBody struct {
+	Area         json.RawMessage `json:"area"`
+	Region       *int            `json:"region"`
+	SightingTime string          `json:"sighting_time"`
+	Samples      *int            `json:"samples"`
+	CellSize     *float64        `json:"cell_size"`
+	driftOptionsBody
+}
+
+// backtrackRequest holds a validated backward simulation from a sighting within area, or
+// within the saved region regionID. A zero sightingTime is the time of the latest currents.
+type backtrackRequest struct {
+	area         orb.MultiPolygon
+	regionID     int
+	sightingTime time.Time
+	samples      int
+	cellSize     float64
+	opts         drift.Options
+}
+
+// parseBacktrackRequest validates the JSON body of a backward simulation.
+func parseBacktrackRequest(body []byte) (backtrackRequest, error) {
+	p := newQueryParser(nil)
+	var req backtrackRequest
+	var b backtrackRequestBody
+	if !p.decodeSimulationBody(body, &b) {
+		return req, p.err()
+	}
+
+	switch {
+	case len(b.Area) > 0 && b.Region != nil:
+		p.fail("area", "must not be combined with region")
+	case len(b.Area) > 0:
+		if geom, err := geojsonArea(b.Area); err != nil {
+			p.fail("area", "%s", err)
+		} else if req.area = p.multiPolygon("area", geom); req.area != nil && planar.Area(req.area) == 0 {
+			p.fail("area", "must enclose an area")
+		}
+	case b.Region != nil:
+		if *b.Region < 1 {
+			p.fail("region", "must be the ID of a saved region")
+		}
+		req.regionID = *b.Region
+	default:
+		p.fail("area", "is required, or else region")
+	}
+
+	req.sightingTime = p.bodyTime("sighting_time", b.SightingTime)
+	req.opts = p.driftOptions(b.driftOptionsBody, 1, defaultBacktrackParticles, defaultBacktrackDiffusivity)
+	req.opts.Backward = true
+
+	req.samples = min(defaultBacktrackSamples, req.opts.Particles)
+	if b.Samples != nil {
+		if *b.Samples < 0 || *b.Samples > req.opts.Particles {
+			p.fail("samples", "must be between 0 and the number of particles")
+		}
+		req.samples = *b.Samples
+	}
+	req.cellSize = defaultBacktrackCellSize
+	if b.CellSize != nil {
+		if *b.CellSize < minBacktrackCellSize || *b.CellSize > maxBacktrackCellSize {
+			p.fail("cell_size", "must be between %g and %g", minBacktrackCellSize, maxBacktrackCellSize)
+		}
+		req.cellSize = *b.CellSize
+	}
+	return req, p.err()
+}
+
+// simulationTime returns t, or the time of the latest currents if t is zero.
+func (s *Server) simulationTime(ctx context.Context, ds *datasets.Dataset, t time.Time) (time.Time, error) {
+	if !t.IsZero() {
+		return t, nil
+	}
+	latest, err := s.db.GetLatestDatasetTimestamp(ctx, ds)
+	if err != nil {
+		return t, fmt.Errorf("error retrieving latest currents timestamp: %w", err)
+	}
+	return latest.UTC(), nil
+}
+
+// currentsDataset returns the dataset the simulations drift with, responding with 500 if it isn't registered.
+func (s *Server) currentsDataset(w http.ResponseWriter) (*datasets.Dataset, bool) {
+	ds, ok := datasets.Get("currents")
+	if !ok {
+		s.respondWithError(w, http.StatusInternalServerError, "currents dataset not registered")
+	}
+	return ds, ok
+}
+
+// currentField loads the currents of ds between start and end, responding with 404 if there are none.
+func (s *Server) currentField(w http.ResponseWriter, r *http.Request, ds *datasets.Dataset, start, end time.Time) (*drift.Field, bool) {
+	data, err := s.db.GetDatasetData(r.Context(), ds, start.Add(-driftDataMargin), end.Add(driftDataMargin),
+		-90, -180, 90, 180, nil, false, false)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving currents: "+err.Error())
+		return nil, false
+	}
+	field, err := drift.NewField(ds, data)
+	if errors.Is(err, drift.ErrNoCurrents) {
+		s.respondWithError(w, http.StatusNotFound, "No currents within a day of the simulated time")
+		return nil, false
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error building the current field: "+err.Error())
+		return nil, false
+	}
+	return field, true
+}
+
+// PostDriftSimulationHandler releases virtual particles at the points of the request and
+// advects them through the stored surface currents, responding with their trajectories
+// as GeoJSON LineStrings.
+func (s *Server) PostDriftSimulationHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readJSONBody(w, r)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	req, err := parseDriftRequest(body)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	ds, ok := s.currentsDataset(w)
+	if !ok {
+		return
+	}
+	req.releaseTime, err = s.simulationTime(r.Context(), ds, req.releaseTime)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	field, ok := s.currentField(w, r, ds, req.releaseTime, req.releaseTime.Add(req.opts.Duration))
+	if !ok {
+		return
+	}
+
+	releases := make([]drift.Release, len(req.points))
+	for i, point := range req.points {
+		releases[i] = drift.Release{Point: point, Time: req.releaseTime}
+	}
+	trajectories := drift.Simulate(field, releases, req.opts)
+	s.respondWithMediaType(w, http.StatusOK, "application/geo+json", drift.ToGeoJSON(trajectories))
+}
+
+// backtrackResponse holds the probable origins of a sighting and a sample of the trajectories leading to it.
+type backtrackResponse struct {
+	SightingTime time.Time `json:"sighting_time"`
+	OriginTime   time.Time `json:"origin_time"`
+	Particles    int       `json:"particles"`
+	Stranded     int       `json:"stranded"`
+	Outside      int       `json:"outside"`
+	// Density holds the cells the particles came from, the most probable first
+	Density      *geojson.FeatureCollection `json:"density"`
+	Trajectories *geojson.FeatureCollection `json:"trajectories"`
+}
+
+// PostBacktrackSimulationHandler releases particles over the area of a sighting and traces
+// them back in time through the stored surface currents, responding with the density of
+// their origins and a sample of their trajectories.
+func (s *Server) PostBacktrackSimulationHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readJSONBody(w, r)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	req, err := parseBacktrackRequest(body)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	if req.regionID != 0 {
+		region, err := s.db.GetRegion(r.Context(), req.regionID)
+		if errors.Is(err, database.ErrRegionNotFound) {
+			s.respondWithError(w, http.StatusNotFound, "Unknown region "+strconv.Itoa(req.regionID))
+			return
+		}
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, "Error retrieving region: "+err.Error())
+			return
+		}
+		req.area = region.Geometry
+	}
+
+	ds, ok := s.currentsDataset(w)
+	if !ok {
+		return
+	}
+	req.sightingTime, err = s.simulationTime(r.Context(), ds, req.sightingTime)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	originTime := req.sightingTime.Add(-req.opts.Duration)
+	field, ok := s.currentField(w, r, ds, originTime, req.sightingTime)
+	if !ok {
+		return
+	}
+
+	// every particle is released at its own point of the area
+	releases := drift.ReleasesWithin(req.area, req.sightingTime, req.opts.Particles, req.opts.Seed)
+	opts := req.opts
+	opts.Particles = 1
+	trajectories := drift.Simulate(field, releases, opts)
+
+	resp := backtrackResponse{
+		SightingTime: req.sightingTime,
+		OriginTime:   originTime,
+		Particles:    len(trajectories),
+		Density:      drift.DensityToGeoJSON(drift.Density(trajectories, req.cellSize), len(trajectories)),
+		Trajectories: drift.ToGeoJSON(trajectories[:min(req.samples, len(trajectories))]),
+	}
+	for _, traj := range trajectories {
+		switch traj.Status {
+		case drift.StatusStranded:
+			resp.Stranded++
+		case drift.StatusOutside:
+			resp.Outside++
+		}
+	}
+	s.respondWithJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,327 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/drift"
+
+	"github.com/paulmach/orb"
+)
+
+func TestParseDriftRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{name: "Minimal", body: `{"release_points": [{"lat": 41, "lon": 2}], "duration_hours": 24}`},
+		{name: "Unknown field", body: `{"release_points": [{"lat": 41, "lon": 2}], "duration_hours": 24, "windage": 0.03}`, wantFields: []string{"body"}},
+		{
+			name:       "Missing points and duration",
+			body:       `{}`,
+			wantFields: []string{"release_points", "duration_hours"},
+		},
+		{
+			name:       "Invalid values",
+			body:       `{"release_points": [{"lat": 91, "lon": 2}, {"lat": 41}], "release_time": "yesterday", "duration_hours": 200, "time_step_minutes": 0.5, "diffusivity": -1, "particles": 600}`,
+			wantFields: []string{"release_points[0]", "release_points[1]", "release_time", "duration_hours", "time_step_minutes", "diffusivity", "particles"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDriftRequest([]byte(tt.body))
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestPostDriftSimulationHandler(t *testing.T) {
+	latest := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	// a uniform northward current of 0.1 m/s over 40-42 N and 1-3 E
+	var data []models.GridData
+	for lat := 40.0; lat <= 42; lat += 0.5 {
+		for lon := 1.0; lon <= 3; lon += 0.5 {
+			data = append(data, models.GridData{MeasurementTime: latest, Latitude: lat, Longitude: lon, Values: []float32{0, 0.1}})
+		}
+	}
+	db := &fakeDB{data: data, latest: latest}
+	s := &Server{db: db}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	t.Run("Trajectories", func(t *testing.T) {
+		body := `{"release_points": [{"lat": 41, "lon": 2}, {"lat": 41, "lon": 5}], "duration_hours": 6, "time_step_minutes": 60}`
+		resp, err := http.Post(server.URL+"/simulations/drift", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+		if !db.startTime.Equal(latest.Add(-24*time.Hour)) || !db.endTime.Equal(latest.Add(30*time.Hour)) {
+			t.Errorf("expected currents within a day of the simulation; got %v to %v", db.startTime, db.endTime)
+		}
+
+		var fc struct {
+			Features []struct {
+				Geometry struct {
+					Type        string       `json:"type"`
+					Coordinates [][2]float64 `json:"coordinates"`
+				} `json:"geometry"`
+				Properties struct {
+					Release     int      `json:"release"`
+					Status      string   `json:"status"`
+					ReleaseTime string   `json:"release_time"`
+					EndTime     string   `json:"end_time"`
+					Times       []string `json:"times"`
+				} `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&fc); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		if len(fc.Features) != 2 {
+			t.Fatalf("expected a trajectory per release point; got %d", len(fc.Features))
+		}
+
+		drifted := fc.Features[0]
+		if drifted.Geometry.Type != "LineString" || len(drifted.Geometry.Coordinates) != 7 {
+			t.Fatalf("expected a LineString of 7 positions; got %s of %d", drifted.Geometry.Type, len(drifted.Geometry.Coordinates))
+		}
+		if drifted.Properties.Status != "active" || drifted.Properties.ReleaseTime != "2025-01-31T00:00:00Z" || drifted.Properties.EndTime != "2025-01-31T06:00:00Z" {
+			t.Errorf("unexpected properties %+v", drifted.Properties)
+		}
+		// 0.1 m/s for 6 hours is 2160 m, about 0.0194° of latitude
+		if end := drifted.Geometry.Coordinates[6]; end[0] != 2 || end[1] < 41.019 || end[1] > 41.02 {
+			t.Errorf("expected to drift about 2 km north; got to %v", end)
+		}
+
+		outside := fc.Features[1]
+		if outside.Properties.Release != 1 || outside.Properties.Status != "outside" || len(outside.Properties.Times) != 1 {
+			t.Errorf("expected the release outside of the currents not to move; got %+v", outside.Properties)
+		}
+	})
+
+	t.Run("No currents", func(t *testing.T) {
+		s := &Server{db: &fakeDB{}}
+		server := httptest.NewServer(s.RegisterRoutes())
+		defer server.Close()
+
+		body := `{"release_points": [{"lat": 41, "lon": 2}], "release_time": "2020-01-01", "duration_hours": 6}`
+		resp, err := http.Post(server.URL+"/simulations/drift", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status Not Found; got %v", resp.Status)
+		}
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		for _, body := range []string{`{"duration_hours": 6}`, ``} {
+			resp, err := http.Post(server.URL+"/simulations/drift", "application/json", strings.NewReader(body))
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status Bad Request for %q; got %v", body, resp.Status)
+			}
+		}
+	})
+}
+
+func TestParseBacktrackRequest(t *testing.T) {
+	square := `{"type": "Polygon", "coordinates": [[[2, 41], [2.1, 41], [2.1, 41.1], [2, 41.1], [2, 41]]]}`
+	tests := []struct {
+		name       string
+		body       string
+		want       backtrackRequest
+		wantFields []string
+	}{
+		{
+			name: "Defaults",
+			body: `{"area": ` + square + `, "duration_hours": 48}`,
+			want: backtrackRequest{
+				area:     orb.MultiPolygon{{{{2, 41}, {2.1, 41}, {2.1, 41.1}, {2, 41.1}, {2, 41}}}},
+				samples:  defaultBacktrackSamples,
+				cellSize: defaultBacktrackCellSize,
+				opts: drift.Options{Duration: 48 * time.Hour, Step: defaultDriftStep, Diffusivity: defaultBacktrackDiffusivity,
+					Particles: defaultBacktrackParticles, Backward: true},
+			},
+		},
+		{
+			name: "Saved region",
+			body: `{"region": 3, "sighting_time": "2025-01-31", "duration_hours": 24, "particles": 10, "diffusivity": 0, "cell_size": 0.1}`,
+			want: backtrackRequest{
+				regionID: 3, sightingTime: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), samples: 10, cellSize: 0.1,
+				opts: drift.Options{Duration: 24 * time.Hour, Step: defaultDriftStep, Particles: 10, Backward: true},
+			},
+		},
+		{name: "Missing area", body: `{"duration_hours": 24}`, wantFields: []string{"area"}},
+		{name: "Area and region", body: `{"area": ` + square + `, "region": 1, "duration_hours": 24}`, wantFields: []string{"area"}},
+		{
+			name:       "Polygon without area",
+			body:       `{"area": {"type": "Polygon", "coordinates": [[[2, 41], [2.1, 41], [2, 41], [2, 41]]]}, "duration_hours": 24}`,
+			wantFields: []string{"area"},
+		},
+		{
+			name:       "Invalid values",
+			body:       `{"area": ` + square + `, "duration_hours": 24, "particles": 10, "samples": 11, "cell_size": 5}`,
+			wantFields: []string{"samples", "cell_size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBacktrackRequest([]byte(tt.body))
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected request %+v; got %+v", tt.want, got)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestPostBacktrackSimulationHandler(t *testing.T) {
+	latest := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	// a uniform eastward current of 0.5 m/s over 40-42 N and 1-3 E
+	var data []models.GridData
+	for lat := 40.0; lat <= 42; lat += 0.5 {
+		for lon := 1.0; lon <= 3; lon += 0.5 {
+			data = append(data, models.GridData{MeasurementTime: latest, Latitude: lat, Longitude: lon, Values: []float32{0.5, 0}})
+		}
+	}
+	sighting := orb.MultiPolygon{{{{2.4, 41}, {2.5, 41}, {2.5, 41.1}, {2.4, 41.1}, {2.4, 41}}}}
+	db := &fakeDB{data: data, latest: latest, regions: []models.Region{{ID: 1, Name: "Sighting", Geometry: sighting}}}
+	s := &Server{db: db}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	post := func(t *testing.T, body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(server.URL+"/simulations/backtrack", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("Origins", func(t *testing.T) {
+		resp := post(t, `{"region": 1, "duration_hours": 24, "time_step_minutes": 120, "particles": 100, "samples": 5, "diffusivity": 0, "cell_size": 0.1}`)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status OK; got %v", resp.Status)
+		}
+		if !db.startTime.Equal(latest.Add(-48*time.Hour)) || !db.endTime.Equal(latest.Add(24*time.Hour)) {
+			t.Errorf("expected currents within a day of the simulation; got %v to %v", db.startTime, db.endTime)
+		}
+
+		var body struct {
+			SightingTime string `json:"sighting_time"`
+			OriginTime   string `json:"origin_time"`
+			Particles    int    `json:"particles"`
+			Outside      int    `json:"outside"`
+			Density      struct {
+				Features []struct {
+					Geometry struct {
+						Coordinates [][][2]float64 `json:"coordinates"`
+					} `json:"geometry"`
+					Properties struct {
+						Count    int     `json:"count"`
+						Fraction float64 `json:"fraction"`
+					} `json:"properties"`
+				} `json:"features"`
+			} `json:"density"`
+			Trajectories struct {
+				Features []json.RawMessage `json:"features"`
+			} `json:"trajectories"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+		if body.SightingTime != "2025-01-31T00:00:00Z" || body.OriginTime != "2025-01-30T00:00:00Z" {
+			t.Errorf("expected to trace back from the latest currents for a day; got %s to %s", body.SightingTime, body.OriginTime)
+		}
+		if body.Particles != 100 || body.Outside != 0 || len(body.Trajectories.Features) != 5 {
+			t.Errorf("expected 100 particles within the currents and 5 sample trajectories; got %d, %d outside and %d samples",
+				body.Particles, body.Outside, len(body.Trajectories.Features))
+		}
+
+		// 0.5 m/s for a day is 43.2 km, about 0.52° of longitude west of the sighting
+		total := 0
+		for _, cell := range body.Density.Features {
+			total += cell.Properties.Count
+			for _, c := range cell.Geometry.Coordinates[0] {
+				if c[0] < 1.8 || c[0] > 2.1 || c[1] < 40.9 || c[1] > 41.2 {
+					t.Fatalf("expected the origins west of the sighting; got a cell at %v", cell.Geometry.Coordinates)
+				}
+			}
+		}
+		if total != 100 {
+			t.Errorf("expected the cells to count every particle; got %d", total)
+		}
+		if first := body.Density.Features[0].Properties; first.Fraction != float64(first.Count)/100 {
+			t.Errorf("expected the fraction of the particles; got %+v", first)
+		}
+	})
+
+	t.Run("Unknown region", func(t *testing.T) {
+		resp := post(t, `{"region": 7, "duration_hours": 24}`)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status Not Found; got %v", resp.Status)
+		}
+	})
+
+	t.Run("Empty body", func(t *testing.T) {
+		resp := post(t, ``)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status Bad Request; got %v", resp.Status)
+		}
+	})
+}
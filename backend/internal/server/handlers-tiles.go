@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/paulmach/orb/maptile"
+)
+
+const (
+	// maxTileZoom is the deepest zoom level tiles are served for
+	maxTileZoom = 22
+	// tileBuffer is the fraction of a tile added around its bound, so point symbols
+	// on tile edges aren't cut off
+	tileBuffer = 1.0 / 64
+)
+
+// tileQuery holds the parameters of the tile endpoints.
+type tileQuery struct {
+	tile maptile.Tile
+	// day of the served data, zero if the latest day is served
+	day      time.Time
+	rawData  bool
+	maskLand bool
+}
+
+// newTileParser returns a parser of the query parameters and of the z, x and y tile
+// coordinates of the path of the tile endpoints.
+func newTileParser(values url.Values, z, x, y string) *queryParser {
+	params := url.Values{"z": {z}, "x": {x}, "y": {y}}
+	for field, v := range values {
+		params[field] = v
+	}
+	return newQueryParser(params)
+}
+
+// tileQuery parses the parameters shared by the tile endpoints.
+func (p *queryParser) tileQuery() tileQuery {
+	var q tileQuery
+	zoom := p.requiredInt("z", 0, maxTileZoom)
+	if !p.failed("z") {
+		tiles := 1<<zoom - 1
+		q.tile = maptile.New(uint32(p.requiredInt("x", 0, tiles)), uint32(p.requiredInt("y", 0, tiles)), maptile.Zoom(zoom))
+	}
+	if t := p.time("time", time.Time{}); !t.IsZero() {
+		q.day = t.Truncate(24 * time.Hour)
+	}
+	q.rawData = p.bool("raw_data", false)
+	q.maskLand = p.bool("mask_land", false)
+	return q
+}
+
+// parseTileQuery validates the tile coordinates of the path and the query parameters of the vector tile endpoint.
+func parseTileQuery(values url.Values, z, x, y string) (tileQuery, error) {
+	p := newTileParser(values, z, x, y)
+	q := p.tileQuery()
+	return q, p.err()
+}
+
+// getTileData returns the data of the day of a tile within its bound extended by margin degrees.
+func (s *Server) getTileData(ctx context.Context, ds *datasets.Dataset, q tileQuery, margin float64) ([]models.GridData, error) {
+	if q.day.IsZero() {
+		latest, err := s.db.GetLatestDatasetTimestamp(ctx, ds)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving latest %s timestamp: %w", ds.Name, err)
+		}
+		q.day = latest.UTC().Truncate(24 * time.Hour)
+	}
+	bound := q.tile.Bound(tileBuffer)
+	return s.db.GetDatasetData(ctx, ds, q.day, q.day.Add(24*time.Hour-time.Nanosecond),
+		bound.Min.Lat()-margin, bound.Min.Lon()-margin, bound.Max.Lat()+margin, bound.Max.Lon()+margin, nil, q.rawData, q.maskLand)
+}
+
+// GetVectorTileHandler serves the data of a single day of a dataset as Mapbox Vector Tiles.
+func (s *Server) GetVectorTileHandler(w http.ResponseWriter, r *http.Request) {
+	ds, ok := datasets.Get(chi.URLParam(r, "dataset"))
+	if !ok {
+		s.respondWithError(w, http.StatusNotFound, "Unknown dataset "+chi.URLParam(r, "dataset"))
+		return
+	}
+	q, err := parseTileQuery(r.URL.Query(), chi.URLParam(r, "z"), chi.URLParam(r, "x"), chi.URLParam(r, "y"))
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	data, err := s.getTileData(r.Context(), ds, q, 0)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" data: "+err.Error())
+		return
+	}
+	if len(data) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	tile, err := models.GridDataToMVT(ds, data, q.tile)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error encoding "+ds.Name+" tile: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(tile)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/maptile"
+)
+
+func TestParseTileQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		z, x, y    string
+		query      string
+		want       tileQuery
+		wantFields []string
+	}{
+		{
+			name: "Tile of the latest day",
+			z:    "8", x: "129", y: "95",
+			want: tileQuery{tile: maptile.New(129, 95, 8)},
+		},
+		{
+			name: "Time selects its day",
+			z:    "8", x: "129", y: "95",
+			query: "time=2025-01-31T12:30:00Z&mask_land=true",
+			want:  tileQuery{tile: maptile.New(129, 95, 8), day: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), maskLand: true},
+		},
+		{
+			name: "Coordinates outside of the zoom level",
+			z:    "2", x: "4", y: "-1",
+			wantFields: []string{"x", "y"},
+		},
+		{
+			name: "Malformed zoom",
+			z:    "deep", x: "0", y: "0",
+			wantFields: []string{"z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			got, err := parseTileQuery(values, tt.z, tt.x, tt.y)
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected query %+v; got %+v", tt.want, got)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestGetVectorTileHandler(t *testing.T) {
+	latest := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	data := []models.GridData{
+		{ID: 1, MeasurementTime: latest, Latitude: 41.1, Longitude: 1.9, Values: []float32{0.5}, Provenance: models.ProvenanceObserved},
+		// within a few meters of the first cell, thinned out below zoom 12
+		{ID: 2, MeasurementTime: latest, Latitude: 41.1001, Longitude: 1.9001, Values: []float32{0.6}, Provenance: models.ProvenanceObserved},
+		{ID: 3, MeasurementTime: latest, Latitude: 41.3, Longitude: 2.1, Values: []float32{0.7}, Provenance: models.ProvenanceObserved},
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		data         []models.GridData
+		wantStatus   int
+		wantFeatures int
+	}{
+		{name: "Thinned tile", path: "/tiles/chlorophyll/8/129/95.mvt", data: data, wantStatus: http.StatusOK, wantFeatures: 2},
+		{name: "Full resolution tile", path: "/tiles/chlorophyll/12/2069/1527.mvt", data: data, wantStatus: http.StatusOK, wantFeatures: 3},
+		{name: "Empty tile", path: "/tiles/chlorophyll/8/129/95.mvt", wantStatus: http.StatusNoContent},
+		{name: "Unknown dataset", path: "/tiles/plankton/8/129/95.mvt", wantStatus: http.StatusNotFound},
+		{name: "Invalid coordinates", path: "/tiles/chlorophyll/1/5/0.mvt", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{data: tt.data, latest: latest}
+			s := &Server{db: db}
+			server := httptest.NewServer(s.RegisterRoutes())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d; got %v", tt.wantStatus, resp.Status)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if want := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC); !db.startTime.Equal(want) {
+				t.Errorf("expected data of %v; got %v", want, db.startTime)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/vnd.mapbox-vector-tile" {
+				t.Errorf("expected vector tile content type; got %q", ct)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("error reading response body. Err: %v", err)
+			}
+			layers, err := mvt.Unmarshal(body)
+			if err != nil {
+				t.Fatalf("error decoding tile. Err: %v", err)
+			}
+			if len(layers) != 1 || layers[0].Name != "chlorophyll" {
+				t.Fatalf("expected a single chlorophyll layer; got %v", layers)
+			}
+			if got := len(layers[0].Features); got != tt.wantFeatures {
+				t.Errorf("expected %d features; got %d", tt.wantFeatures, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geo"
+)
+
+// timeSeries is the series of a single variable at the grid cell nearest to the requested point.
+type timeSeries struct {
+	Dataset string `json:"dataset"`
+	// location of the grid cell and its distance from the requested point in meters,
+	// missing if the dataset has no data in the time range
+	Latitude  *float64    `json:"latitude,omitempty"`
+	Longitude *float64    `json:"longitude,omitempty"`
+	Distance  *float64    `json:"distance_m,omitempty"`
+	Times     []time.Time `json:"times"`
+	// null where the value is missing
+	Values     []*float32 `json:"values"`
+	Provenance []string   `json:"provenance"`
+}
+
+type timeSeriesResponse struct {
+	Latitude  float64                `json:"latitude"`
+	Longitude float64                `json:"longitude"`
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time"`
+	Series    map[string]*timeSeries `json:"series"`
+}
+
+// timeSeriesQuery holds the parameters of the time series endpoint.
+type timeSeriesQuery struct {
+	point              orb.Point
+	startTime, endTime time.Time
+	// requested columns grouped by the dataset storing them, in the order datasets are first requested
+	datasets []*datasets.Dataset
+	columns  map[*datasets.Dataset][]string
+	rawData  bool
+}
+
+func parseTimeSeriesQuery(values url.Values, now time.Time) (timeSeriesQuery, error) {
+	p := newQueryParser(values)
+	q := timeSeriesQuery{columns: make(map[*datasets.Dataset][]string)}
+	lat := p.requiredFloat("lat", -90, 90)
+	lon := p.requiredFloat("lon", -180, 180)
+	q.point = orb.Point{lon, lat}
+	q.startTime, q.endTime = p.timeRange(now)
+	for _, column := range p.list("vars") {
+		ds, ok := datasets.FindColumn(column)
+		if !ok {
+			p.fail("vars", "unknown variable %q", column)
+			continue
+		}
+		if _, ok := q.columns[ds]; !ok {
+			q.datasets = append(q.datasets, ds)
+		}
+		q.columns[ds] = append(q.columns[ds], column)
+	}
+	q.rawData = p.bool("raw_data", false)
+	return q, p.err()
+}
+
+// buildTimeSeries returns the series of the requested columns of the dataset data at a single location.
+func buildTimeSeries(ds *datasets.Dataset, columns []string, data []models.GridData, point orb.Point) map[string]*timeSeries {
+	series := make(map[string]*timeSeries, len(columns))
+	for _, column := range columns {
+		ts := &timeSeries{
+			Dataset:    ds.Name,
+			Times:      make([]time.Time, len(data)),
+			Values:     make([]*float32, len(data)),
+			Provenance: make([]string, len(data)),
+		}
+		if len(data) > 0 {
+			lat, lon := data[0].Latitude, data[0].Longitude
+			distance := geo.Distance(point, orb.Point{lon, lat})
+			ts.Latitude, ts.Longitude, ts.Distance = &lat, &lon, &distance
+		}
+		index := ds.ColumnIndex(column)
+		for i, d := range data {
+			ts.Times[i] = d.MeasurementTime
+			ts.Provenance[i] = d.Provenance
+			if v := d.Values[index]; !math.IsNaN(float64(v)) {
+				ts.Values[i] = &v
+			}
+		}
+		series[column] = ts
+	}
+	return series
+}
+
+// GetTimeSeriesHandler serves the series of the requested variables at the grid cells nearest to a point,
+// responding with 404 if none of the datasets has data near the point in the time range.
+func (s *Server) GetTimeSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	q, err := parseTimeSeriesQuery(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+
+	resp := timeSeriesResponse{
+		Latitude:  q.point.Lat(),
+		Longitude: q.point.Lon(),
+		StartTime: q.startTime,
+		EndTime:   q.endTime,
+		Series:    make(map[string]*timeSeries),
+	}
+	found := false
+	for _, ds := range q.datasets {
+		data, err := s.db.GetDatasetTimeSeries(r.Context(), ds, q.point, q.startTime, q.endTime, q.rawData)
+		if err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, "Error retrieving "+ds.Name+" time series: "+err.Error())
+			return
+		}
+		found = found || len(data) > 0
+		for column, ts := range buildTimeSeries(ds, q.columns[ds], data, q.point) {
+			resp.Series[column] = ts
+		}
+	}
+	if !found {
+		s.respondWithError(w, http.StatusNotFound, "No data near the point in the time range")
+		return
+	}
+	s.respondWithJSON(w, http.StatusOK, resp)
+}
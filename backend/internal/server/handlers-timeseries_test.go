@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+func TestParseTimeSeriesQuery(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantColumns map[string][]string
+		wantFields  []string
+	}{
+		{
+			name:  "Variables are grouped by dataset",
+			query: "lat=41.18&lon=1.75&vars=chlor_a,u_current,v_current,chlor_a",
+			wantColumns: map[string][]string{
+				"chlorophyll": {"chlor_a"},
+				"currents":    {"u_current", "v_current"},
+			},
+		},
+		{
+			name:       "Missing location and variables",
+			query:      "",
+			wantFields: []string{"lat", "lon", "vars"},
+		},
+		{
+			name:       "Unknown variable",
+			query:      "lat=41.18&lon=1.75&vars=chlor_a,salinity",
+			wantFields: []string{"vars"},
+		},
+		{
+			name:       "Latitude out of range",
+			query:      "lat=91&lon=1.75&vars=sst",
+			wantFields: []string{"lat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseTimeSeriesQuery(values, now)
+
+			if tt.wantFields != nil {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected *ValidationError, got %v", err)
+				}
+				var fields []string
+				for _, f := range validationErr.Fields {
+					fields = append(fields, f.Field)
+				}
+				if !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Errorf("rejected fields %v, want %v", fields, tt.wantFields)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseTimeSeriesQuery() returned error: %v", err)
+			}
+			columns := make(map[string][]string)
+			for _, ds := range q.datasets {
+				columns[ds.Name] = q.columns[ds]
+			}
+			if !reflect.DeepEqual(columns, tt.wantColumns) {
+				t.Errorf("columns %v, want %v", columns, tt.wantColumns)
+			}
+		})
+	}
+}
+
+func TestBuildTimeSeries(t *testing.T) {
+	ds, _ := datasets.Get("currents")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := []models.GridData{
+		{MeasurementTime: start, Latitude: 41.125, Longitude: 1.875, Values: []float32{0.1, 0.2}, Provenance: models.ProvenanceObserved},
+		{MeasurementTime: start.Add(24 * time.Hour), Latitude: 41.125, Longitude: 1.875, Values: []float32{float32(math.NaN()), 0.3}, Provenance: models.ProvenanceObserved},
+	}
+
+	series := buildTimeSeries(ds, []string{"v_current", "u_current"}, data, orb.Point{1.75, 41.18})
+
+	v := series["v_current"]
+	if v == nil || len(v.Values) != 2 || *v.Values[0] != 0.2 || *v.Values[1] != 0.3 {
+		t.Fatalf("unexpected v_current series %+v", v)
+	}
+	u := series["u_current"]
+	if u == nil || *u.Values[0] != 0.1 || u.Values[1] != nil {
+		t.Fatalf("expected missing u_current value to be null, got %+v", u)
+	}
+	if u.Latitude == nil || *u.Latitude != 41.125 || u.Distance == nil || *u.Distance < 10000 || *u.Distance > 13000 {
+		t.Errorf("unexpected grid cell location %v, %v at distance %v", u.Latitude, u.Longitude, u.Distance)
+	}
+
+	empty := buildTimeSeries(ds, []string{"u_current"}, nil, orb.Point{1.75, 41.18})["u_current"]
+	if empty.Latitude != nil || len(empty.Times) != 0 {
+		t.Errorf("expected empty series without location, got %+v", empty)
+	}
+}
+
+func TestGetTimeSeriesHandler(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		data       []models.GridData
+		wantStatus int
+	}{
+		{
+			name:       "Nearest cell",
+			data:       []models.GridData{{MeasurementTime: start, Latitude: 41.177, Longitude: 1.75, Values: []float32{0.21}}},
+			wantStatus: http.StatusOK,
+		},
+		// the database returns nothing when no cell is near the point
+		{name: "No cell nearby", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{db: &fakeDB{data: tt.data}}
+			server := httptest.NewServer(s.RegisterRoutes())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/timeseries?lat=41.18&lon=1.75&vars=chlor_a&start_time=2025-01-01&end_time=2025-01-02")
+			if err != nil {
+				t.Fatalf("error making request to server. Err: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d; got %v", tt.wantStatus, resp.Status)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var body timeSeriesResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("error decoding response body. Err: %v", err)
+			}
+			if ts := body.Series["chlor_a"]; ts == nil || len(ts.Values) != 1 || *ts.Values[0] != 0.21 {
+				t.Errorf("expected the chlor_a series; got %+v", body.Series)
+			}
+		})
+	}
+}
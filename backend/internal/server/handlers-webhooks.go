@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/utils/notifier"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Limits of the webhook subscriptions and their delivery history.
+const (
+	maxWebhookURLLength    = 2000
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 200
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// webhookRequestBody is the JSON body subscribing a webhook.
+type webhookRequestBody struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// webhookRequest holds a validated webhook subscription.
+type webhookRequest struct {
+	url    string
+	events []string
+	secret string
+}
+
+// webhookResponse shows the secret of a webhook, which is only done when it's created.
+type webhookResponse struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// parseWebhookRequest validates the JSON body of a webhook subscription. Without events the
+// webhook is subscribed to all of them, without a secret the caller generates one.
+func parseWebhookRequest(body []byte) (webhookRequest, error) {
+	p := newQueryParser(nil)
+	var req webhookRequest
+	var b webhookRequestBody
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		p.fail("body", "must be a JSON object of a webhook: %s", err)
+		return req, p.err()
+	}
+
+	req.url = strings.TrimSpace(b.URL)
+	if req.url == "" {
+		p.fail("url", "is required")
+	} else if u, err := url.Parse(req.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		p.fail("url", "must be an absolute http or https URL, got %q", req.url)
+	} else if len(req.url) > maxWebhookURLLength {
+		p.fail("url", "must be at most %d characters long", maxWebhookURLLength)
+	}
+
+	if len(b.Events) == 0 {
+		req.events = models.WebhookEvents
+	}
+	for _, event := range b.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			p.fail("events", "must be one of %s, got %q", strings.Join(models.WebhookEvents, ", "), event)
+		} else if !slices.Contains(req.events, event) {
+			req.events = append(req.events, event)
+		}
+	}
+
+	req.secret = b.Secret
+	if req.secret != "" && (len(req.secret) < minWebhookSecretLength || len(req.secret) > maxWebhookSecretLength) {
+		p.fail("secret", "must be between %d and %d characters long", minWebhookSecretLength, maxWebhookSecretLength)
+	}
+	return req, p.err()
+}
+
+// GetWebhooksHandler lists the webhook subscriptions, without their secrets.
+func (s *Server) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := s.db.GetWebhooks(r.Context())
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving webhooks: "+err.Error())
+		return
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+	s.respondWithJSON(w, http.StatusOK, webhooks)
+}
+
+// CreateWebhookHandler subscribes the webhook of the JSON body, responding with its secret.
+func (s *Server) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := readJSONBody(w, r)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	req, err := parseWebhookRequest(body)
+	if err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	if req.secret == "" {
+		if req.secret, err = notifier.NewSecret(); err != nil {
+			s.respondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	webhook, err := s.db.CreateWebhook(r.Context(), req.url, req.events, req.secret)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error saving webhook: "+err.Error())
+		return
+	}
+	w.Header().Set("Location", "/webhooks/"+strconv.Itoa(webhook.ID))
+	s.respondWithJSON(w, http.StatusCreated, webhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetWebhookHandler serves a webhook subscription, without its secret.
+func (s *Server) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := s.webhook(w, r)
+	if !ok {
+		return
+	}
+	s.respondWithJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhookHandler removes a webhook subscription and its delivery history.
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.webhookID(w, r)
+	if !ok {
+		return
+	}
+	err := s.db.DeleteWebhook(r.Context(), id)
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Unknown webhook "+strconv.Itoa(id))
+		return
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error deleting webhook: "+err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveriesHandler lists the last deliveries to a webhook, newest first.
+func (s *Server) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	p := newQueryParser(r.URL.Query())
+	limit := defaultDeliveriesLimit
+	if p.values.Get("limit") != "" {
+		limit = p.requiredInt("limit", 1, maxDeliveriesLimit)
+	}
+	if err := p.err(); err != nil {
+		s.respondWithValidationError(w, err)
+		return
+	}
+	webhook, ok := s.webhook(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := s.db.GetWebhookDeliveries(r.Context(), webhook.ID, limit)
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving webhook deliveries: "+err.Error())
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	s.respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// webhook returns the webhook of the id path parameter, responding with 404 if it doesn't exist.
+func (s *Server) webhook(w http.ResponseWriter, r *http.Request) (models.Webhook, bool) {
+	id, ok := s.webhookID(w, r)
+	if !ok {
+		return models.Webhook{}, false
+	}
+	webhook, err := s.db.GetWebhook(r.Context(), id)
+	if errors.Is(err, database.ErrWebhookNotFound) {
+		s.respondWithError(w, http.StatusNotFound, "Unknown webhook "+strconv.Itoa(id))
+		return models.Webhook{}, false
+	}
+	if err != nil {
+		s.respondWithError(w, http.StatusInternalServerError, "Error retrieving webhook: "+err.Error())
+		return models.Webhook{}, false
+	}
+	return webhook, true
+}
+
+// webhookID returns the id path parameter, responding with 404 if it isn't a webhook ID.
+func (s *Server) webhookID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id < 1 {
+		s.respondWithError(w, http.StatusNotFound, "Unknown webhook "+chi.URLParam(r, "id"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+func TestParseWebhookRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		want       webhookRequest
+		wantFields []string
+	}{
+		{
+			name: "Defaults to every event",
+			body: `{"url":"https://example.com/hooks"}`,
+			want: webhookRequest{url: "https://example.com/hooks", events: models.WebhookEvents},
+		},
+		{
+			name: "Events and secret",
+			body: `{"url":"http://localhost:9000","events":["bloom.detected","bloom.detected"],"secret":"0123456789abcdef"}`,
+			want: webhookRequest{url: "http://localhost:9000", events: []string{"bloom.detected"}, secret: "0123456789abcdef"},
+		},
+		{name: "Missing URL", body: `{}`, wantFields: []string{"url"}},
+		{name: "Relative URL", body: `{"url":"/hooks"}`, wantFields: []string{"url"}},
+		{name: "Unsupported scheme", body: `{"url":"ftp://example.com"}`, wantFields: []string{"url"}},
+		{name: "Unknown event", body: `{"url":"https://example.com","events":["data.deleted"]}`, wantFields: []string{"events"}},
+		{name: "Short secret", body: `{"url":"https://example.com","secret":"short"}`, wantFields: []string{"secret"}},
+		{name: "Unknown field", body: `{"url":"https://example.com","token":"x"}`, wantFields: []string{"body"}},
+		{name: "Not JSON", body: `url=https://example.com`, wantFields: []string{"body"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWebhookRequest([]byte(tt.body))
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("expected request %+v; got %+v", tt.want, got)
+				}
+				return
+			}
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected a validation error; got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected rejected fields %v; got %v", tt.wantFields, fields)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlers(t *testing.T) {
+	db := &fakeDB{deliveries: []models.WebhookDelivery{
+		{ID: 3, WebhookID: 1, Event: models.EventBloomDetected, Attempts: 1, StatusCode: 200},
+		{ID: 2, WebhookID: 2, Event: models.EventDataIngested, Attempts: 1, StatusCode: 200},
+		{ID: 1, WebhookID: 1, Event: models.EventIngestionFailed, Attempts: 4, StatusCode: 503, Error: "unexpected status: 503 Service Unavailable"},
+	}}
+	s := &Server{db: db}
+	server := httptest.NewServer(s.RegisterRoutes())
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("error making request to server. Err: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	decode := func(resp *http.Response, v any) {
+		t.Helper()
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("error decoding response body. Err: %v", err)
+		}
+	}
+
+	resp := do(http.MethodPost, "/webhooks", `{"url":"https://example.com/hooks","events":["ingestion.failed"]}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status Created; got %v", resp.Status)
+	}
+	if loc := resp.Header.Get("Location"); loc != "/webhooks/1" {
+		t.Errorf("expected location /webhooks/1; got %q", loc)
+	}
+	var created map[string]any
+	decode(resp, &created)
+	if secret, _ := created["secret"].(string); len(secret) != 64 || secret != db.webhooks[0].Secret {
+		t.Errorf("expected the generated secret in the response; got %v", created["secret"])
+	}
+	if resp := do(http.MethodPost, "/webhooks", `{"url":"example.com"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request for an invalid URL; got %v", resp.Status)
+	}
+	for name, body := range map[string]string{
+		"an empty body":     " ",
+		"an oversized body": `{"url":"https://example.com","secret":"` + strings.Repeat("x", maxJSONBodySize) + `"}`,
+	} {
+		resp := do(http.MethodPost, "/webhooks", body)
+		var e errorResponse
+		decode(resp, &e)
+		if resp.StatusCode != http.StatusBadRequest || e.Error != "invalid request body" || len(e.Details) != 1 || e.Details[0].Field != "body" {
+			t.Errorf("expected the body to be rejected for %s; got %v %+v", name, resp.Status, e)
+		}
+	}
+
+	resp = do(http.MethodGet, "/webhooks/1", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", resp.Status)
+	}
+	var webhook map[string]any
+	decode(resp, &webhook)
+	if _, ok := webhook["secret"]; ok || webhook["url"] != "https://example.com/hooks" {
+		t.Errorf("expected the webhook without its secret; got %v", webhook)
+	}
+
+	resp = do(http.MethodGet, "/webhooks/1/deliveries?limit=1", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", resp.Status)
+	}
+	var deliveries []models.WebhookDelivery
+	decode(resp, &deliveries)
+	if len(deliveries) != 1 || deliveries[0].ID != 3 {
+		t.Errorf("expected the newest delivery of the webhook; got %+v", deliveries)
+	}
+	if resp := do(http.MethodGet, "/webhooks/1/deliveries?limit=0", ""); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status Bad Request for a limit of 0; got %v", resp.Status)
+	}
+
+	if resp := do(http.MethodDelete, "/webhooks/1", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status No Content; got %v", resp.Status)
+	}
+	for _, path := range []string{"/webhooks/1", "/webhooks/first", "/webhooks/1/deliveries"} {
+		if resp := do(http.MethodGet, path, ""); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status Not Found for %s; got %v", path, resp.Status)
+		}
+	}
+
+	resp = do(http.MethodGet, "/webhooks", "")
+	var webhooks []models.Webhook
+	decode(resp, &webhooks)
+	if webhooks == nil || len(webhooks) != 0 {
+		t.Errorf("expected an empty list; got %v", webhooks)
+	}
+}
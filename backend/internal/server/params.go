@@ -0,0 +1,451 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// Default area served when the request doesn't set a bounding box, the same area the updater downloads.
+const (
+	defaultMinLat = 40.50
+	defaultMinLon = 1.10
+	defaultMaxLat = 41.46
+	defaultMaxLon = 2.83
+)
+
+const (
+	// defaultTimeWindow is served when the request doesn't set start_time
+	defaultTimeWindow = 14 * 24 * time.Hour
+	// maxTimeWindow is the longest time range a single request may ask for
+	maxTimeWindow = 120 * 24 * time.Hour
+)
+
+// timeLayouts are the ISO-8601 forms accepted for time parameters.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// FieldError describes why a single query parameter was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError holds every rejected query parameter or body field of a request.
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return e.Message + ": " + strings.Join(messages, "; ")
+}
+
+// queryParser reads query parameters, collecting an error for every invalid one
+// so a single response can report all of them.
+type queryParser struct {
+	values url.Values
+	errors []FieldError
+}
+
+func newQueryParser(values url.Values) *queryParser {
+	return &queryParser{values: values}
+}
+
+func (p *queryParser) fail(field, format string, args ...any) {
+	p.errors = append(p.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// failed reports whether field was already rejected.
+func (p *queryParser) failed(field string) bool {
+	for _, e := range p.errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns the collected errors as a *ValidationError, or nil. Parsers without
+// query parameters validate request bodies and report an invalid body.
+func (p *queryParser) err() error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+	message := "invalid query parameters"
+	switch {
+	case p.values == nil:
+		message = "invalid request body"
+	case p.failed("body"):
+		message = "invalid query parameters or body"
+	}
+	return &ValidationError{Message: message, Fields: p.errors}
+}
+
+// time returns field parsed as an ISO-8601 time, or def if it's not set.
+func (p *queryParser) time(field string, def time.Time) time.Time {
+	raw := p.values.Get(field)
+	if raw == "" {
+		return def
+	}
+	if t, ok := parseTime(raw); ok {
+		return t
+	}
+	p.fail(field, "must be an ISO-8601 time, e.g. 2025-01-31T00:00:00Z, got %q", raw)
+	return def
+}
+
+// parseTime parses raw in any of timeLayouts, returning the time in UTC.
+func parseTime(raw string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// float returns field parsed as a number within [min, max], or def if it's not set.
+func (p *queryParser) float(field string, def, min, max float64) float64 {
+	raw := p.values.Get(field)
+	if raw == "" {
+		return def
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		p.fail(field, "must be a number, got %q", raw)
+		return def
+	}
+	if val < min || val > max {
+		p.fail(field, "must be between %g and %g, got %g", min, max, val)
+		return def
+	}
+	return val
+}
+
+// requiredFloat returns field parsed as a number within [min, max], rejecting it if it's not set.
+func (p *queryParser) requiredFloat(field string, min, max float64) float64 {
+	if p.values.Get(field) == "" {
+		p.fail(field, "is required")
+		return 0
+	}
+	return p.float(field, 0, min, max)
+}
+
+// requiredInt returns field parsed as an integer within [min, max], rejecting it if it's not set.
+func (p *queryParser) requiredInt(field string, min, max int) int {
+	raw := p.values.Get(field)
+	if raw == "" {
+		p.fail(field, "is required")
+		return 0
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil {
+		p.fail(field, "must be an integer, got %q", raw)
+		return 0
+	}
+	if val < min || val > max {
+		p.fail(field, "must be between %d and %d, got %d", min, max, val)
+		return 0
+	}
+	return val
+}
+
+// list returns the comma separated values of field, rejecting it if it's empty.
+func (p *queryParser) list(field string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(p.values.Get(field), ",") {
+		item = strings.TrimSpace(item)
+		if item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		p.fail(field, "is required")
+	}
+	return items
+}
+
+// bool returns field parsed as a boolean, or def if it's not set.
+func (p *queryParser) bool(field string, def bool) bool {
+	raw := p.values.Get(field)
+	if raw == "" {
+		return def
+	}
+	val, err := strconv.ParseBool(raw)
+	if err != nil {
+		p.fail(field, "must be true or false, got %q", raw)
+		return def
+	}
+	return val
+}
+
+// oneOf returns field if it's one of allowed, or def if it's not set.
+func (p *queryParser) oneOf(field, def string, allowed ...string) string {
+	raw := p.values.Get(field)
+	if raw == "" {
+		return def
+	}
+	for _, a := range allowed {
+		if raw == a {
+			return raw
+		}
+	}
+	p.fail(field, "must be one of %s, got %q", strings.Join(allowed, ", "), raw)
+	return def
+}
+
+// timeRange parses start_time and end_time, checking their order and that the range
+// is at most maxTimeWindow long. Without start_time the last defaultTimeWindow before
+// end_time is returned.
+func (p *queryParser) timeRange(now time.Time) (time.Time, time.Time) {
+	return p.timeRangeWithDefault(now, defaultTimeWindow)
+}
+
+// timeRangeWithDefault is timeRange returning the last window before end_time without start_time.
+func (p *queryParser) timeRangeWithDefault(now time.Time, window time.Duration) (time.Time, time.Time) {
+	endTime := p.time("end_time", now)
+	startTime := p.time("start_time", endTime.Add(-window))
+	if p.failed("start_time") || p.failed("end_time") {
+		return startTime, endTime
+	}
+	p.checkTimeRange("start_time", "end_time", startTime, endTime)
+	return startTime, endTime
+}
+
+// checkTimeRange rejects ranges ending before they start or longer than maxTimeWindow.
+func (p *queryParser) checkTimeRange(startField, endField string, startTime, endTime time.Time) {
+	if startTime.After(endTime) {
+		p.fail(startField, "must not be after %s", endField)
+	} else if endTime.Sub(startTime) > maxTimeWindow {
+		p.fail(endField, "time range must not exceed %d days", int(maxTimeWindow.Hours()/24))
+	}
+}
+
+// boundingBox parses min_lat, min_lon, max_lat and max_lon, checking their ranges and order.
+func (p *queryParser) boundingBox() (minLat, minLon, maxLat, maxLon float64) {
+	minLat = p.float("min_lat", defaultMinLat, -90, 90)
+	minLon = p.float("min_lon", defaultMinLon, -180, 180)
+	maxLat = p.float("max_lat", defaultMaxLat, -90, 90)
+	maxLon = p.float("max_lon", defaultMaxLon, -180, 180)
+	if !p.failed("min_lat") && !p.failed("max_lat") && minLat >= maxLat {
+		p.fail("min_lat", "must be less than max_lat")
+	}
+	if !p.failed("min_lon") && !p.failed("max_lon") && minLon >= maxLon {
+		p.fail("min_lon", "must be less than max_lon")
+	}
+	return minLat, minLon, maxLat, maxLon
+}
+
+// boundingBoxFields are the parameters read by boundingBox.
+var boundingBoxFields = []string{"min_lat", "min_lon", "max_lat", "max_lon"}
+
+// wkt returns the geometry of a required WKT field.
+func (p *queryParser) wkt(field string) orb.Geometry {
+	raw := p.values.Get(field)
+	if raw == "" {
+		p.fail(field, "is required")
+		return nil
+	}
+	geom, err := wkt.Unmarshal(raw)
+	if err != nil {
+		p.fail(field, "must be a WKT geometry, got %q", raw)
+		return nil
+	}
+	return geom
+}
+
+// checkCoordinates rejects coordinates outside of the valid longitude and latitude ranges.
+func (p *queryParser) checkCoordinates(field string, bound orb.Bound) {
+	if bound.Min.Lon() < -180 || bound.Max.Lon() > 180 || bound.Min.Lat() < -90 || bound.Max.Lat() > 90 {
+		p.fail(field, "longitudes must be between -180 and 180 and latitudes between -90 and 90")
+	}
+}
+
+// multiPolygon returns geom as a multipolygon, rejecting field if geom is another geometry,
+// has unclosed rings or coordinates out of range.
+func (p *queryParser) multiPolygon(field string, geom orb.Geometry) orb.MultiPolygon {
+	area, err := toMultiPolygon(geom)
+	if err != nil {
+		p.fail(field, "%s", err)
+		return nil
+	}
+	p.checkCoordinates(field, area.Bound())
+	if p.failed(field) {
+		return nil
+	}
+	return area
+}
+
+// area parses the polygon q is restricted to, given either as a WKT POLYGON or MULTIPOLYGON
+// in the wkt field, as a GeoJSON body or as the ID of a saved region in the region field,
+// which is left for the handler to load. The polygon replaces the bounding box, so only
+// one of them may be given.
+func (p *queryParser) area(q *datasetQuery, body []byte) {
+	var given []string
+	for _, field := range []string{"wkt", "region"} {
+		if p.values.Get(field) != "" {
+			given = append(given, field)
+		}
+	}
+	if len(body) > 0 {
+		given = append(given, "body")
+	}
+	for _, field := range boundingBoxFields {
+		if p.values.Get(field) != "" {
+			given = append(given, field)
+			break
+		}
+	}
+	if len(given) == 0 {
+		return
+	}
+	if len(given) > 1 {
+		p.fail(given[1], "must not be combined with %s", given[0])
+		return
+	}
+
+	var area orb.MultiPolygon
+	switch given[0] {
+	case "wkt":
+		if geom := p.wkt("wkt"); geom != nil {
+			area = p.multiPolygon("wkt", geom)
+		}
+	case "region":
+		q.regionID = p.requiredInt("region", 1, math.MaxInt32)
+	case "body":
+		geom, err := geojsonArea(body)
+		if err != nil {
+			p.fail("body", "%s", err)
+			return
+		}
+		area = p.multiPolygon("body", geom)
+	}
+	if area != nil {
+		q.setArea(area)
+	}
+}
+
+// geojsonArea returns the polygons of a GeoJSON geometry, feature or feature collection.
+func geojsonArea(body []byte) (orb.MultiPolygon, error) {
+	var doc struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, errors.New("must be a GeoJSON geometry, feature or feature collection")
+	}
+
+	var geometries []orb.Geometry
+	switch doc.Type {
+	case "FeatureCollection":
+		fc, err := geojson.UnmarshalFeatureCollection(body)
+		if err != nil {
+			return nil, fmt.Errorf("must be a GeoJSON feature collection: %w", err)
+		}
+		for _, f := range fc.Features {
+			geometries = append(geometries, f.Geometry)
+		}
+	case "Feature":
+		f, err := geojson.UnmarshalFeature(body)
+		if err != nil {
+			return nil, fmt.Errorf("must be a GeoJSON feature: %w", err)
+		}
+		geometries = append(geometries, f.Geometry)
+	default:
+		g, err := geojson.UnmarshalGeometry(body)
+		if err != nil {
+			return nil, fmt.Errorf("must be a GeoJSON geometry: %w", err)
+		}
+		geometries = append(geometries, g.Geometry())
+	}
+
+	var area orb.MultiPolygon
+	for _, geom := range geometries {
+		polygons, err := toMultiPolygon(geom)
+		if err != nil {
+			return nil, err
+		}
+		area = append(area, polygons...)
+	}
+	if len(area) == 0 {
+		return nil, errors.New("must hold at least one polygon")
+	}
+	return area, nil
+}
+
+// toMultiPolygon returns a polygon or multipolygon as a multipolygon, checking that its rings are closed.
+func toMultiPolygon(geom orb.Geometry) (orb.MultiPolygon, error) {
+	var area orb.MultiPolygon
+	switch g := geom.(type) {
+	case orb.Polygon:
+		area = orb.MultiPolygon{g}
+	case orb.MultiPolygon:
+		area = g
+	case nil:
+		return nil, errors.New("must be a polygon or multipolygon")
+	default:
+		return nil, fmt.Errorf("must be a polygon or multipolygon, got %s", geom.GeoJSONType())
+	}
+	for _, polygon := range area {
+		if len(polygon) == 0 {
+			return nil, errors.New("polygons must have an outer ring")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 || !ring.Closed() {
+				return nil, errors.New("rings must have at least 4 positions and end where they start")
+			}
+		}
+	}
+	return area, nil
+}
+
+// datasetQuery holds the parameters shared by the dataset endpoints.
+type datasetQuery struct {
+	startTime, endTime             time.Time
+	minLat, minLon, maxLat, maxLon float64
+	// area the rows are restricted to besides the bounding box, nil if the request has none
+	area orb.MultiPolygon
+	// regionID is the saved region the request is restricted to, 0 if none
+	regionID int
+	rawData  bool
+	maskLand bool
+	// anomaly serves the difference of the values to the climatology besides the values
+	anomaly bool
+	// format is the requested response format, empty if it's left to the Accept header
+	format string
+}
+
+// setArea restricts the query to area, narrowing the bounding box to its bound.
+func (q *datasetQuery) setArea(area orb.MultiPolygon) {
+	q.area = area
+	bound := area.Bound()
+	q.minLat, q.minLon, q.maxLat, q.maxLon = bound.Min.Lat(), bound.Min.Lon(), bound.Max.Lat(), bound.Max.Lon()
+}
+
+// parseDatasetQuery validates the query parameters and the GeoJSON body, if any, of the dataset endpoints.
+func parseDatasetQuery(values url.Values, body []byte, now time.Time) (datasetQuery, error) {
+	p := newQueryParser(values)
+	var q datasetQuery
+	q.startTime, q.endTime = p.timeRange(now)
+	q.minLat, q.minLon, q.maxLat, q.maxLon = p.boundingBox()
+	p.area(&q, body)
+	q.rawData = p.bool("raw_data", false)
+	q.maskLand = p.bool("mask_land", false)
+	q.anomaly = p.bool("anomaly", false)
+	q.format = p.oneOf("format", "", formatGeoJSON, formatCSV, formatNetCDF)
+	return q, p.err()
+}
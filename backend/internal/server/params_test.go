@@ -0,0 +1,158 @@
+package server
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+)
+
+func TestParseDatasetQuery(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		want       datasetQuery
+		wantFields []string
+	}{
+		{
+			name:  "Defaults",
+			query: "",
+			want: datasetQuery{
+				startTime: now.Add(-defaultTimeWindow), endTime: now,
+				minLat: defaultMinLat, minLon: defaultMinLon, maxLat: defaultMaxLat, maxLon: defaultMaxLon,
+			},
+		},
+		{
+			name:  "All parameters",
+			query: "start_time=2025-01-01T00:00:00Z&end_time=2025-01-31&min_lat=40&min_lon=1&max_lat=42&max_lon=3&raw_data=true&mask_land=1&anomaly=true",
+			want: datasetQuery{
+				startTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				endTime:   time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+				minLat:    40, minLon: 1, maxLat: 42, maxLon: 3,
+				rawData: true, maskLand: true, anomaly: true,
+			},
+		},
+		{
+			name:  "Time with offset is converted to UTC",
+			query: "start_time=2025-01-01T02:00:00%2B02:00&end_time=2025-01-02T00:00:00Z",
+			want: datasetQuery{
+				startTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				endTime:   time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+				minLat:    defaultMinLat, minLon: defaultMinLon, maxLat: defaultMaxLat, maxLon: defaultMaxLon,
+			},
+		},
+		{
+			name:       "Malformed time",
+			query:      "start_time=yesterday",
+			wantFields: []string{"start_time"},
+		},
+		{
+			name:       "Start after end",
+			query:      "start_time=2025-02-01&end_time=2025-01-01",
+			wantFields: []string{"start_time"},
+		},
+		{
+			name:       "Time range too long",
+			query:      "start_time=2024-01-01&end_time=2025-01-01",
+			wantFields: []string{"end_time"},
+		},
+		{
+			name:       "Malformed and out of range coordinates",
+			query:      "min_lat=north&max_lon=181",
+			wantFields: []string{"min_lat", "max_lon"},
+		},
+		{
+			name:       "Min not less than max",
+			query:      "min_lat=41&max_lat=41&min_lon=3&max_lon=2",
+			wantFields: []string{"min_lat", "min_lon"},
+		},
+		{
+			name:  "WKT polygon replaces the bounding box",
+			query: "wkt=" + url.QueryEscape("POLYGON((1 40, 2 40, 2 41, 1 40))"),
+			want: datasetQuery{
+				startTime: now.Add(-defaultTimeWindow), endTime: now,
+				minLat: 40, minLon: 1, maxLat: 41, maxLon: 2,
+				area: orb.MultiPolygon{{{{1, 40}, {2, 40}, {2, 41}, {1, 40}}}},
+			},
+		},
+		{
+			name: "GeoJSON feature collection body",
+			body: `{"type":"FeatureCollection","features":[
+				{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[1,40],[2,40],[2,41],[1,40]]]}},
+				{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[3,42],[4,42],[4,43],[3,42]]]}}
+			]}`,
+			want: datasetQuery{
+				startTime: now.Add(-defaultTimeWindow), endTime: now,
+				minLat: 40, minLon: 1, maxLat: 43, maxLon: 4,
+				area: orb.MultiPolygon{{{{1, 40}, {2, 40}, {2, 41}, {1, 40}}}, {{{3, 42}, {4, 42}, {4, 43}, {3, 42}}}},
+			},
+		},
+		{
+			name:  "Saved region",
+			query: "region=7",
+			want: datasetQuery{
+				startTime: now.Add(-defaultTimeWindow), endTime: now,
+				minLat: defaultMinLat, minLon: defaultMinLon, maxLat: defaultMaxLat, maxLon: defaultMaxLon,
+				regionID: 7,
+			},
+		},
+		{
+			name:       "Polygon combined with a bounding box",
+			query:      "region=7&min_lat=40",
+			wantFields: []string{"min_lat"},
+		},
+		{
+			name:       "Point instead of a polygon",
+			query:      "wkt=POINT(1 40)",
+			wantFields: []string{"wkt"},
+		},
+		{
+			name:       "Unclosed ring",
+			body:       `{"type":"Polygon","coordinates":[[[1,40],[2,40],[2,41],[1,41]]]}`,
+			wantFields: []string{"body"},
+		},
+		{
+			name:       "Malformed booleans",
+			query:      "raw_data=maybe&mask_land=yes&anomaly=no",
+			wantFields: []string{"raw_data", "mask_land", "anomaly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("invalid test query: %v", err)
+			}
+			got, err := parseDatasetQuery(values, []byte(tt.body), now)
+
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("parseDatasetQuery() returned error: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("parseDatasetQuery() = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			var fields []string
+			for _, f := range validationErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("rejected fields %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 
+	"ocean-digital-twin/internal/datasets"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -30,11 +32,59 @@ func (s *Server) RegisterRoutes() http.Handler {
 		r.Post("/", s.NewCountHandler)
 	})
 
-	r.Route("/chlorophyll", func(r chi.Router) {
-		r.Get("/", s.GetChlorophyllDataHandler)
+	for _, ds := range datasets.All() {
+		r.Route(ds.Route, func(r chi.Router) {
+			r.Get("/", s.GetDatasetDataHandler(ds))
+			r.Post("/", s.GetDatasetDataHandler(ds))
+			r.Get("/aggregate", s.GetDatasetAggregateHandler(ds))
+			r.Post("/aggregate", s.GetDatasetAggregateHandler(ds))
+		})
+	}
+
+	r.Route("/regions", func(r chi.Router) {
+		r.Get("/", s.GetRegionsHandler)
+		r.Post("/", s.CreateRegionHandler)
+		r.Get("/{id}", s.GetRegionHandler)
+		r.Delete("/{id}", s.DeleteRegionHandler)
+	})
+
+	r.Route("/events", func(r chi.Router) {
+		r.Get("/blooms", s.GetBloomEventsHandler)
+		r.Post("/blooms", s.GetBloomEventsHandler)
+	})
+
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", s.GetWebhooksHandler)
+		r.Post("/", s.CreateWebhookHandler)
+		r.Get("/{id}", s.GetWebhookHandler)
+		r.Delete("/{id}", s.DeleteWebhookHandler)
+		r.Get("/{id}/deliveries", s.GetWebhookDeliveriesHandler)
 	})
-	r.Route("/currents", func(r chi.Router) {
-		r.Get("/", s.GetCurrentsDataHandler)
+
+	r.Get("/timeseries", s.GetTimeSeriesHandler)
+
+	r.Route("/simulations", func(r chi.Router) {
+		r.Post("/drift", s.PostDriftSimulationHandler)
+		r.Post("/backtrack", s.PostBacktrackSimulationHandler)
+	})
+
+	r.Get("/tiles/{dataset}/{z}/{x}/{y}.mvt", s.GetVectorTileHandler)
+
+	r.Route("/raster/{layer}", func(r chi.Router) {
+		r.Get("/{z}/{x}/{y}.png", s.GetRasterTileHandler)
+		r.Get("/legend", s.GetRasterLegendHandler)
+	})
+
+	r.Route("/edr", func(r chi.Router) {
+		r.Get("/", s.GetEDRLandingHandler)
+		r.Get("/conformance", s.GetEDRConformanceHandler)
+		r.Get("/collections", s.GetEDRCollectionsHandler)
+		r.Route("/collections/{collectionId}", func(r chi.Router) {
+			r.Get("/", s.GetEDRCollectionHandler)
+			for _, queryType := range edrQueryTypes {
+				r.Get("/"+queryType, s.GetEDRDataHandler(queryType))
+			}
+		})
 	})
 
 	r.Get("/health", s.healthHandler)
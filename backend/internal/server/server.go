@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -45,7 +46,12 @@ func NewServer() (*http.Server, database.Service) {
 }
 
 func (s *Server) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+	s.respondWithMediaType(w, code, "application/json", payload)
+}
+
+// respondWithMediaType responds with payload encoded as JSON, for JSON based formats with their own media type.
+func (s *Server) respondWithMediaType(w http.ResponseWriter, code int, mediaType string, payload interface{}) {
+	w.Header().Set("Content-Type", mediaType)
 	w.WriteHeader(code)
 
 	jsonResp, err := json.Marshal(payload)
@@ -61,6 +67,23 @@ func (s *Server) respondWithJSON(w http.ResponseWriter, code int, payload interf
 	}
 }
 
-func (s *Server) respondWithError(w http.ResponseWriter, code int, message string) {
-	s.respondWithJSON(w, code, map[string]string{"error": message})
+// errorResponse is the body of every error response, details list the rejected fields of invalid requests.
+type errorResponse struct {
+	Error   string       `json:"error"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func (s *Server) respondWithError(w http.ResponseWriter, code int, message string, details ...FieldError) {
+	s.respondWithJSON(w, code, errorResponse{Error: message, Details: details})
+}
+
+// respondWithValidationError responds with 400 and the rejected fields if err is a
+// *ValidationError, and with 500 otherwise.
+func (s *Server) respondWithValidationError(w http.ResponseWriter, err error) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		s.respondWithError(w, http.StatusBadRequest, validationErr.Message, validationErr.Fields...)
+		return
+	}
+	s.respondWithError(w, http.StatusInternalServerError, err.Error())
 }
@@ -0,0 +1,237 @@
+// Package climatology keeps the per-cell, per-day-of-year statistics of the datasets up to
+// date and imports them from long-term archives.
+package climatology
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+type Builder struct {
+	db     database.Service
+	logger *slog.Logger
+}
+
+func NewBuilder(db database.Service, logger *slog.Logger) *Builder {
+	return &Builder{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RunClimatologyUpdate adds every measurement time of the dataset after the last one added
+// to its climatology, except the newest: the updater downloads it again with the next one,
+// filling in late and revised values, and a time can't be taken out once added. It stops at
+// the first failure so the failed time is retried by the next run.
+func (b *Builder) RunClimatologyUpdate(ctx context.Context, ds *datasets.Dataset) error {
+	timestamps, err := b.db.GetAllDatasetTimestamps(ctx, ds)
+	if err != nil {
+		return err
+	}
+	last, err := b.db.GetLatestClimatologyUpdate(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	if len(timestamps) > 0 {
+		timestamps = timestamps[:len(timestamps)-1]
+	}
+
+	var updated, samples int
+	for _, t := range timestamps {
+		if !t.After(last) {
+			continue
+		}
+		n, err := b.db.AccumulateClimatology(ctx, ds, t)
+		if err != nil {
+			return err
+		}
+		updated++
+		samples += n
+	}
+	if updated > 0 {
+		b.logger.Info("Updated climatology", "dataset", ds.Name, "times", updated, "samples", samples)
+	}
+	return nil
+}
+
+// Import adds the observations of a CSV archive to the climatology of the dataset and
+// returns the number of rows read, see readArchive for the format. Rows of measurement times
+// already added to the climatology, by the updater or an earlier import, are skipped, so
+// importing an archive again adds nothing.
+func (b *Builder) Import(ctx context.Context, ds *datasets.Dataset, r io.Reader) (int, error) {
+	added, err := b.db.GetClimatologyTimes(ctx, ds)
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[int64]bool, len(added))
+	for _, t := range added {
+		skip[t.UnixNano()] = true
+	}
+
+	a, err := readArchive(ds, r, skip)
+	if err != nil {
+		return a.rows, err
+	}
+	if a.skipped > 0 {
+		b.logger.Warn("Skipped archive rows of times already in the climatology", "dataset", ds.Name, "rows", a.skipped)
+	}
+	if len(a.times) == 0 {
+		b.logger.Info("Archive adds nothing to the climatology", "dataset", ds.Name, "rows", a.rows)
+		return a.rows, nil
+	}
+	if err := b.db.AddClimatology(ctx, ds, a.times, a.sums); err != nil {
+		return a.rows, err
+	}
+	b.logger.Info("Imported climatology", "dataset", ds.Name, "rows", a.rows, "times", len(a.times), "cells", len(a.sums))
+	return a.rows, nil
+}
+
+// archive holds the observations of a CSV archive summed per variable, grid cell and day of
+// the year.
+type archive struct {
+	sums []models.ClimatologySums
+	// times of the summed observations with the number of values of each
+	times []models.ClimatologyTime
+	rows  int
+	// skipped rows, of times already added to the climatology
+	skipped int
+}
+
+// timeLayouts are the forms accepted for the times of an archive.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// readArchive sums the observations of a CSV archive per variable, grid cell and day of the
+// year. The header names the time column (time or measurement_time), latitude, longitude
+// and any of the dataset variables by their ERDDAP name or their column, other columns are
+// ignored. Units in parentheses after the names, as in ERDDAP .csvp files, are dropped.
+// Empty and NaN values are skipped, as are the rows of the measurement times in skip, keyed
+// by their Unix nanoseconds.
+func readArchive(ds *datasets.Dataset, r io.Reader, skip map[int64]bool) (archive, error) {
+	var a archive
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return a, fmt.Errorf("error reading archive header: %w", err)
+	}
+
+	// variables are named after their ERDDAP variable or the column they are stored in
+	variables := make(map[string]string, 2*len(ds.Variables))
+	for _, v := range ds.Variables {
+		variables[v.Name], variables[v.Column] = v.Column, v.Column
+	}
+
+	timeCol, latCol, lonCol := -1, -1, -1
+	columns := make(map[int]string)
+	for i, name := range header {
+		if j := strings.Index(name, " ("); j >= 0 {
+			name = name[:j]
+		}
+		switch name = strings.TrimSpace(name); {
+		case name == "time" || name == "measurement_time":
+			timeCol = i
+		case name == "latitude":
+			latCol = i
+		case name == "longitude":
+			lonCol = i
+		case variables[name] != "":
+			columns[i] = variables[name]
+		}
+	}
+	if timeCol < 0 || latCol < 0 || lonCol < 0 {
+		return a, errors.New("archive header must name the time, latitude and longitude columns")
+	}
+	if len(columns) == 0 {
+		return a, fmt.Errorf("archive holds none of the %s columns %s", ds.Name, strings.Join(ds.Columns(), ", "))
+	}
+
+	type key struct {
+		column   string
+		day      int
+		lat, lon float64
+	}
+	sums := make(map[key]*models.ClimatologySums)
+	samples := make(map[time.Time]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		a.rows++
+		if err != nil {
+			return a, fmt.Errorf("error reading archive row %d: %w", a.rows, err)
+		}
+		t, ok := parseTime(record[timeCol])
+		if !ok {
+			return a, fmt.Errorf("archive row %d: invalid time %q", a.rows, record[timeCol])
+		}
+		t = t.UTC()
+		if skip[t.UnixNano()] {
+			a.skipped++
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(record[latCol], 64)
+		lon, lonErr := strconv.ParseFloat(record[lonCol], 64)
+		if latErr != nil || lonErr != nil {
+			return a, fmt.Errorf("archive row %d: invalid coordinates %q, %q", a.rows, record[latCol], record[lonCol])
+		}
+		lat, lon = models.ClimatologyCoordinate(lat), models.ClimatologyCoordinate(lon)
+		day := models.DayOfYear(t)
+
+		for i, column := range columns {
+			raw := strings.TrimSpace(record[i])
+			if raw == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return a, fmt.Errorf("archive row %d: invalid %s %q", a.rows, column, raw)
+			}
+			if math.IsNaN(v) || math.IsInf(v, 0) || v == ds.FillValue {
+				continue
+			}
+			k := key{column, day, lat, lon}
+			s, ok := sums[k]
+			if !ok {
+				s = &models.ClimatologySums{Variable: column, DayOfYear: day, Latitude: lat, Longitude: lon}
+				sums[k] = s
+			}
+			s.Add(v)
+			samples[t]++
+		}
+	}
+
+	a.sums = make([]models.ClimatologySums, 0, len(sums))
+	for _, s := range sums {
+		a.sums = append(a.sums, *s)
+	}
+	a.times = make([]models.ClimatologyTime, 0, len(samples))
+	for t, n := range samples {
+		a.times = append(a.times, models.ClimatologyTime{MeasurementTime: t, Samples: n})
+	}
+	sort.Slice(a.times, func(i, j int) bool { return a.times[i].MeasurementTime.Before(a.times[j].MeasurementTime) })
+	return a, nil
+}
+
+func parseTime(raw string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(raw)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,208 @@
+package climatology
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+func TestReadArchive(t *testing.T) {
+	ds, _ := datasets.Get("chlorophyll")
+	archive := `time (UTC),latitude (degrees_north),longitude (degrees_east),chlor_a (mg m-3),quality
+2020-03-01T12:00:00Z,41.0375,2.1,1.5,good
+2021-03-01T12:00:00Z,41.0375,2.1,2.5,good
+2020-02-29T12:00:00Z,41.0375,2.1,0.5,good
+2019-03-01,41.0375,2.1000000001,NaN,bad
+2019-03-01,41.0375,2.1,,bad
+2019-03-02T00:00:00Z,41.0375,2.1,4,good
+`
+	// March 1 of 2021 was already added
+	skip := map[int64]bool{time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC).UnixNano(): true}
+	a, err := readArchive(ds, strings.NewReader(archive), skip)
+	if err != nil {
+		t.Fatalf("readArchive() returned error: %v", err)
+	}
+	if a.rows != 6 || a.skipped != 1 {
+		t.Errorf("expected 6 rows with 1 skipped, got %d and %d", a.rows, a.skipped)
+	}
+	sums := a.sums
+	sort.Slice(sums, func(i, j int) bool { return sums[i].DayOfYear < sums[j].DayOfYear })
+	want := []models.ClimatologySums{
+		// February 29 shares the day of February 28
+		{Variable: "chlor_a", DayOfYear: 59, Latitude: 41.0375, Longitude: 2.1, Samples: 1, Sum: 0.5, SumSquares: 0.25},
+		// March 1 of leap and other years
+		{Variable: "chlor_a", DayOfYear: 60, Latitude: 41.0375, Longitude: 2.1, Samples: 1, Sum: 1.5, SumSquares: 2.25},
+		{Variable: "chlor_a", DayOfYear: 61, Latitude: 41.0375, Longitude: 2.1, Samples: 1, Sum: 4, SumSquares: 16},
+	}
+	if len(sums) != len(want) {
+		t.Fatalf("expected %d cells, got %+v", len(want), sums)
+	}
+	for i := range want {
+		if sums[i] != want[i] {
+			t.Errorf("expected %+v, got %+v", want[i], sums[i])
+		}
+	}
+	// times without a valid value add nothing
+	wantTimes := []models.ClimatologyTime{
+		{MeasurementTime: time.Date(2020, 2, 29, 12, 0, 0, 0, time.UTC), Samples: 1},
+		{MeasurementTime: time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC), Samples: 1},
+		{MeasurementTime: time.Date(2019, 3, 2, 0, 0, 0, 0, time.UTC), Samples: 1},
+	}
+	sort.Slice(wantTimes, func(i, j int) bool { return wantTimes[i].MeasurementTime.Before(wantTimes[j].MeasurementTime) })
+	if !reflect.DeepEqual(a.times, wantTimes) {
+		t.Errorf("expected times %v, got %v", wantTimes, a.times)
+	}
+
+	tests := []struct {
+		name    string
+		archive string
+	}{
+		{"Missing coordinates", "time,chlor_a\n2020-01-01,1\n"},
+		{"No dataset columns", "time,latitude,longitude,sst\n2020-01-01,41,2,15\n"},
+		{"Invalid time", "time,latitude,longitude,chlor_a\nyesterday,41,2,1\n"},
+		{"Invalid value", "time,latitude,longitude,chlor_a\n2020-01-01,41,2,high\n"},
+		{"Empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readArchive(ds, strings.NewReader(tt.archive), nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadArchiveFillValue(t *testing.T) {
+	ds, _ := datasets.Get("sst")
+	archive := "measurement_time,latitude,longitude,sst\n2020-07-01T00:00:00Z,41,2,-327.68\n2020-07-01T00:00:00Z,41,2.05,24.5\n"
+	a, err := readArchive(ds, strings.NewReader(archive), nil)
+	if err != nil {
+		t.Fatalf("readArchive() returned error: %v", err)
+	}
+	if sums := a.sums; len(sums) != 1 || sums[0].Longitude != 2.05 || sums[0].Samples != 1 {
+		t.Errorf("expected the fill value to be skipped, got %+v", sums)
+	}
+}
+
+func TestReadArchiveERDDAPNames(t *testing.T) {
+	ds, _ := datasets.Get("sst")
+	// ERDDAP names the variable stored in the sst column analysed_sst
+	archive := "time (UTC),latitude (degrees_north),longitude (degrees_east),analysed_sst (degree_C)\n2020-07-01T09:00:00Z,41,2,24.5\n"
+	a, err := readArchive(ds, strings.NewReader(archive), nil)
+	if err != nil {
+		t.Fatalf("readArchive() returned error: %v", err)
+	}
+	if len(a.sums) != 1 || a.sums[0].Variable != "sst" || a.sums[0].Sum != 24.5 {
+		t.Errorf("expected analysed_sst to be summed as sst, got %+v", a.sums)
+	}
+}
+
+// fakeDB records the measurement times added to the climatology, the remaining methods panic.
+type fakeDB struct {
+	database.Service
+	timestamps []time.Time
+	latest     time.Time
+	added      []time.Time
+	imported   []models.ClimatologyTime
+}
+
+func (f *fakeDB) GetClimatologyTimes(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error) {
+	times := append([]time.Time{}, f.added...)
+	for _, t := range f.imported {
+		times = append(times, t.MeasurementTime)
+	}
+	return times, nil
+}
+
+func (f *fakeDB) AddClimatology(ctx context.Context, ds *datasets.Dataset, times []models.ClimatologyTime, sums []models.ClimatologySums) error {
+	f.imported = append(f.imported, times...)
+	return nil
+}
+
+func (f *fakeDB) GetAllDatasetTimestamps(ctx context.Context, ds *datasets.Dataset) ([]time.Time, error) {
+	return f.timestamps, nil
+}
+
+func (f *fakeDB) GetLatestClimatologyUpdate(ctx context.Context, ds *datasets.Dataset) (time.Time, error) {
+	return f.latest, nil
+}
+
+func (f *fakeDB) AccumulateClimatology(ctx context.Context, ds *datasets.Dataset, measurementTime time.Time) (int, error) {
+	f.added = append(f.added, measurementTime)
+	return 10, nil
+}
+
+func TestRunClimatologyUpdate(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	db := &fakeDB{timestamps: []time.Time{day(1), day(2), day(3), day(4)}, latest: day(2)}
+	ds, _ := datasets.Get("chlorophyll")
+
+	b := NewBuilder(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := b.RunClimatologyUpdate(context.Background(), ds); err != nil {
+		t.Fatalf("RunClimatologyUpdate() returned error: %v", err)
+	}
+	// the newest time is downloaded again by the next update
+	if len(db.added) != 1 || !db.added[0].Equal(day(3)) {
+		t.Errorf("expected the times after the last update but the newest to be added, got %v", db.added)
+	}
+
+	db.added = nil
+	db.timestamps = db.timestamps[:1]
+	if err := b.RunClimatologyUpdate(context.Background(), ds); err != nil || len(db.added) != 0 {
+		t.Errorf("expected a single time not to be added, got %v, %v", db.added, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	ds, _ := datasets.Get("chlorophyll")
+	// the updater added the first day, pgx returns times in the local zone
+	db := &fakeDB{added: []time.Time{time.Date(2025, 1, 1, 13, 0, 0, 0, time.FixedZone("CET", 3600))}}
+	b := NewBuilder(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	archive := "time,latitude,longitude,chlor_a\n2025-01-01T12:00:00Z,41,2,1\n2025-01-02T12:00:00Z,41,2,2\n"
+
+	for i := 0; i < 2; i++ {
+		if rows, err := b.Import(context.Background(), ds, strings.NewReader(archive)); err != nil || rows != 2 {
+			t.Fatalf("Import() returned %d rows, %v", rows, err)
+		}
+	}
+	// importing the archive again adds nothing
+	want := []models.ClimatologyTime{{MeasurementTime: time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC), Samples: 1}}
+	if !reflect.DeepEqual(db.imported, want) {
+		t.Errorf("expected only the second day to be imported once, got %v", db.imported)
+	}
+}
+
+func TestDayOfYear(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		want int
+	}{
+		{time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC), 365},
+		{time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), 59},
+		{time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), 59},
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 60},
+		{time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), 365},
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 60},
+		// times are compared in UTC
+		{time.Date(2023, 1, 2, 0, 30, 0, 0, time.FixedZone("CET", 3600)), 1},
+	}
+	for _, tt := range tests {
+		if got := models.DayOfYear(tt.time); got != tt.want {
+			t.Errorf("DayOfYear(%v) = %d, want %d", tt.time, got, tt.want)
+		}
+	}
+	if math.Abs(models.ClimatologyCoordinate(2.1000000001)-2.1) != 0 {
+		t.Errorf("expected coordinates to be rounded to the grid")
+	}
+}
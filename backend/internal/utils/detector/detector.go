@@ -0,0 +1,269 @@
+// Package detector finds algal blooms in the chlorophyll data after every update and
+// records them as events.
+package detector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+const (
+	// chlorophyllColumn holds the concentrations the rules are evaluated on
+	chlorophyllColumn = "chlor_a"
+	// maxDetectionBackfill limits how far back a first detection run goes
+	maxDetectionBackfill = 30 * 24 * time.Hour
+	// earthRadiusKm is the mean radius of the Earth, used for the area of grid cells
+	earthRadiusKm = 6371.0088
+)
+
+type Detector struct {
+	db     database.Service
+	logger *slog.Logger
+	config Config
+}
+
+func NewDetector(db database.Service, logger *slog.Logger, config Config) *Detector {
+	return &Detector{
+		db:     db,
+		logger: logger,
+		config: config,
+	}
+}
+
+// RunBloomDetection evaluates the rules at every chlorophyll measurement time after the last
+// one evaluated and returns the bloom events it saved. It stops at the first failure so a
+// measurement time that couldn't be evaluated isn't skipped by later runs.
+func (d *Detector) RunBloomDetection(ctx context.Context) ([]models.BloomEvent, error) {
+	ds, ok := datasets.Get("chlorophyll")
+	if !ok {
+		return nil, fmt.Errorf("chlorophyll dataset is not registered")
+	}
+	timestamps, err := d.db.GetAllDatasetTimestamps(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(timestamps) == 0 {
+		return nil, nil
+	}
+	last, err := d.db.GetLatestBloomDetection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	earliest := timestamps[len(timestamps)-1].Add(-maxDetectionBackfill)
+	land := d.landLocations(ctx, ds)
+
+	var detected []models.BloomEvent
+	for _, t := range timestamps {
+		if !t.After(last) || t.Before(earliest) {
+			continue
+		}
+		grid, err := d.db.GetDatasetDataAtTimestamp(ctx, ds, t)
+		if err != nil {
+			return detected, err
+		}
+		climatology, err := d.loadClimatology(ctx, ds, t)
+		if err != nil {
+			return detected, err
+		}
+
+		events := detectBlooms(grid, ds.ColumnIndex(chlorophyllColumn), land, climatology, d.config.Rules)
+		for i := range events {
+			events[i].MeasurementTime = t
+		}
+		saved, err := d.db.SaveBloomEvents(ctx, t, events)
+		if err != nil {
+			return detected, err
+		}
+		if len(saved) > 0 {
+			d.logger.Info("Detected blooms", "time", t, "events", len(saved))
+		}
+		detected = append(detected, saved...)
+	}
+	return detected, nil
+}
+
+// loadClimatology returns the chlorophyll climatology of the day of t. Without one the
+// anomaly rules flag no cells, so only the threshold rules are evaluated.
+func (d *Detector) loadClimatology(ctx context.Context, ds *datasets.Dataset, t time.Time) (*models.Climatology, error) {
+	// the climatology only holds cells of the dataset, so there's no need to narrow the area
+	cells, err := d.db.GetClimatology(ctx, ds, []int{models.DayOfYear(t)}, d.config.ClimatologyWindowDays, -90, -180, 90, 180)
+	if err != nil {
+		return nil, err
+	}
+	if len(cells) == 0 && d.config.usesAnomalies() {
+		d.logger.Warn("No chlorophyll climatology, evaluating only the threshold rules", "time", t)
+	}
+	return models.NewClimatology(ds, cells), nil
+}
+
+// landLocations returns the chlorophyll cells on land, which are never flagged. Without
+// them every cell is evaluated, as land cells hold no values anyway.
+func (d *Detector) landLocations(ctx context.Context, ds *datasets.Dataset) map[orb.Point]bool {
+	points, err := d.db.GetDatasetLandLocations(ctx, ds)
+	if err != nil {
+		d.logger.Warn("could not get land locations, evaluating every cell", "dataset", ds.Name, "err", err)
+		return nil
+	}
+	land := make(map[orb.Point]bool, len(points))
+	for _, p := range points {
+		land[p] = true
+	}
+	return land
+}
+
+// detectBlooms returns an event for every 8-connected patch of cells a rule flags in the
+// grid, ordered by rule, that is at least as large as the minimum area of the rule. index
+// is the position of the chlorophyll values, their z-scores are computed against the
+// climatology, if any.
+func detectBlooms(grid [][]models.GridData, index int, land map[orb.Point]bool, climatology *models.Climatology, rules []Rule) []models.BloomEvent {
+	lats, lons := gridCoordinates(grid)
+	dLat, dLon := spacing(lats), spacing(lons)
+	if dLat == 0 {
+		dLat = dLon
+	}
+	if dLon == 0 {
+		dLon = dLat
+	}
+	if dLat == 0 {
+		// a single cell has no extent to measure
+		return nil
+	}
+
+	// value and z-score of every cell that can be flagged, z-scores are NaN without a usable climatology
+	values := make([][]float64, len(grid))
+	zScores := make([][]float64, len(grid))
+	for row := range grid {
+		values[row] = make([]float64, len(grid[row]))
+		zScores[row] = make([]float64, len(grid[row]))
+		for col, d := range grid[row] {
+			values[row][col], zScores[row][col] = math.NaN(), math.NaN()
+			p := orb.Point{lons[col], lats[row]}
+			if d.MeasurementTime.IsZero() || land[p] {
+				continue
+			}
+			values[row][col] = float64(d.Values[index])
+			if climatology != nil {
+				_, zScores[row][col] = climatology.Anomaly(d, index)
+			}
+		}
+	}
+
+	var events []models.BloomEvent
+	for _, rule := range rules {
+		flagged := func(row, col int) bool {
+			v, z := values[row][col], zScores[row][col]
+			switch {
+			case math.IsNaN(v):
+				return false
+			case rule.Threshold > 0 && v < rule.Threshold:
+				return false
+			case rule.AnomalyStd > 0 && (math.IsNaN(z) || z < rule.AnomalyStd):
+				return false
+			}
+			return true
+		}
+		for _, patch := range findPatches(len(grid), len(lons), flagged) {
+			e := models.BloomEvent{Rule: rule.Name, Cells: len(patch), MaxChlorA: math.Inf(-1)}
+			maxZ := math.NaN()
+			var sum float64
+			for _, c := range patch {
+				lat, lon, v := lats[c[0]], lons[c[1]], values[c[0]][c[1]]
+				e.AreaKm2 += cellArea(lat, dLat, dLon)
+				e.Geometry = append(e.Geometry, orb.Bound{
+					Min: orb.Point{lon - dLon/2, lat - dLat/2},
+					Max: orb.Point{lon + dLon/2, lat + dLat/2},
+				}.ToPolygon())
+				sum += v
+				e.MaxChlorA = max(e.MaxChlorA, v)
+				if z := zScores[c[0]][c[1]]; !math.IsNaN(z) && (math.IsNaN(maxZ) || z > maxZ) {
+					maxZ = z
+				}
+			}
+			if e.AreaKm2 < rule.MinAreaKm2 {
+				continue
+			}
+			e.MeanChlorA = sum / float64(len(patch))
+			if !math.IsNaN(maxZ) {
+				e.MaxZScore = &maxZ
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// findPatches returns the 8-connected groups of flagged cells of a grid of rows and cols,
+// as row and column indexes.
+func findPatches(rows, cols int, flagged func(row, col int) bool) [][][2]int {
+	visited := make([][]bool, rows)
+	for i := range visited {
+		visited[i] = make([]bool, cols)
+	}
+	var patches [][][2]int
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if visited[r][c] || !flagged(r, c) {
+				continue
+			}
+			visited[r][c] = true
+			patch := [][2]int{{r, c}}
+			for i := 0; i < len(patch); i++ {
+				for dr := -1; dr <= 1; dr++ {
+					for dc := -1; dc <= 1; dc++ {
+						nR, nC := patch[i][0]+dr, patch[i][1]+dc
+						if nR < 0 || nR >= rows || nC < 0 || nC >= cols || visited[nR][nC] || !flagged(nR, nC) {
+							continue
+						}
+						visited[nR][nC] = true
+						patch = append(patch, [2]int{nR, nC})
+					}
+				}
+			}
+			patches = append(patches, patch)
+		}
+	}
+	return patches
+}
+
+// gridCoordinates returns the latitude of every row and the longitude of every column of a
+// grid, read from the cells holding data.
+func gridCoordinates(grid [][]models.GridData) (lats, lons []float64) {
+	if len(grid) == 0 {
+		return nil, nil
+	}
+	lats, lons = make([]float64, len(grid)), make([]float64, len(grid[0]))
+	for row := range grid {
+		for col, d := range grid[row] {
+			if !d.MeasurementTime.IsZero() {
+				lats[row], lons[col] = d.Latitude, d.Longitude
+			}
+		}
+	}
+	return lats, lons
+}
+
+// spacing returns the smallest distance between consecutive sorted coordinates, 0 if there is a single one.
+func spacing(coords []float64) float64 {
+	var s float64
+	for i := 1; i < len(coords); i++ {
+		if d := math.Abs(coords[i] - coords[i-1]); d > 0 && (s == 0 || d < s) {
+			s = d
+		}
+	}
+	return s
+}
+
+// cellArea returns the area in km² of a grid cell of dLat by dLon degrees centered at lat.
+func cellArea(lat, dLat, dLon float64) float64 {
+	rad := math.Pi / 180
+	return earthRadiusKm * earthRadiusKm * dLat * rad * dLon * rad * math.Cos(lat*rad)
+}
@@ -0,0 +1,124 @@
+package detector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+// newGrid returns a grid of chlorophyll values on a 0.01° grid with its first row at 41.04 N
+// and first column at 2 E, laid out like GetDatasetDataAtTimestamp. The cells listed in
+// empty hold no data, like cells the database has no row for.
+func newGrid(values [][]float64, empty ...[2]int) [][]models.GridData {
+	measured := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	grid := make([][]models.GridData, len(values))
+	for row := range values {
+		grid[row] = make([]models.GridData, len(values[row]))
+		for col, v := range values[row] {
+			grid[row][col] = models.GridData{
+				MeasurementTime: measured,
+				Latitude:        math.Round((41.04-float64(row)*0.01)*100) / 100,
+				Longitude:       math.Round((2+float64(col)*0.01)*100) / 100,
+				Values:          []float32{float32(v)},
+			}
+		}
+	}
+	for _, e := range empty {
+		grid[e[0]][e[1]] = models.GridData{Values: []float32{0}}
+	}
+	return grid
+}
+
+func Test_detectBlooms(t *testing.T) {
+	nan := math.NaN()
+	grid := newGrid([][]float64{
+		{4, 5, 0.2, 0.2},
+		{3, 6, 0.2, 0.2},
+		{0.2, 0.2, nan, 0.2},
+		{0.2, 0.2, 0.2, 3.5},
+	})
+	// about 0.93 km² per cell at 41 N
+	rule := Rule{Name: "high", Threshold: 3, MinAreaKm2: 2}
+
+	events := detectBlooms(grid, 0, nil, nil, []Rule{rule})
+	if len(events) != 1 {
+		t.Fatalf("expected the single cell to be too small; got %d events", len(events))
+	}
+	e := events[0]
+	if e.Rule != "high" || e.Cells != 4 || e.MeanChlorA != 4.5 || e.MaxChlorA != 6 || e.MaxZScore != nil {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if math.Abs(e.AreaKm2-4*0.9332) > 0.01 {
+		t.Errorf("expected an area of about 3.73 km²; got %g", e.AreaKm2)
+	}
+	if bound := e.Geometry.Bound(); math.Abs(bound.Min.Lon()-1.995) > 1e-9 || math.Abs(bound.Max.Lat()-41.045) > 1e-9 {
+		t.Errorf("expected the outline to cover the cells; got %v", bound)
+	}
+
+	t.Run("Diagonal neighbours and land", func(t *testing.T) {
+		grid := newGrid([][]float64{
+			{4, 0.2, 0.2},
+			{0.2, 4, 0.2},
+			{0.2, 0.2, 4},
+		}, [2]int{0, 2})
+		land := map[orb.Point]bool{{2.02, 41.02}: true}
+		events := detectBlooms(grid, 0, land, nil, []Rule{{Name: "high", Threshold: 3}})
+		if len(events) != 1 || events[0].Cells != 2 {
+			t.Errorf("expected a diagonal patch of 2 cells without the land cell; got %+v", events)
+		}
+	})
+
+	t.Run("Anomalies", func(t *testing.T) {
+		grid := newGrid([][]float64{
+			{2.5, 2.5},
+			{1.2, 2.5},
+		})
+		var cells []models.ClimatologyCell
+		for _, row := range grid {
+			for _, d := range row {
+				cells = append(cells, models.ClimatologyCell{Variable: "chlor_a", DayOfYear: 1, Latitude: d.Latitude, Longitude: d.Longitude, Samples: 20, Mean: 1, Std: 0.5})
+			}
+		}
+		// the top right cell has too short a history to tell an anomaly
+		cells[1].Samples = models.MinClimatologySamples - 1
+		// the climatology of another day doesn't apply
+		cells = append(cells, models.ClimatologyCell{Variable: "chlor_a", DayOfYear: 2, Latitude: 41.03, Longitude: 2, Samples: 20, Mean: 0, Std: 0.1})
+		ds, _ := datasets.Get("chlorophyll")
+
+		events := detectBlooms(grid, 0, nil, models.NewClimatology(ds, cells), []Rule{{Name: "anomaly", AnomalyStd: 2}, {Name: "high", Threshold: 2}})
+		var got []int
+		for _, e := range events {
+			got = append(got, e.Cells)
+		}
+		if !reflect.DeepEqual(got, []int{2, 3}) {
+			t.Fatalf("expected an anomaly of 2 cells and a high patch of 3; got %v", got)
+		}
+		if z := events[0].MaxZScore; z == nil || *z != 3 {
+			t.Errorf("expected a z-score of 3; got %v", z)
+		}
+	})
+}
+
+func Test_findPatches(t *testing.T) {
+	flags := [][]bool{
+		{true, false, false, true},
+		{false, true, false, true},
+		{false, false, false, false},
+		{true, true, false, false},
+	}
+	patches := findPatches(4, 4, func(row, col int) bool { return flags[row][col] })
+	want := [][][2]int{
+		{{0, 0}, {1, 1}},
+		{{0, 3}, {1, 3}},
+		{{3, 0}, {3, 1}},
+	}
+	if !reflect.DeepEqual(patches, want) {
+		t.Errorf("expected patches %v; got %v", want, patches)
+	}
+}
@@ -0,0 +1,89 @@
+package detector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"ocean-digital-twin/internal/database/models"
+)
+
+// Config holds the bloom detection rules.
+type Config struct {
+	// ClimatologyWindowDays before and after the day of a measurement are pooled into the
+	// climatology the anomaly rules compare cells with
+	ClimatologyWindowDays int    `json:"climatology_window_days"`
+	Rules                 []Rule `json:"rules"`
+}
+
+// Rule flags the chlorophyll cells of a bloom. A cell is flagged if it meets every condition
+// set, a zero disables a condition. Connected patches of flagged cells are bloom events.
+type Rule struct {
+	Name string `json:"name"`
+	// Threshold flags cells with chlor_a at or above it, in mg m-3
+	Threshold float64 `json:"threshold"`
+	// AnomalyStd flags cells at least this many standard deviations above their climatological mean
+	AnomalyStd float64 `json:"anomaly_std"`
+	// MinAreaKm2 is the smallest patch reported as an event, leaving out single noisy cells
+	MinAreaKm2 float64 `json:"min_area_km2"`
+}
+
+// DefaultConfig returns the rules used without a rules file: high concentrations, and
+// unusual concentrations that aren't negligible.
+func DefaultConfig() Config {
+	return Config{
+		ClimatologyWindowDays: models.ClimatologyWindowDays,
+		Rules: []Rule{
+			{Name: "high_chlorophyll", Threshold: 3, MinAreaKm2: 20},
+			{Name: "chlorophyll_anomaly", Threshold: 1, AnomalyStd: 2, MinAreaKm2: 20},
+		},
+	}
+}
+
+// LoadConfig reads the rules from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading bloom rules file: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("error parsing bloom rules: %w", err)
+	}
+	if c.ClimatologyWindowDays == 0 {
+		c.ClimatologyWindowDays = DefaultConfig().ClimatologyWindowDays
+	}
+	return c, c.Validate()
+}
+
+// Validate checks that every rule is named uniquely and sets a condition.
+func (c Config) Validate() error {
+	if c.ClimatologyWindowDays < 1 || c.ClimatologyWindowDays > 182 {
+		return fmt.Errorf("climatology_window_days must be between 1 and 182")
+	}
+	names := make(map[string]bool)
+	for i, r := range c.Rules {
+		switch {
+		case r.Name == "":
+			return fmt.Errorf("rule %d has no name", i)
+		case names[r.Name]:
+			return fmt.Errorf("rule %s is defined twice", r.Name)
+		case r.Threshold <= 0 && r.AnomalyStd <= 0:
+			return fmt.Errorf("rule %s needs a positive threshold or anomaly_std", r.Name)
+		case r.Threshold < 0 || r.AnomalyStd < 0 || r.MinAreaKm2 < 0:
+			return fmt.Errorf("rule %s has a negative condition", r.Name)
+		}
+		names[r.Name] = true
+	}
+	return nil
+}
+
+// usesAnomalies reports whether a rule compares cells with the climatology.
+func (c Config) usesAnomalies() bool {
+	for _, r := range c.Rules {
+		if r.AnomalyStd > 0 {
+			return true
+		}
+	}
+	return false
+}
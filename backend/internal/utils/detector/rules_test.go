@@ -0,0 +1,47 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr bool
+	}{
+		{name: "Threshold rule", rules: `{"rules": [{"name": "high", "threshold": 5, "min_area_km2": 10}]}`},
+		{name: "No condition", rules: `{"rules": [{"name": "empty", "min_area_km2": 10}]}`, wantErr: true},
+		{name: "Duplicate names", rules: `{"rules": [{"name": "high", "threshold": 5}, {"name": "high", "anomaly_std": 2}]}`, wantErr: true},
+		{name: "Invalid JSON", rules: `{"rules": [`, wantErr: true},
+		{name: "Window too wide", rules: `{"climatology_window_days": 200, "rules": [{"name": "high", "threshold": 5}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.rules), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			config, err := LoadConfig(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error; got %+v", config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.ClimatologyWindowDays != DefaultConfig().ClimatologyWindowDays {
+				t.Errorf("expected the default climatology window; got %d days", config.ClimatologyWindowDays)
+			}
+		})
+	}
+
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("expected valid default rules; got %v", err)
+	}
+}
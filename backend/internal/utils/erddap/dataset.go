@@ -0,0 +1,161 @@
+package erddap
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/batchatco/go-native-netcdf/netcdf"
+)
+
+func (d *Downloader) DownloadDatasetData(ctx context.Context, ds *datasets.Dataset, startTime, endTime time.Time) ([]models.GridData, error) {
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	maxTime, err := d.GetLatestDataTime(ctx, ds.ERDDAPID)
+	if err != nil {
+		return nil, err
+	}
+	if endTime.After(maxTime) {
+		endTime = maxTime
+	}
+	url := d.buildDatasetURL(startTime, endTime, ds)
+	d.logger.Info("Downloading dataset data", "dataset", ds.Name, "url", url)
+
+	tempFile := filepath.Join(tempDir, fmt.Sprintf("%s_%s_%s.nc",
+		ds.Name, startTime.Format("20060102"), endTime.Format("20060102")))
+
+	if err := d.downloadFile(ctx, url, tempFile); err != nil {
+		return nil, err
+	}
+	defer os.Remove(tempFile)
+
+	data, err := d.processDatasetFile(ds, tempFile)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *Downloader) processDatasetFile(ds *datasets.Dataset, filePath string) ([]models.GridData, error) {
+	nc, err := netcdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening NetCDF file: %w", err)
+	}
+	defer nc.Close()
+
+	coords := make(map[string][]float64)
+	for _, dim := range []string{datasets.DimTime, datasets.DimLatitude, datasets.DimLongitude} {
+		v, err := nc.GetVariable(dim)
+		if err != nil || v == nil {
+			return nil, fmt.Errorf("error getting %s variable: %w", dim, err)
+		}
+		values, err := flattenValues(v.Values)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected type for %s values: %w", dim, err)
+		}
+		coords[dim] = values
+	}
+	times := coords[datasets.DimTime]
+	lats := coords[datasets.DimLatitude]
+	lons := coords[datasets.DimLongitude]
+
+	// strides of the time, latitude and longitude dimensions in the flattened variables,
+	// all other dimensions hold a single level
+	strides := make(map[string]int)
+	stride := 1
+	for i := len(ds.Dimensions) - 1; i >= 0; i-- {
+		strides[ds.Dimensions[i]] = stride
+		if n, ok := coords[ds.Dimensions[i]]; ok {
+			stride *= len(n)
+		}
+	}
+
+	variables := make([][]float64, len(ds.Variables))
+	for i, dv := range ds.Variables {
+		v, err := nc.GetVariable(dv.Name)
+		if err != nil || v == nil {
+			return nil, fmt.Errorf("error getting %s variable: %w", dv.Name, err)
+		}
+		values, err := flattenValues(v.Values)
+		if err != nil {
+			d.logger.Info("unexpected variable", "name", dv.Name, "dim", v.Dimensions, "att", v.Attributes)
+			return nil, fmt.Errorf("unexpected type for %s values: %w", dv.Name, err)
+		}
+		if len(values) != stride {
+			return nil, fmt.Errorf("unexpected size of %s: got %d values, expected %d", dv.Name, len(values), stride)
+		}
+		variables[i] = values
+	}
+
+	var result []models.GridData
+	for timeIdx, t := range times {
+		measurementTime := time.Unix(int64(t), 0).UTC()
+		for latIdx, lat := range lats {
+			for lonIdx, lon := range lons {
+				idx := timeIdx*strides[datasets.DimTime] +
+					latIdx*strides[datasets.DimLatitude] +
+					lonIdx*strides[datasets.DimLongitude]
+
+				values := make([]float32, len(variables))
+				for i, v := range variables {
+					if isFillValue(v[idx], ds.FillValue) {
+						values[i] = float32(math.NaN())
+					} else {
+						values[i] = float32(v[idx])
+					}
+				}
+				result = append(result, models.GridData{
+					MeasurementTime: measurementTime,
+					Latitude:        lat,
+					Longitude:       lon,
+					Values:          values,
+				})
+			}
+		}
+	}
+	d.logger.Info("Processed NetCDF file", "dataset", ds.Name, "points", len(result))
+	return result, nil
+}
+
+// isFillValue compares values at float32 precision, as variables stored as
+// float32 don't hold the exact float64 fill value.
+func isFillValue(v, fill float64) bool {
+	return float32(v) == float32(fill)
+}
+
+// flattenValues converts NetCDF variable values of any numeric type and
+// dimensionality into a flat slice in row-major order.
+func flattenValues(values interface{}) ([]float64, error) {
+	var result []float64
+	var walk func(v reflect.Value) error
+	walk = func(v reflect.Value) error {
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				if err := walk(v.Index(i)); err != nil {
+					return err
+				}
+			}
+		case reflect.Float32, reflect.Float64:
+			result = append(result, v.Float())
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			result = append(result, float64(v.Int()))
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			result = append(result, float64(v.Uint()))
+		default:
+			return fmt.Errorf("unsupported value type %s", v.Type())
+		}
+		return nil
+	}
+	if err := walk(reflect.ValueOf(values)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
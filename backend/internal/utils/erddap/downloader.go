@@ -6,14 +6,13 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"ocean-digital-twin/internal/datasets"
 	"os"
 	"strings"
 	"time"
 )
 
 const (
-	ChlorDatasetID     = "noaacwNPPVIIRSchlaDaily"
-	CurrentsDatasetID  = "noaacwBLENDEDNRTcurrentsDaily"
 	erddapBaseURL      = "https://coastwatch.noaa.gov/erddap/griddap/"
 	erddapInfoBaseURL  = "https://coastwatch.noaa.gov/erddap/info/"
 	fileType           = "nc"
@@ -43,27 +42,29 @@ func NewDownloader(logger *slog.Logger, minLat, minLon, maxLat, maxLon float64)
 	}
 }
 
-func (d *Downloader) buildURL(startTime, endTime time.Time, datasetID string) string {
+func (d *Downloader) buildDatasetURL(startTime, endTime time.Time, ds *datasets.Dataset) string {
 	startStr := startTime.Format("2006-01-02T15:04:05Z")
 	endStr := endTime.Format("2006-01-02T15:04:05Z")
 
-	query := fmt.Sprintf("chlor_a[(%s):1:(%s)][(0.0):1:(0.0)][(%.5f):1:(%.5f)][(%.5f):1:(%.5f)]",
-		startStr, endStr, d.minLat, d.maxLat, d.minLon, d.maxLon)
-
-	return fmt.Sprintf("%s/%s.%s?%s", erddapBaseURL, datasetID, fileType, query)
-}
-
-func (d *Downloader) buildURLWithVars(startTime, endTime time.Time, datasetID string, vars []string) string {
-	startStr := startTime.Format("2006-01-02T15:04:05Z")
-	endStr := endTime.Format("2006-01-02T15:04:05Z")
-	query := ""
+	constraints := ""
+	for _, dim := range ds.Dimensions {
+		switch dim {
+		case datasets.DimTime:
+			constraints += fmt.Sprintf("[(%s):1:(%s)]", startStr, endStr)
+		case datasets.DimLatitude:
+			constraints += fmt.Sprintf("[(%.5f):1:(%.5f)]", d.minLat, d.maxLat)
+		case datasets.DimLongitude:
+			constraints += fmt.Sprintf("[(%.5f):1:(%.5f)]", d.minLon, d.maxLon)
+		default:
+			constraints += "[(0.0):1:(0.0)]"
+		}
+	}
 
-	for _, v := range vars {
-		query += fmt.Sprintf("%s[(%s):1:(%s)][(%.5f):1:(%.5f)][(%.5f):1:(%.5f)],",
-			v, startStr, endStr, d.minLat, d.maxLat, d.minLon, d.maxLon)
+	vars := make([]string, len(ds.Variables))
+	for i, v := range ds.Variables {
+		vars[i] = v.Name + constraints
 	}
-	query = strings.Trim(query, ",")
-	return fmt.Sprintf("%s/%s.%s?%s", erddapBaseURL, datasetID, fileType, query)
+	return fmt.Sprintf("%s/%s.%s?%s", erddapBaseURL, ds.ERDDAPID, fileType, strings.Join(vars, ","))
 }
 
 func (d *Downloader) downloadFile(ctx context.Context, url, destPath string) error {
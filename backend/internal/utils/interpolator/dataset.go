@@ -0,0 +1,191 @@
+package interpolator
+
+import (
+	"context"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+
+	"github.com/paulmach/orb"
+)
+
+// Names of the interpolation methods stored with the values they filled.
+const (
+	methodLinear        = "linear"
+	methodBorderAverage = "border_average"
+	methodIDW           = "idw"
+	methodKriging       = "ordinary_kriging"
+)
+
+// RunDatasetInterpolation runs the interpolation strategies configured for the dataset in order.
+func (i *Interpolator) RunDatasetInterpolation(ctx context.Context, ds *datasets.Dataset) {
+	for _, strategy := range ds.Interpolation {
+		var err error
+		switch strategy {
+		case datasets.InterpolationArea:
+			err = i.RunInterpolationBasedOnArea(ctx, ds)
+		case datasets.InterpolationTime:
+			err = i.RunLinearInterpolationBasedOnTime(ctx, ds)
+		case datasets.InterpolationIDW:
+			err = i.RunIDWInterpolation(ctx, ds)
+		case datasets.InterpolationKriging:
+			err = i.RunKrigingInterpolation(ctx, ds)
+		default:
+			i.logger.Error("unknown interpolation strategy", "dataset", ds.Name, "strategy", strategy)
+		}
+		if err != nil {
+			i.logger.Error("interpolation failed", "dataset", ds.Name, "strategy", strategy, "err", err)
+		}
+	}
+}
+
+func (i *Interpolator) RunLinearInterpolationBasedOnTime(ctx context.Context, ds *datasets.Dataset) error {
+	i.logger.Info("Starting interpolation of data based on time", "dataset", ds.Name)
+
+	points, err := i.db.GetAllDatasetLocations(ctx, ds)
+	if err != nil {
+		i.logger.Error("error geting locations", "dataset", ds.Name, "err", err)
+		return err
+	}
+	i.logger.Info("Success getting location points", "count", len(points))
+	land := i.landLocations(ctx, ds)
+	for _, p := range points {
+		if land[p] {
+			continue
+		}
+		data, err := i.db.GetDatasetDataAtLocation(ctx, ds, p)
+		if err != nil {
+			i.logger.Error("error geting data at location", "dataset", ds.Name, "loc", p, "err", err)
+		}
+		for _, v := range scalarVariables(ds) {
+			interpolableDataSlice := make([]InterpolatableData, len(data))
+			for i := range data {
+				interpolableDataSlice[i] = models.GridValue{
+					Data:       &data[i],
+					Index:      v,
+					Provenance: models.ProvenanceTimeInterpolated,
+					Method:     methodLinear,
+				}
+			}
+			i.interpolateLinearyDataRow(interpolableDataSlice)
+		}
+		if ui, vi, ok := vectorIndexes(ds); ok {
+			interpolateVectorRow(data, ui, vi, models.ProvenanceTimeInterpolated, methodLinear, func(row []InterpolatableData) {
+				i.interpolateLinearyDataRow(row)
+			})
+		}
+		i.db.UpdateDatasetData(ctx, ds, data)
+	}
+	i.logger.Info("Interpolation of data based on time completed", "dataset", ds.Name)
+	return nil
+}
+
+func (i *Interpolator) RunInterpolationBasedOnArea(ctx context.Context, ds *datasets.Dataset) error {
+	i.logger.Info("Starting interpolation of data area", "dataset", ds.Name)
+	err := i.interpolateDatasetGrids(ctx, ds, methodBorderAverage, func(data [][]InterpolatableData) error {
+		i.interpolateDataArea(data)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.logger.Info("Interpolation of data based on area completed", "dataset", ds.Name)
+	return nil
+}
+
+func (i *Interpolator) RunIDWInterpolation(ctx context.Context, ds *datasets.Dataset) error {
+	power, radius := ds.IDWParameters()
+	i.logger.Info("Starting IDW interpolation of data area", "dataset", ds.Name, "power", power, "radius", radius)
+	err := i.interpolateDatasetGrids(ctx, ds, methodIDW, func(data [][]InterpolatableData) error {
+		i.interpolateDataIDW(data, power, radius)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	i.logger.Info("IDW interpolation of data area completed", "dataset", ds.Name)
+	return nil
+}
+
+func (i *Interpolator) RunKrigingInterpolation(ctx context.Context, ds *datasets.Dataset) error {
+	params := ds.KrigingParameters()
+	i.logger.Info("Starting kriging interpolation of data area", "dataset", ds.Name,
+		"model", params.Model, "max_lag", params.MaxLag, "neighbors", params.Neighbors)
+	err := i.interpolateDatasetGrids(ctx, ds, methodKriging, func(data [][]InterpolatableData) error {
+		_, err := i.interpolateDataKriging(data, params)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	i.logger.Info("Kriging interpolation of data area completed", "dataset", ds.Name)
+	return nil
+}
+
+// interpolateDatasetGrids runs fill on the grid of every variable at every timestamp
+// of the dataset and saves the results, marking filled cells with method. Grids fill
+// fails on are logged and skipped.
+func (i *Interpolator) interpolateDatasetGrids(ctx context.Context, ds *datasets.Dataset, method string, fill func([][]InterpolatableData) error) error {
+	timestamps, err := i.db.GetAllDatasetTimestamps(ctx, ds)
+	if err != nil {
+		i.logger.Error("error geting timestamps", "dataset", ds.Name, "err", err)
+		return err
+	}
+	i.logger.Info("Success getting timestamps", "count", len(timestamps))
+	land := i.landLocations(ctx, ds)
+	for _, t := range timestamps {
+		data, err := i.db.GetDatasetDataAtTimestamp(ctx, ds, t)
+		if err != nil {
+			i.logger.Error("error geting data at timestamp", "dataset", ds.Name, "time", t, "err", err)
+		}
+		for row := range data {
+			for col := range data[row] {
+				data[row][col].Land = land[orb.Point{data[row][col].Longitude, data[row][col].Latitude}]
+			}
+		}
+
+		for _, v := range scalarVariables(ds) {
+			interpolableDataSlice := make([][]InterpolatableData, len(data))
+			for row := range data {
+				interpolableDataSlice[row] = make([]InterpolatableData, len(data[row]))
+				for col := range data[row] {
+					interpolableDataSlice[row][col] = models.GridValue{
+						Data:       &data[row][col],
+						Index:      v,
+						Provenance: models.ProvenanceAreaInterpolated,
+						Method:     method,
+					}
+				}
+			}
+			if err := fill(interpolableDataSlice); err != nil {
+				i.logger.Warn("could not interpolate grid", "dataset", ds.Name, "time", t,
+					"variable", ds.Variables[v].Name, "method", method, "err", err)
+			}
+		}
+		if ui, vi, ok := vectorIndexes(ds); ok {
+			if err := interpolateVectorGrid(data, ui, vi, models.ProvenanceAreaInterpolated, method, fill); err != nil {
+				i.logger.Warn("could not interpolate vector grid", "dataset", ds.Name, "time", t,
+					"method", method, "err", err)
+			}
+		}
+		for row := range data {
+			i.db.UpdateDatasetData(ctx, ds, data[row])
+		}
+	}
+	return nil
+}
+
+// landLocations returns the locations of the dataset on land. If they can't be read the
+// data is interpolated without the land mask.
+func (i *Interpolator) landLocations(ctx context.Context, ds *datasets.Dataset) map[orb.Point]bool {
+	land := make(map[orb.Point]bool)
+	points, err := i.db.GetDatasetLandLocations(ctx, ds)
+	if err != nil {
+		i.logger.Error("error getting land locations, interpolating without land mask", "dataset", ds.Name, "err", err)
+		return land
+	}
+	for _, p := range points {
+		land[p] = true
+	}
+	i.logger.Info("Success getting land locations", "dataset", ds.Name, "count", len(land))
+	return land
+}
@@ -11,6 +11,18 @@ type InterpolatableData interface {
 	SetValue(float32)
 }
 
+// MaskedData is implemented by InterpolatableData that can be masked out, e.g. cells on land.
+// Masked cells are never filled, their values are not used and they don't count as the
+// edge of the grid, so gaps along the coast are treated like gaps surrounded by data.
+type MaskedData interface {
+	Masked() bool
+}
+
+func isMasked(d InterpolatableData) bool {
+	m, ok := d.(MaskedData)
+	return ok && m.Masked()
+}
+
 type Interpolator struct {
 	db     database.Service
 	logger *slog.Logger
@@ -63,8 +75,90 @@ func (ip *Interpolator) interpolateLinearyDataRow(data []InterpolatableData) {
 }
 
 func (ip *Interpolator) interpolateDataArea(data [][]InterpolatableData) [][]InterpolatableData {
+	for _, group := range findNaNGroups(data) {
+		// After exploring the group, check if it's surrounded and has neighbors
+		if group.isSurrounded && len(group.neighborValues) > 0 {
+			sum := float32(0.0)
+			for _, val := range group.neighborValues {
+				sum += val
+			}
+			average := sum / float32(len(group.neighborValues))
+
+			for _, coord := range group.coords {
+				data[coord[0]][coord[1]].SetValue(average)
+			}
+		}
+	}
+
+	return data
+}
+
+// interpolateDataIDW fills surrounded NaN groups like interpolateDataArea, but every NaN
+// gets its own inverse distance weighted average of the valid values within radius cells.
+// NaNs farther than radius from any valid value remain unfilled.
+func (ip *Interpolator) interpolateDataIDW(data [][]InterpolatableData, power float64, radius int) [][]InterpolatableData {
+	type fill struct {
+		row, col int
+		value    float32
+	}
+	// values are computed first so filled cells don't take part in later averages
+	var fills []fill
+	for _, group := range findNaNGroups(data) {
+		if !group.isSurrounded || len(group.neighborValues) == 0 {
+			continue
+		}
+		for _, coord := range group.coords {
+			if value, ok := idwValue(data, coord[0], coord[1], power, radius); ok {
+				fills = append(fills, fill{coord[0], coord[1], value})
+			}
+		}
+	}
+	for _, f := range fills {
+		data[f.row][f.col].SetValue(f.value)
+	}
+
+	return data
+}
+
+// idwValue returns the inverse distance weighted average of the valid values within
+// radius cells of data[r][c], or false if there are none.
+func idwValue(data [][]InterpolatableData, r, c int, power float64, radius int) (float32, bool) {
+	var weightedSum, weights float64
+	for nR := max(r-radius, 0); nR <= min(r+radius, len(data)-1); nR++ {
+		for nC := max(c-radius, 0); nC <= min(c+radius, len(data[nR])-1); nC++ {
+			val := float64(data[nR][nC].Value())
+			if math.IsNaN(val) || isMasked(data[nR][nC]) {
+				continue
+			}
+			distance := math.Hypot(float64(nR-r), float64(nC-c))
+			if distance > float64(radius) {
+				continue
+			}
+			weight := 1 / math.Pow(distance, power)
+			weightedSum += weight * val
+			weights += weight
+		}
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return float32(weightedSum / weights), true
+}
+
+// nanGroup is a contiguous group of NaN cells of a grid.
+type nanGroup struct {
+	coords [][2]int
+	// valid values adjacent to any cell of the group
+	neighborValues []float32
+	// false if any cell of the group is on the edge of the grid
+	isSurrounded bool
+}
+
+// findNaNGroups returns the contiguous (including diagonals) groups of NaN cells of data.
+// Masked cells are skipped.
+func findNaNGroups(data [][]InterpolatableData) []nanGroup {
 	if len(data) == 0 || len(data[0]) == 0 {
-		return data
+		return nil
 	}
 
 	rows := len(data)
@@ -79,18 +173,17 @@ func (ip *Interpolator) interpolateDataArea(data [][]InterpolatableData) [][]Int
 	dr := []int{-1, -1, -1, 0, 0, 1, 1, 1}
 	dc := []int{-1, 0, 1, -1, 1, -1, 0, 1}
 
+	var groups []nanGroup
 	for r := 0; r < rows; r++ {
 		for c := 0; c < cols; c++ {
-			if math.IsNaN(float64(data[r][c].Value())) && !visited[r][c] {
+			if math.IsNaN(float64(data[r][c].Value())) && !visited[r][c] && !isMasked(data[r][c]) {
 				// Found an unvisited NaN, start exploring the group
-				groupCoords := make([][2]int, 0)
-				neighborValues := make([]float32, 0)
-				isSurrounded := true
+				group := nanGroup{isSurrounded: true}
 
 				// Use a queue for BFS
 				queue := [][2]int{{r, c}}
 				visited[r][c] = true
-				groupCoords = append(groupCoords, [2]int{r, c})
+				group.coords = append(group.coords, [2]int{r, c})
 
 				for len(queue) > 0 {
 					currR, currC := queue[0][0], queue[0][1]
@@ -102,38 +195,29 @@ func (ip *Interpolator) interpolateDataArea(data [][]InterpolatableData) [][]Int
 
 						// Check bounds
 						if nR < 0 || nR >= rows || nC < 0 || nC >= cols {
-							isSurrounded = false
+							group.isSurrounded = false
 							continue
 						}
 
+						if isMasked(data[nR][nC]) {
+							// land is neither part of a group nor its edge
+							continue
+						}
 						if math.IsNaN(float64(data[nR][nC].Value())) {
 							if !visited[nR][nC] {
 								visited[nR][nC] = true
 								queue = append(queue, [2]int{nR, nC})
-								groupCoords = append(groupCoords, [2]int{nR, nC})
+								group.coords = append(group.coords, [2]int{nR, nC})
 							}
 						} else {
 							// Found a non-NaN neighbor, add its value
-							neighborValues = append(neighborValues, data[nR][nC].Value())
+							group.neighborValues = append(group.neighborValues, data[nR][nC].Value())
 						}
 					}
 				}
-
-				// After exploring the group, check if it's surrounded and has neighbors
-				if isSurrounded && len(neighborValues) > 0 {
-					sum := float32(0.0)
-					for _, val := range neighborValues {
-						sum += val
-					}
-					average := sum / float32(len(neighborValues))
-
-					for _, coord := range groupCoords {
-						data[coord[0]][coord[1]].SetValue(average)
-					}
-				}
+				groups = append(groups, group)
 			}
 		}
 	}
-
-	return data
+	return groups
 }
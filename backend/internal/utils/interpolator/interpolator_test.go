@@ -3,6 +3,7 @@ package interpolator
 import (
 	"log/slog"
 	"math"
+	"ocean-digital-twin/internal/database/models"
 	"testing"
 )
 
@@ -452,3 +453,308 @@ func Test_interpolateDataArea_WithInterface(t *testing.T) {
 		})
 	}
 }
+
+func Test_interpolateDataRow_MarksProvenance(t *testing.T) {
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name     string
+		input    []float32
+		expected []string
+	}{
+		{
+			name:     "Only the filled value is marked",
+			input:    []float32{1, nan, 3},
+			expected: []string{models.ProvenanceObserved, models.ProvenanceTimeInterpolated, models.ProvenanceObserved},
+		},
+		{
+			name:     "Leading NaN is not filled",
+			input:    []float32{nan, 2, 3},
+			expected: []string{models.ProvenanceObserved, models.ProvenanceObserved, models.ProvenanceObserved},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]models.GridData, len(tt.input))
+			inputDataSlice := make([]InterpolatableData, len(tt.input))
+			for i, v := range tt.input {
+				data[i] = models.GridData{Values: []float32{v}, Provenance: models.ProvenanceObserved}
+				inputDataSlice[i] = models.GridValue{
+					Data:       &data[i],
+					Provenance: models.ProvenanceTimeInterpolated,
+					Method:     methodLinear,
+				}
+			}
+
+			interpolator := NewInterpolator(nil, &slog.Logger{})
+			interpolator.interpolateLinearyDataRow(inputDataSlice)
+
+			for i, d := range data {
+				if d.Provenance != tt.expected[i] {
+					t.Errorf("provenance of value %d is %q, want %q", i, d.Provenance, tt.expected[i])
+				}
+				if d.Provenance == models.ProvenanceObserved && d.InterpolationMethod != "" {
+					t.Errorf("observed value %d has interpolation method %q", i, d.InterpolationMethod)
+				}
+			}
+		})
+	}
+}
+
+func Test_interpolateDataIDW(t *testing.T) {
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name     string
+		input    [][]float32
+		power    float64
+		radius   int
+		expected [][]float32
+	}{
+		{
+			name: "Single NaN surrounded by equal data",
+			input: [][]float32{
+				{1, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+			power:  2,
+			radius: 1,
+			expected: [][]float32{
+				{1, 1, 1},
+				{1, 1, 1},
+				{1, 1, 1},
+			},
+		},
+		{
+			name: "Closer values weigh more",
+			input: [][]float32{
+				{0, 0, 0},
+				{0, nan, 4},
+				{0, 0, 0},
+			},
+			power:  2,
+			radius: 2,
+			// orthogonal neighbours weigh 1, diagonal ones 1/2: 4 / (4*1 + 4*0.5)
+			expected: [][]float32{
+				{0, 0, 0},
+				{0, 4.0 / 6.0, 4},
+				{0, 0, 0},
+			},
+		},
+		{
+			name: "Power 0 is a plain average within radius",
+			input: [][]float32{
+				{1, 2, 3},
+				{4, nan, 5},
+				{6, 7, 8},
+			},
+			power:  0,
+			radius: 2,
+			expected: [][]float32{
+				{1, 2, 3},
+				{4, 4.5, 5},
+				{6, 7, 8},
+			},
+		},
+		{
+			name: "Group cells get their own values",
+			input: [][]float32{
+				{0, 0, 0, 0},
+				{0, nan, nan, 10},
+				{0, 0, 0, 0},
+			},
+			power:  1,
+			radius: 1,
+			// diagonal neighbours are outside the radius
+			expected: [][]float32{
+				{0, 0, 0, 0},
+				{0, 0, 10.0 / 3.0, 10},
+				{0, 0, 0, 0},
+			},
+		},
+		{
+			name: "NaN outside the radius remains unfilled",
+			input: [][]float32{
+				{1, 1, 1, 1, 1},
+				{1, nan, nan, nan, 1},
+				{1, nan, nan, nan, 1},
+				{1, nan, nan, nan, 1},
+				{1, 1, 1, 1, 1},
+			},
+			power:  2,
+			radius: 1,
+			expected: [][]float32{
+				{1, 1, 1, 1, 1},
+				{1, 1, 1, 1, 1},
+				{1, 1, nan, 1, 1},
+				{1, 1, 1, 1, 1},
+				{1, 1, 1, 1, 1},
+			},
+		},
+		{
+			name: "NaN group touching the edge (should not be filled)",
+			input: [][]float32{
+				{nan, nan, 1},
+				{nan, 1, 1},
+				{1, 1, 1},
+			},
+			power:  2,
+			radius: 3,
+			expected: [][]float32{
+				{nan, nan, 1},
+				{nan, 1, 1},
+				{1, 1, 1},
+			},
+		},
+		{
+			name:     "Empty input slice",
+			input:    [][]float32{},
+			power:    2,
+			radius:   3,
+			expected: [][]float32{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDataSlice := newMock2DDataSlice(tt.input)
+
+			interpolator := NewInterpolator(nil, &slog.Logger{})
+			result := interpolator.interpolateDataIDW(inputDataSlice, tt.power, tt.radius)
+
+			resultValues := extractValuesFrom2DSlice(result)
+
+			if !are2DFloat32SlicesEqual(resultValues, tt.expected) {
+				t.Errorf("interpolateDataIDW() for input %v resulted in values %v, want %v",
+					tt.input, resultValues, tt.expected)
+			}
+		})
+	}
+}
+
+// Mock implementation of InterpolatableData on land or sea
+type mockMaskedData struct {
+	mockInterpolatableData
+	land bool
+}
+
+func (m *mockMaskedData) Masked() bool {
+	return m.land
+}
+
+func newMockMasked2DDataSlice(values [][]float32, land [][]bool) [][]InterpolatableData {
+	slice := make([][]InterpolatableData, len(values))
+	for i, row := range values {
+		slice[i] = make([]InterpolatableData, len(row))
+		for j, v := range row {
+			slice[i][j] = &mockMaskedData{mockInterpolatableData: mockInterpolatableData{val: v}, land: land[i][j]}
+		}
+	}
+	return slice
+}
+
+func Test_interpolateDataArea_WithLandMask(t *testing.T) {
+	nan := float32(math.NaN())
+
+	tests := []struct {
+		name     string
+		input    [][]float32
+		land     [][]bool
+		expected [][]float32
+	}{
+		{
+			name: "Land cells are not filled",
+			input: [][]float32{
+				{1, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+			land: [][]bool{
+				{false, false, false},
+				{false, true, false},
+				{false, false, false},
+			},
+			expected: [][]float32{
+				{1, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+		},
+		{
+			name: "Gap along the coast is filled",
+			input: [][]float32{
+				{nan, nan, nan, nan},
+				{2, nan, nan, 2},
+				{2, 2, 2, 2},
+			},
+			land: [][]bool{
+				{true, true, true, true},
+				{false, false, false, false},
+				{false, false, false, false},
+			},
+			expected: [][]float32{
+				{nan, nan, nan, nan},
+				{2, 2, 2, 2},
+				{2, 2, 2, 2},
+			},
+		},
+		{
+			name: "Land values are not averaged",
+			input: [][]float32{
+				{9, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+			land: [][]bool{
+				{true, false, false},
+				{false, false, false},
+				{false, false, false},
+			},
+			expected: [][]float32{
+				{9, 1, 1},
+				{1, 1, 1},
+				{1, 1, 1},
+			},
+		},
+		{
+			name: "Gap at the sea edge of the grid is not filled",
+			input: [][]float32{
+				{nan, 1, 1},
+				{nan, nan, 1},
+				{1, 1, 1},
+			},
+			land: [][]bool{
+				{false, false, false},
+				{false, false, false},
+				{false, false, false},
+			},
+			expected: [][]float32{
+				{nan, 1, 1},
+				{nan, nan, 1},
+				{1, 1, 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interpolator := NewInterpolator(nil, &slog.Logger{})
+			for method, fill := range map[string]func([][]InterpolatableData) [][]InterpolatableData{
+				"area": interpolator.interpolateDataArea,
+				"idw": func(data [][]InterpolatableData) [][]InterpolatableData {
+					return interpolator.interpolateDataIDW(data, 0, 5)
+				},
+			} {
+				inputDataSlice := newMockMasked2DDataSlice(tt.input, tt.land)
+				resultValues := extractValuesFrom2DSlice(fill(inputDataSlice))
+
+				if !are2DFloat32SlicesEqual(resultValues, tt.expected) {
+					t.Errorf("%s interpolation for input %v resulted in values %v, want %v",
+						method, tt.input, resultValues, tt.expected)
+				}
+			}
+		})
+	}
+}
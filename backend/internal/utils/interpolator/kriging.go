@@ -0,0 +1,302 @@
+package interpolator
+
+import (
+	"fmt"
+	"math"
+	"ocean-digital-twin/internal/datasets"
+	"sort"
+)
+
+// VarianceData is implemented by InterpolatableData that can store the
+// estimation variance of a value filled by kriging.
+type VarianceData interface {
+	SetVariance(float32)
+}
+
+// variogram is a fitted variogram model: gamma(h) = nugget + partialSill * shape(h / rng).
+type variogram struct {
+	model       datasets.VariogramModel
+	nugget      float64
+	partialSill float64
+	rng         float64
+}
+
+func variogramShape(model datasets.VariogramModel, h float64) float64 {
+	switch model {
+	case datasets.VariogramExponential:
+		// practical range, the model reaches 95% of the sill at h = 1
+		return 1 - math.Exp(-3*h)
+	default:
+		if h >= 1 {
+			return 1
+		}
+		return 1.5*h - 0.5*h*h*h
+	}
+}
+
+func (v variogram) at(h float64) float64 {
+	if h == 0 {
+		return 0
+	}
+	return v.nugget + v.partialSill*variogramShape(v.model, h/v.rng)
+}
+
+// lagBin is a bin of the empirical variogram.
+type lagBin struct {
+	distance     float64
+	semivariance float64
+	pairs        int
+}
+
+// empiricalVariogram returns the semivariance of valid cell pairs binned by their
+// distance in grid cells, rounded to the nearest integer up to maxLag.
+func empiricalVariogram(data [][]InterpolatableData, maxLag int) []lagBin {
+	// offsets in one half plane so every pair is counted once
+	type offset struct {
+		dr, dc   int
+		distance float64
+	}
+	var offsets []offset
+	for dr := 0; dr <= maxLag; dr++ {
+		for dc := -maxLag; dc <= maxLag; dc++ {
+			if dr == 0 && dc <= 0 {
+				continue
+			}
+			distance := math.Hypot(float64(dr), float64(dc))
+			if distance <= float64(maxLag) {
+				offsets = append(offsets, offset{dr, dc, distance})
+			}
+		}
+	}
+
+	sums := make([]float64, maxLag+1)
+	distances := make([]float64, maxLag+1)
+	counts := make([]int, maxLag+1)
+	for r := range data {
+		for c := range data[r] {
+			z := float64(data[r][c].Value())
+			if math.IsNaN(z) || isMasked(data[r][c]) {
+				continue
+			}
+			for _, o := range offsets {
+				nR, nC := r+o.dr, c+o.dc
+				if nR >= len(data) || nC < 0 || nC >= len(data[nR]) {
+					continue
+				}
+				other := float64(data[nR][nC].Value())
+				if math.IsNaN(other) || isMasked(data[nR][nC]) {
+					continue
+				}
+				bin := int(math.Round(o.distance))
+				sums[bin] += (z - other) * (z - other)
+				distances[bin] += o.distance
+				counts[bin]++
+			}
+		}
+	}
+
+	var bins []lagBin
+	for i := range counts {
+		if counts[i] == 0 {
+			continue
+		}
+		bins = append(bins, lagBin{
+			distance:     distances[i] / float64(counts[i]),
+			semivariance: sums[i] / float64(2*counts[i]),
+			pairs:        counts[i],
+		})
+	}
+	return bins
+}
+
+// fitVariogram fits the model to the empirical variogram by weighted least squares,
+// weighting bins by their number of pairs. For each candidate range the nugget and
+// partial sill are solved directly and the range with the smallest error is kept.
+// It returns false if no model with a positive partial sill fits.
+func fitVariogram(bins []lagBin, model datasets.VariogramModel, maxLag int) (variogram, bool) {
+	best := variogram{model: model}
+	bestErr := math.Inf(1)
+	for rng := 0.5; rng <= float64(2*maxLag); rng += 0.5 {
+		var sw, sf, sff, sg, sfg float64
+		for _, b := range bins {
+			w := float64(b.pairs)
+			f := variogramShape(model, b.distance/rng)
+			sw += w
+			sf += w * f
+			sff += w * f * f
+			sg += w * b.semivariance
+			sfg += w * f * b.semivariance
+		}
+		det := sw*sff - sf*sf
+		if det <= 1e-12*sw*sff {
+			// shape is the same at every lag, nugget and sill can't be told apart
+			continue
+		}
+		partialSill := (sw*sfg - sf*sg) / det
+		nugget := (sg - partialSill*sf) / sw
+		if nugget < 0 {
+			nugget = 0
+			partialSill = sfg / sff
+		}
+		if partialSill <= 0 {
+			continue
+		}
+
+		var sse float64
+		for _, b := range bins {
+			residual := b.semivariance - nugget - partialSill*variogramShape(model, b.distance/rng)
+			sse += float64(b.pairs) * residual * residual
+		}
+		if sse < bestErr {
+			bestErr = sse
+			best = variogram{model: model, nugget: nugget, partialSill: partialSill, rng: rng}
+		}
+	}
+	return best, !math.IsInf(bestErr, 1)
+}
+
+// krigingPoint is a valid cell used for an estimate.
+type krigingPoint struct {
+	row, col int
+	value    float64
+	distance float64
+}
+
+// nearestValid returns up to n valid cells within radius of data[r][c], closest first.
+func nearestValid(data [][]InterpolatableData, r, c, radius, n int) []krigingPoint {
+	var points []krigingPoint
+	for nR := max(r-radius, 0); nR <= min(r+radius, len(data)-1); nR++ {
+		for nC := max(c-radius, 0); nC <= min(c+radius, len(data[nR])-1); nC++ {
+			val := float64(data[nR][nC].Value())
+			if math.IsNaN(val) || isMasked(data[nR][nC]) {
+				continue
+			}
+			distance := math.Hypot(float64(nR-r), float64(nC-c))
+			if distance <= float64(radius) {
+				points = append(points, krigingPoint{nR, nC, val, distance})
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].distance < points[j].distance
+	})
+	if len(points) > n {
+		points = points[:n]
+	}
+	return points
+}
+
+// krige returns the ordinary kriging estimate and variance at the cell the points
+// were selected for.
+func krige(v variogram, points []krigingPoint) (float64, float64, bool) {
+	n := len(points)
+	if n == 0 {
+		return 0, 0, false
+	}
+	// [gamma_ij 1; 1 0] [w; mu] = [gamma_i0; 1]
+	a := make([][]float64, n+1)
+	gamma0 := make([]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+2)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			h := math.Hypot(float64(points[i].row-points[j].row), float64(points[i].col-points[j].col))
+			a[i][j] = v.at(h)
+		}
+		a[i][n] = 1
+		a[n][i] = 1
+		gamma0[i] = v.at(points[i].distance)
+		a[i][n+1] = gamma0[i]
+	}
+	a[n][n+1] = 1
+
+	x, ok := solveLinearSystem(a)
+	if !ok {
+		return 0, 0, false
+	}
+	var estimate, variance float64
+	for i := 0; i < n; i++ {
+		estimate += x[i] * points[i].value
+		variance += x[i] * gamma0[i]
+	}
+	variance += x[n]
+	return estimate, math.Max(variance, 0), true
+}
+
+// solveLinearSystem solves the augmented matrix a in place by Gaussian elimination with
+// partial pivoting. It returns false if the system is singular.
+func solveLinearSystem(a [][]float64) ([]float64, bool) {
+	n := len(a)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k <= n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := a[row][n]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, true
+}
+
+// interpolateDataKriging fills surrounded NaN groups like interpolateDataArea using ordinary
+// kriging with a variogram fitted to the valid values of the grid. Each NaN is estimated from
+// its nearest valid cells within the max lag, and the kriging variance is stored on cells
+// implementing VarianceData. It returns an error if no variogram could be fitted.
+func (ip *Interpolator) interpolateDataKriging(data [][]InterpolatableData, p datasets.KrigingParameters) ([][]InterpolatableData, error) {
+	var targets [][2]int
+	for _, group := range findNaNGroups(data) {
+		if group.isSurrounded && len(group.neighborValues) > 0 {
+			targets = append(targets, group.coords...)
+		}
+	}
+	if len(targets) == 0 {
+		return data, nil
+	}
+
+	v, ok := fitVariogram(empiricalVariogram(data, p.MaxLag), p.Model, p.MaxLag)
+	if !ok {
+		return data, fmt.Errorf("could not fit %s variogram", p.Model)
+	}
+
+	type fill struct {
+		row, col int
+		value    float32
+		variance float32
+	}
+	// values are computed first so filled cells don't take part in later estimates
+	var fills []fill
+	for _, coord := range targets {
+		points := nearestValid(data, coord[0], coord[1], p.MaxLag, p.Neighbors)
+		estimate, variance, ok := krige(v, points)
+		if ok {
+			fills = append(fills, fill{coord[0], coord[1], float32(estimate), float32(variance)})
+		}
+	}
+	for _, f := range fills {
+		data[f.row][f.col].SetValue(f.value)
+		if vd, ok := data[f.row][f.col].(VarianceData); ok {
+			vd.SetVariance(f.variance)
+		}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,195 @@
+package interpolator
+
+import (
+	"log/slog"
+	"math"
+	"ocean-digital-twin/internal/datasets"
+	"testing"
+)
+
+// Mock implementation of InterpolatableData that also stores the kriging variance
+type mockVarianceData struct {
+	mockInterpolatableData
+	variance float32
+	hasVar   bool
+}
+
+func (m *mockVarianceData) SetVariance(v float32) {
+	m.variance = v
+	m.hasVar = true
+}
+
+func newMockVarianceGrid(values [][]float32) ([][]InterpolatableData, [][]*mockVarianceData) {
+	slice := make([][]InterpolatableData, len(values))
+	mocks := make([][]*mockVarianceData, len(values))
+	for i, row := range values {
+		slice[i] = make([]InterpolatableData, len(row))
+		mocks[i] = make([]*mockVarianceData, len(row))
+		for j, v := range row {
+			mocks[i][j] = &mockVarianceData{mockInterpolatableData: mockInterpolatableData{val: v}}
+			slice[i][j] = mocks[i][j]
+		}
+	}
+	return slice, mocks
+}
+
+// linearGrid returns a size x size grid with value row + col.
+func linearGrid(size int) [][]float32 {
+	grid := make([][]float32, size)
+	for r := range grid {
+		grid[r] = make([]float32, size)
+		for c := range grid[r] {
+			grid[r][c] = float32(r + c)
+		}
+	}
+	return grid
+}
+
+func Test_fitVariogram(t *testing.T) {
+	tests := []struct {
+		name  string
+		model datasets.VariogramModel
+		want  variogram
+	}{
+		{
+			name:  "Spherical model",
+			model: datasets.VariogramSpherical,
+			want:  variogram{model: datasets.VariogramSpherical, nugget: 0.1, partialSill: 1, rng: 5},
+		},
+		{
+			name:  "Exponential model",
+			model: datasets.VariogramExponential,
+			want:  variogram{model: datasets.VariogramExponential, nugget: 0, partialSill: 2, rng: 7.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bins []lagBin
+			for h := 1; h <= 10; h++ {
+				bins = append(bins, lagBin{distance: float64(h), semivariance: tt.want.at(float64(h)), pairs: 100})
+			}
+
+			got, ok := fitVariogram(bins, tt.model, 10)
+			if !ok {
+				t.Fatal("fitVariogram() could not fit the variogram")
+			}
+			if got.rng != tt.want.rng ||
+				math.Abs(got.nugget-tt.want.nugget) > 1e-6 ||
+				math.Abs(got.partialSill-tt.want.partialSill) > 1e-6 {
+				t.Errorf("fitVariogram() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_interpolateDataKriging(t *testing.T) {
+	nan := float32(math.NaN())
+	params := datasets.KrigingParameters{Model: datasets.VariogramSpherical, MaxLag: 4, Neighbors: 8}
+
+	centerNaN := linearGrid(5)
+	centerNaN[2][2] = nan
+
+	edgeNaN := linearGrid(5)
+	edgeNaN[0][2] = nan
+
+	tests := []struct {
+		name     string
+		input    [][]float32
+		expected [][]float32
+		filled   [][2]int
+		wantErr  bool
+	}{
+		{
+			name:     "NaN in a linear field is estimated exactly",
+			input:    centerNaN,
+			expected: linearGrid(5),
+			filled:   [][2]int{{2, 2}},
+		},
+		{
+			name:     "NaN at the edge (should not be filled)",
+			input:    edgeNaN,
+			expected: edgeNaN,
+		},
+		{
+			name:     "Array with no NaNs",
+			input:    linearGrid(5),
+			expected: linearGrid(5),
+		},
+		{
+			name: "Constant field has no variogram",
+			input: [][]float32{
+				{1, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+			expected: [][]float32{
+				{1, 1, 1},
+				{1, nan, 1},
+				{1, 1, 1},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDataSlice, mocks := newMockVarianceGrid(tt.input)
+
+			interpolator := NewInterpolator(nil, &slog.Logger{})
+			result, err := interpolator.interpolateDataKriging(inputDataSlice, params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("interpolateDataKriging() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			resultValues := extractValuesFrom2DSlice(result)
+			for r := range tt.expected {
+				for c := range tt.expected[r] {
+					want, got := tt.expected[r][c], resultValues[r][c]
+					if math.IsNaN(float64(want)) != math.IsNaN(float64(got)) ||
+						math.Abs(float64(want-got)) > 1e-4 {
+						t.Errorf("interpolateDataKriging() value at %d,%d is %v, want %v", r, c, got, want)
+					}
+				}
+			}
+			for _, cell := range tt.filled {
+				m := mocks[cell[0]][cell[1]]
+				if !m.hasVar || m.variance <= 0 {
+					t.Errorf("expected a positive kriging variance at %v, got %v (set: %t)", cell, m.variance, m.hasVar)
+				}
+			}
+		})
+	}
+}
+
+func Test_interpolateDataKriging_VarianceGrowsWithDistance(t *testing.T) {
+	nan := float32(math.NaN())
+	input := linearGrid(9)
+	// sine keeps the field from being perfectly linear so the variogram has a sill
+	for r := range input {
+		for c := range input[r] {
+			input[r][c] += float32(math.Sin(float64(r * c)))
+		}
+	}
+	for r := 2; r <= 6; r++ {
+		for c := 2; c <= 6; c++ {
+			input[r][c] = nan
+		}
+	}
+
+	inputDataSlice, mocks := newMockVarianceGrid(input)
+	interpolator := NewInterpolator(nil, &slog.Logger{})
+	params := datasets.KrigingParameters{Model: datasets.VariogramExponential, MaxLag: 6, Neighbors: 16}
+	if _, err := interpolator.interpolateDataKriging(inputDataSlice, params); err != nil {
+		t.Fatalf("interpolateDataKriging() returned error: %v", err)
+	}
+
+	border, center := mocks[2][4], mocks[4][4]
+	if !border.hasVar || !center.hasVar {
+		t.Fatal("expected kriging variance on filled cells")
+	}
+	if center.variance <= border.variance {
+		t.Errorf("expected variance in the center of the gap (%v) to exceed the variance at its border (%v)",
+			center.variance, border.variance)
+	}
+}
@@ -0,0 +1,177 @@
+package interpolator
+
+import (
+	"errors"
+	"math"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+)
+
+// Components of a vectorPoint exposed through vectorComponent.
+const (
+	componentU = iota
+	componentV
+	componentSpeed
+)
+
+// vectorPoint holds a working copy of the vector of a grid cell while its components
+// and speed are interpolated.
+type vectorPoint struct {
+	u, v, speed float32
+	variance    float32
+	hasVariance bool
+	land        bool
+}
+
+func newVectorPoint(d *models.GridData, ui, vi int) *vectorPoint {
+	u, v := d.Values[ui], d.Values[vi]
+	return &vectorPoint{u: u, v: v, speed: float32(math.Hypot(float64(u), float64(v))), land: d.Land}
+}
+
+// vectorComponent exposes a single component of a vectorPoint so it can be interpolated on its own.
+type vectorComponent struct {
+	p         *vectorPoint
+	component int
+}
+
+func (c vectorComponent) Value() float32 {
+	switch c.component {
+	case componentU:
+		return c.p.u
+	case componentV:
+		return c.p.v
+	default:
+		return c.p.speed
+	}
+}
+
+func (c vectorComponent) SetValue(val float32) {
+	switch c.component {
+	case componentU:
+		c.p.u = val
+	case componentV:
+		c.p.v = val
+	default:
+		c.p.speed = val
+	}
+}
+
+func (c vectorComponent) Masked() bool {
+	return c.p.land
+}
+
+func (c vectorComponent) SetVariance(variance float32) {
+	if !c.p.hasVariance || c.p.variance < variance {
+		c.p.variance = variance
+		c.p.hasVariance = true
+	}
+}
+
+// vector returns the filled vector. When both components were missing the direction
+// is taken from the interpolated components and the length from the interpolated speed.
+func (p *vectorPoint) vector(bothMissing bool) (float32, float32) {
+	u, v := p.u, p.v
+	if !bothMissing || math.IsNaN(float64(p.speed)) {
+		return u, v
+	}
+	length := math.Hypot(float64(u), float64(v))
+	if length == 0 {
+		return u, v
+	}
+	scale := float64(p.speed) / length
+	return float32(float64(u) * scale), float32(float64(v) * scale)
+}
+
+// vectorIndexes returns the indexes of the vector components of the dataset if they
+// are interpolated as a vector.
+func vectorIndexes(ds *datasets.Dataset) (int, int, bool) {
+	if ds.Vector == nil || ds.Vector.Interpolation != datasets.VectorSpeedDirection {
+		return 0, 0, false
+	}
+	return ds.ColumnIndex(ds.Vector.U), ds.ColumnIndex(ds.Vector.V), true
+}
+
+// scalarVariables returns the indexes of the dataset variables interpolated one by one.
+func scalarVariables(ds *datasets.Dataset) []int {
+	ui, vi, vector := vectorIndexes(ds)
+	var variables []int
+	for v := range ds.Variables {
+		if vector && (v == ui || v == vi) {
+			continue
+		}
+		variables = append(variables, v)
+	}
+	return variables
+}
+
+// applyVector writes the filled vector of p to the missing components of d.
+func applyVector(d *models.GridData, p *vectorPoint, ui, vi int, provenance, method string) {
+	uMissing := math.IsNaN(float64(d.Values[ui]))
+	vMissing := math.IsNaN(float64(d.Values[vi]))
+	if !uMissing && !vMissing {
+		return
+	}
+	u, v := p.vector(uMissing && vMissing)
+	for _, c := range []struct {
+		index   int
+		value   float32
+		missing bool
+	}{{ui, u, uMissing}, {vi, v, vMissing}} {
+		if !c.missing || math.IsNaN(float64(c.value)) {
+			continue
+		}
+		g := models.GridValue{Data: d, Index: c.index, Provenance: provenance, Method: method}
+		g.SetValue(c.value)
+		if p.hasVariance {
+			g.SetVariance(p.variance)
+		}
+	}
+}
+
+// interpolateVectorRow runs fill on the u, v and speed series of the vector at a single
+// location and writes the filled vectors to data.
+func interpolateVectorRow(data []models.GridData, ui, vi int, provenance, method string, fill func([]InterpolatableData)) {
+	points := make([]*vectorPoint, len(data))
+	for i := range data {
+		points[i] = newVectorPoint(&data[i], ui, vi)
+	}
+	for _, component := range []int{componentU, componentV, componentSpeed} {
+		slice := make([]InterpolatableData, len(points))
+		for i, p := range points {
+			slice[i] = vectorComponent{p: p, component: component}
+		}
+		fill(slice)
+	}
+	for i, p := range points {
+		applyVector(&data[i], p, ui, vi, provenance, method)
+	}
+}
+
+// interpolateVectorGrid runs fill on the u, v and speed grids of the vector at a single
+// timestamp and writes the filled vectors to data. Components fill fails on remain missing.
+func interpolateVectorGrid(data [][]models.GridData, ui, vi int, provenance, method string, fill func([][]InterpolatableData) error) error {
+	points := make([][]*vectorPoint, len(data))
+	for row := range data {
+		points[row] = make([]*vectorPoint, len(data[row]))
+		for col := range data[row] {
+			points[row][col] = newVectorPoint(&data[row][col], ui, vi)
+		}
+	}
+	var errs []error
+	for _, component := range []int{componentU, componentV, componentSpeed} {
+		grid := make([][]InterpolatableData, len(points))
+		for row := range points {
+			grid[row] = make([]InterpolatableData, len(points[row]))
+			for col, p := range points[row] {
+				grid[row][col] = vectorComponent{p: p, component: component}
+			}
+		}
+		errs = append(errs, fill(grid))
+	}
+	for row := range points {
+		for col, p := range points[row] {
+			applyVector(&data[row][col], p, ui, vi, provenance, method)
+		}
+	}
+	return errors.Join(errs...)
+}
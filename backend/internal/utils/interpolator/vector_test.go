@@ -0,0 +1,145 @@
+package interpolator
+
+import (
+	"log/slog"
+	"math"
+	"ocean-digital-twin/internal/database/models"
+	"testing"
+)
+
+func newVectorGridData(vectors [][2]float32) []models.GridData {
+	data := make([]models.GridData, len(vectors))
+	for i, vec := range vectors {
+		data[i] = models.GridData{Values: []float32{vec[0], vec[1]}, Provenance: models.ProvenanceObserved}
+	}
+	return data
+}
+
+func areVectorsEqual(data []models.GridData, expected [][2]float32) bool {
+	values := make([]float32, 0, 2*len(data))
+	want := make([]float32, 0, 2*len(expected))
+	for i := range data {
+		values = append(values, data[i].Values...)
+		want = append(want, expected[i][0], expected[i][1])
+	}
+	return areFloat32SlicesEqual(values, want)
+}
+
+func Test_interpolateVectorRow(t *testing.T) {
+	nan := float32(math.NaN())
+	diagonal := float32(math.Sqrt2 / 2)
+
+	tests := []struct {
+		name     string
+		input    [][2]float32
+		expected [][2]float32
+	}{
+		{
+			name:     "Speed is kept when direction changes",
+			input:    [][2]float32{{1, 0}, {nan, nan}, {0, 1}},
+			expected: [][2]float32{{1, 0}, {diagonal, diagonal}, {0, 1}},
+		},
+		{
+			name:  "Speed is interpolated with direction",
+			input: [][2]float32{{2, 0}, {nan, nan}, {nan, nan}, {0, 4}},
+			// components give the directions (1, 1) and (1, 4), speed the lengths 8/3 and 10/3
+			expected: [][2]float32{
+				{2, 0},
+				{8.0 / 3 * diagonal, 8.0 / 3 * diagonal},
+				{float32(10.0 / 3 / math.Sqrt(17)), float32(40.0 / 3 / math.Sqrt(17))},
+				{0, 4},
+			},
+		},
+		{
+			name:     "Opposite vectors cancel out",
+			input:    [][2]float32{{1, 0}, {nan, nan}, {-1, 0}},
+			expected: [][2]float32{{1, 0}, {0, 0}, {-1, 0}},
+		},
+		{
+			name:     "Single missing component is interpolated on its own",
+			input:    [][2]float32{{1, 1}, {nan, 5}, {3, 1}},
+			expected: [][2]float32{{1, 1}, {2, 5}, {3, 1}},
+		},
+		{
+			name:     "Unbounded gap remains unfilled",
+			input:    [][2]float32{{nan, nan}, {1, 0}, {0, 1}},
+			expected: [][2]float32{{nan, nan}, {1, 0}, {0, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := newVectorGridData(tt.input)
+
+			interpolator := NewInterpolator(nil, &slog.Logger{})
+			interpolateVectorRow(data, 0, 1, models.ProvenanceTimeInterpolated, methodLinear, func(row []InterpolatableData) {
+				interpolator.interpolateLinearyDataRow(row)
+			})
+
+			if !areVectorsEqual(data, tt.expected) {
+				t.Errorf("interpolateVectorRow() for input %v resulted in %v, want %v", tt.input, data, tt.expected)
+			}
+			for i := range data {
+				filled := math.IsNaN(float64(tt.input[i][0])) && !math.IsNaN(float64(tt.expected[i][0]))
+				if filled && data[i].Provenance != models.ProvenanceTimeInterpolated {
+					t.Errorf("provenance of vector %d is %q, want %q", i, data[i].Provenance, models.ProvenanceTimeInterpolated)
+				}
+			}
+		})
+	}
+}
+
+func Test_interpolateVectorGrid(t *testing.T) {
+	nan := float32(math.NaN())
+	// vectors of length 2 rotating around the center
+	input := [][][2]float32{
+		{{2, 0}, {2, 0}, {0, -2}},
+		{{0, 2}, {nan, nan}, {0, -2}},
+		{{0, 2}, {-2, 0}, {-2, 0}},
+	}
+	data := make([][]models.GridData, len(input))
+	for row := range input {
+		data[row] = newVectorGridData(input[row])
+	}
+
+	interpolator := NewInterpolator(nil, &slog.Logger{})
+	err := interpolateVectorGrid(data, 0, 1, models.ProvenanceAreaInterpolated, methodBorderAverage, func(grid [][]InterpolatableData) error {
+		interpolator.interpolateDataArea(grid)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("interpolateVectorGrid() returned error: %v", err)
+	}
+
+	// the components average out to zero, so there is no direction to scale
+	center := data[1][1]
+	if !areFloat32SlicesEqual(center.Values, []float32{0, 0}) {
+		t.Errorf("expected center vector %v, got %v", []float32{0, 0}, center.Values)
+	}
+	if center.Provenance != models.ProvenanceAreaInterpolated || center.InterpolationMethod != methodBorderAverage {
+		t.Errorf("expected center to be marked %q/%q, got %q/%q", models.ProvenanceAreaInterpolated,
+			methodBorderAverage, center.Provenance, center.InterpolationMethod)
+	}
+
+	// vectors mostly pointing east keep their length
+	input = [][][2]float32{
+		{{2, 0}, {2, 0}, {2, 0}},
+		{{0, 2}, {nan, nan}, {2, 0}},
+		{{2, 0}, {2, 0}, {0, -2}},
+	}
+	for row := range input {
+		data[row] = newVectorGridData(input[row])
+	}
+	err = interpolateVectorGrid(data, 0, 1, models.ProvenanceAreaInterpolated, methodBorderAverage, func(grid [][]InterpolatableData) error {
+		interpolator.interpolateDataArea(grid)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("interpolateVectorGrid() returned error: %v", err)
+	}
+	center = data[1][1]
+	speed := math.Hypot(float64(center.Values[0]), float64(center.Values[1]))
+	if math.Abs(speed-2) > 1e-6 {
+		t.Errorf("expected center speed 2, got %v (%v)", speed, center.Values)
+	}
+}
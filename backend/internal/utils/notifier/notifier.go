@@ -0,0 +1,250 @@
+// Package notifier posts signed JSON payloads of events to the webhooks subscribed to them
+// and records every delivery.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+)
+
+const (
+	// defaultMaxAttempts of a delivery before it is given up
+	defaultMaxAttempts = 4
+	// defaultBackoff before the second attempt, doubled after every further one
+	defaultBackoff = 2 * time.Second
+	// requestTimeout of a single attempt
+	requestTimeout = 10 * time.Second
+	// queueSize is the number of events waiting for delivery, further events are dropped
+	queueSize = 64
+)
+
+// Payload is the body posted to the webhooks.
+type Payload struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// notification is an event waiting for delivery with its encoded payload.
+type notification struct {
+	event string
+	body  []byte
+}
+
+type Notifier struct {
+	db          database.Service
+	logger      *slog.Logger
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan notification
+	// ctx of the deliveries, independent of the callers and cancelled by Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNotifier returns a notifier delivering events in the background until Shutdown.
+func NewNotifier(db database.Service, logger *slog.Logger) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		db:          db,
+		logger:      logger,
+		client:      &http.Client{Timeout: requestTimeout},
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		queue:       make(chan notification, queueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Notify queues data as event for delivery to every webhook subscribed to it and returns
+// immediately, so slow or dead receivers can't hold up the caller. Failures are logged and
+// recorded in the delivery history, they are never returned so they can't interrupt the
+// caller. Events are dropped if the queue is full or the notifier is shut down.
+func (n *Notifier) Notify(event string, data any) {
+	body, err := json.Marshal(Payload{Event: event, CreatedAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		n.logger.Error("Failed to encode webhook payload", "event", event, "err", err)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		n.logger.Warn("Notifier is shut down, dropping event", "event", event)
+		return
+	}
+	select {
+	case n.queue <- notification{event: event, body: body}:
+	default:
+		n.logger.Error("Webhook queue is full, dropping event", "event", event)
+	}
+}
+
+// Shutdown stops accepting events and waits for the queued ones to be delivered. If ctx
+// ends first, the remaining deliveries are cancelled and the error of ctx is returned.
+func (n *Notifier) Shutdown(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-n.done
+		return ctx.Err()
+	}
+}
+
+// run delivers the queued events one after another until the queue is closed.
+func (n *Notifier) run() {
+	defer close(n.done)
+	defer n.cancel()
+	for m := range n.queue {
+		if n.ctx.Err() != nil {
+			n.logger.Warn("Notifier shut down, dropping event", "event", m.event)
+			continue
+		}
+		n.notify(n.ctx, m.event, m.body)
+	}
+}
+
+// notify posts body as event to every webhook subscribed to it and returns once every
+// delivery succeeded or was given up.
+func (n *Notifier) notify(ctx context.Context, event string, body []byte) {
+	webhooks, err := n.db.GetWebhooksForEvent(ctx, event)
+	if err != nil {
+		n.logger.Error("Failed to get webhooks", "event", event, "err", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, webhook := range webhooks {
+		wg.Add(1)
+		go func(webhook models.Webhook) {
+			defer wg.Done()
+			n.deliver(ctx, webhook, event, body)
+		}(webhook)
+	}
+	wg.Wait()
+}
+
+// deliver posts body to webhook until it is accepted, it's rejected with a status retrying
+// won't change or the attempts run out, updating the delivery record after every attempt.
+func (n *Notifier) deliver(ctx context.Context, webhook models.Webhook, event string, body []byte) {
+	delivery, err := n.db.CreateWebhookDelivery(ctx, webhook.ID, event, body)
+	if err != nil {
+		n.logger.Error("Failed to record webhook delivery", "webhook", webhook.ID, "event", event, "err", err)
+		return
+	}
+
+	wait := n.backoff
+	for delivery.Attempts < n.maxAttempts {
+		if delivery.Attempts > 0 {
+			select {
+			case <-time.After(wait):
+				wait *= 2
+			case <-ctx.Done():
+				return
+			}
+		}
+		delivery.Attempts++
+		status, postErr := n.post(ctx, webhook, delivery.ID, event, body)
+		delivery.StatusCode, delivery.Error = status, ""
+		if postErr != nil {
+			delivery.Error = postErr.Error()
+		} else {
+			now := time.Now().UTC()
+			delivery.DeliveredAt = &now
+		}
+		if err := n.db.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			n.logger.Error("Failed to update webhook delivery", "delivery", delivery.ID, "err", err)
+		}
+		if postErr == nil || !retryable(status) {
+			break
+		}
+	}
+	if delivery.DeliveredAt == nil {
+		n.logger.Warn("Webhook delivery failed", "webhook", webhook.ID, "delivery", delivery.ID, "event", event,
+			"attempts", delivery.Attempts, "err", delivery.Error)
+	}
+}
+
+// post makes a single attempt at delivering body and returns the status code of the
+// response, 0 if none was received. Responses outside of 2xx are returned as errors.
+func (n *Notifier) post(ctx context.Context, webhook models.Webhook, deliveryID int, event string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ocean-digital-twin-webhooks")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(deliveryID))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(webhook.Secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether an attempt answered with status, 0 for no response, may succeed
+// when repeated.
+func retryable(status int) bool {
+	return status == 0 || status >= 500 ||
+		status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and body joined by a dot, keyed
+// with secret. Receivers verify the X-Webhook-Signature header by computing it themselves.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random hex encoded secret of 32 bytes.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,249 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+)
+
+// fakeDB keeps the webhooks and deliveries in memory, the remaining methods panic.
+type fakeDB struct {
+	database.Service
+	mu         sync.Mutex
+	webhooks   []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (db *fakeDB) GetWebhooksForEvent(_ context.Context, event string) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, w := range db.webhooks {
+		for _, e := range w.Events {
+			if e == event {
+				webhooks = append(webhooks, w)
+			}
+		}
+	}
+	return webhooks, nil
+}
+
+func (db *fakeDB) CreateWebhookDelivery(_ context.Context, webhookID int, event string, payload []byte) (models.WebhookDelivery, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	d := models.WebhookDelivery{ID: len(db.deliveries) + 1, WebhookID: webhookID, Event: event, Payload: payload}
+	db.deliveries = append(db.deliveries, d)
+	return d, nil
+}
+
+func (db *fakeDB) UpdateWebhookDelivery(_ context.Context, d models.WebhookDelivery) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.deliveries[d.ID-1] = d
+	return nil
+}
+
+func newTestNotifier(db database.Service) *Notifier {
+	n := NewNotifier(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	n.backoff = time.Millisecond
+	return n
+}
+
+// notifyAndWait notifies event and waits for its deliveries to finish.
+func notifyAndWait(t *testing.T, n *Notifier, event string, data any) {
+	t.Helper()
+	n.Notify(event, data)
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned error: %v", err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	var requests []received
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{r.Header, body})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	db := &fakeDB{webhooks: []models.Webhook{
+		{ID: 1, URL: receiver.URL, Events: []string{models.EventIngestionFailed}, Secret: "s3cret"},
+		{ID: 2, URL: receiver.URL, Events: []string{models.EventBloomDetected}, Secret: "other"},
+	}}
+	failure := models.IngestionFailure{Dataset: "chlorophyll", Stage: "download", Error: "timeout"}
+	notifyAndWait(t, newTestNotifier(db), models.EventIngestionFailed, failure)
+
+	if len(requests) != 1 {
+		t.Fatalf("expected only the subscribed webhook to be notified; got %d requests", len(requests))
+	}
+	req := requests[0]
+	if got := req.header.Get("X-Webhook-Event"); got != models.EventIngestionFailed {
+		t.Errorf("expected event header %q; got %q", models.EventIngestionFailed, got)
+	}
+	if got := req.header.Get("X-Webhook-Delivery"); got != "1" {
+		t.Errorf("expected delivery header 1; got %q", got)
+	}
+	timestamp := req.header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("expected a Unix timestamp; got %q", timestamp)
+	}
+	if got, want := req.header.Get("X-Webhook-Signature"), "sha256="+Sign("s3cret", timestamp, req.body); got != want {
+		t.Errorf("expected signature %q; got %q", want, got)
+	}
+
+	var payload struct {
+		Event     string                  `json:"event"`
+		CreatedAt time.Time               `json:"created_at"`
+		Data      models.IngestionFailure `json:"data"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("error decoding payload. Err: %v", err)
+	}
+	if payload.Event != models.EventIngestionFailed || payload.Data != failure || payload.CreatedAt.IsZero() {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	d := db.deliveries[0]
+	if d.WebhookID != 1 || d.Attempts != 1 || d.StatusCode != http.StatusNoContent || d.Error != "" || d.DeliveredAt == nil {
+		t.Errorf("expected a delivered record; got %+v", d)
+	}
+	if string(d.Payload) != string(req.body) {
+		t.Errorf("expected the delivery to record the payload; got %s", d.Payload)
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		attempts   int
+		delivered  bool
+		lastStatus int
+	}{
+		{"Recovers after server errors", []int{503, 500, 200}, 3, true, 200},
+		{"Retries rate limits", []int{429, 202}, 2, true, 202},
+		{"Gives up after max attempts", []int{502, 502, 502, 502, 502}, 4, false, 502},
+		{"Doesn't retry client errors", []int{404, 200}, 1, false, 404},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer receiver.Close()
+
+			db := &fakeDB{webhooks: []models.Webhook{{ID: 1, URL: receiver.URL, Events: models.WebhookEvents}}}
+			notifyAndWait(t, newTestNotifier(db), models.EventDataIngested, models.IngestionRun{ID: 7})
+
+			d := db.deliveries[0]
+			if calls != tt.attempts || d.Attempts != tt.attempts {
+				t.Errorf("expected %d attempts; got %d requests and %d recorded", tt.attempts, calls, d.Attempts)
+			}
+			if (d.DeliveredAt != nil) != tt.delivered {
+				t.Errorf("expected delivered %t; got %+v", tt.delivered, d)
+			}
+			if d.StatusCode != tt.lastStatus {
+				t.Errorf("expected last status %d; got %d", tt.lastStatus, d.StatusCode)
+			}
+			if tt.delivered == (d.Error != "") {
+				t.Errorf("expected an error only for failed deliveries; got %q", d.Error)
+			}
+		})
+	}
+
+	t.Run("Unreachable receiver", func(t *testing.T) {
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		db := &fakeDB{webhooks: []models.Webhook{{ID: 1, URL: receiver.URL, Events: models.WebhookEvents}}}
+		n := newTestNotifier(db)
+		n.maxAttempts = 2
+		notifyAndWait(t, n, models.EventDataIngested, nil)
+
+		d := db.deliveries[0]
+		if d.Attempts != 2 || d.StatusCode != 0 || d.Error == "" || d.DeliveredAt != nil {
+			t.Errorf("expected 2 failed attempts without a response; got %+v", d)
+		}
+	})
+}
+
+func TestNotifyAsync(t *testing.T) {
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	db := &fakeDB{webhooks: []models.Webhook{{ID: 1, URL: receiver.URL, Events: models.WebhookEvents}}}
+	n := newTestNotifier(db)
+	n.maxAttempts = 1
+
+	start := time.Now()
+	n.Notify(models.EventDataIngested, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Notify to return without waiting for the receiver; took %v", elapsed)
+	}
+
+	// the hanging delivery is cancelled once the shutdown times out
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := n.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected the shutdown to time out; got %v", err)
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(db.deliveries) != 1 || db.deliveries[0].DeliveredAt != nil {
+		t.Errorf("expected a single undelivered delivery; got %+v", db.deliveries)
+	}
+
+	// events after the shutdown are dropped
+	n.Notify(models.EventDataIngested, nil)
+	if len(db.deliveries) != 1 {
+		t.Errorf("expected no delivery after the shutdown; got %d", len(db.deliveries))
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	want := "b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign("secret", "1700000000", []byte("{}")); got != want {
+		t.Errorf("expected signature %q; got %q", want, got)
+	}
+	if Sign("secret", "1700000000", []byte("{}")) == Sign("other", "1700000000", []byte("{}")) {
+		t.Error("expected the signature to depend on the secret")
+	}
+	if Sign("secret", "1700000000", []byte("{}")) == Sign("secret", "1700000001", []byte("{}")) {
+		t.Error("expected the signature to depend on the timestamp")
+	}
+
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatalf("error generating secret. Err: %v", err)
+	}
+	b, _ := NewSecret()
+	if len(a) != 64 || a == b {
+		t.Errorf("expected distinct 64 character secrets; got %q and %q", a, b)
+	}
+}
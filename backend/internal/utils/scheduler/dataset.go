@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+	"time"
+)
+
+// maxBackfill limits how far back data is downloaded when a dataset is empty or outdated.
+const maxBackfill = 30 * 24 * time.Hour
+
+func (u *Updater) updateDatasetData(ctx context.Context, ds *datasets.Dataset) {
+	u.logger.Info("Starting data update", "dataset", ds.Name)
+
+	if err := u.db.CleanupDatasetData(ctx, ds); err != nil {
+		u.logger.Error("Failed to clean up old data", "dataset", ds.Name, "err", err)
+	}
+
+	latestTime, err := u.db.GetLatestDatasetTimestamp(ctx, ds)
+	if err != nil {
+		u.logger.Error("Failed to get latest timestamp", "dataset", ds.Name, "error", err)
+	}
+	startTime := latestTime
+	// if start time is older than the backfill window set it to the window start
+	if time.Since(startTime) > maxBackfill {
+		startTime = time.Now().UTC().Add(-maxBackfill)
+	}
+
+	endTime, err := u.downloader.GetLatestDataTime(ctx, ds.ERDDAPID)
+	if err != nil {
+		u.logger.Error("Coudnt get latest time from ERDDAP", "dataset", ds.Name)
+		u.notifyFailure(ds, "latest_time", err)
+		return
+	}
+
+	if !startTime.Before(endTime) {
+		u.logger.Info("Latest timestamp of data in db is after or equal the latest timestamp available in erddap - no data to update", "dataset", ds.Name)
+		return
+	}
+
+	data, err := u.downloader.DownloadDatasetData(ctx, ds, startTime, endTime)
+	if err != nil {
+		u.logger.Error("Failed to download data", "dataset", ds.Name, "err", err)
+		u.notifyFailure(ds, "download", err)
+		return
+	}
+
+	if len(data) == 0 {
+		u.logger.Info("No new data available", "dataset", ds.Name)
+		return
+	}
+
+	run, err := u.db.IngestDatasetData(ctx, ds, data)
+	if err != nil {
+		u.logger.Error("Failed to save data", "dataset", ds.Name, "err", err)
+		u.notifyFailure(ds, "save", err)
+		return
+	}
+	u.logger.Info("Data update completed", "dataset", ds.Name, "updated_points", run.Points, "run", run.ID)
+	u.notifier.Notify(models.EventDataIngested, run)
+}
+
+// notifyFailure notifies the webhooks that the update of a dataset failed at stage.
+func (u *Updater) notifyFailure(ds *datasets.Dataset, stage string, err error) {
+	u.notifier.Notify(models.EventIngestionFailed, models.IngestionFailure{
+		Dataset: ds.Name,
+		Stage:   stage,
+		Error:   err.Error(),
+	})
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"log/slog"
 	"ocean-digital-twin/internal/database"
+	"ocean-digital-twin/internal/database/models"
+	"ocean-digital-twin/internal/datasets"
+	"ocean-digital-twin/internal/utils/climatology"
+	"ocean-digital-twin/internal/utils/detector"
 	"ocean-digital-twin/internal/utils/erddap"
 	"ocean-digital-twin/internal/utils/interpolator"
+	"ocean-digital-twin/internal/utils/notifier"
 	"time"
 )
 
@@ -13,6 +18,9 @@ type Updater struct {
 	db           database.Service
 	downloader   *erddap.Downloader
 	interpolator *interpolator.Interpolator
+	detector     *detector.Detector
+	climatology  *climatology.Builder
+	notifier     *notifier.Notifier
 	logger       *slog.Logger
 	interval     time.Duration
 	minLat       float64
@@ -26,11 +34,15 @@ func NewUpdater(
 	logger *slog.Logger,
 	interval time.Duration,
 	minLat, minLon, maxLat, maxLon float64,
+	bloomRules detector.Config,
 ) *Updater {
 	return &Updater{
 		db:           db,
 		downloader:   erddap.NewDownloader(logger, minLat, minLon, maxLat, maxLon),
 		interpolator: interpolator.NewInterpolator(db, logger),
+		detector:     detector.NewDetector(db, logger, bloomRules),
+		climatology:  climatology.NewBuilder(db, logger),
+		notifier:     notifier.NewNotifier(db, logger),
 		logger:       logger,
 		interval:     interval,
 		minLat:       minLat,
@@ -56,12 +68,27 @@ func (u *Updater) Start(ctx context.Context) {
 	}
 }
 
-func (u *Updater) update(ctx context.Context) {
-	u.updateChlorophyllData(ctx)
-	u.interpolator.RunChlorophyllInterpolationBasedOnArea(ctx)
-	u.interpolator.RunLinearChlorophyllInterpolationBasedOnTime(ctx)
+// Shutdown waits for the webhook deliveries of past updates until ctx ends, see
+// notifier.Notifier.Shutdown.
+func (u *Updater) Shutdown(ctx context.Context) error {
+	return u.notifier.Shutdown(ctx)
+}
 
-	u.updateCurrentsData(ctx)
-	u.interpolator.RunCurrentsInterpolationBasedOnArea(ctx)
-	u.interpolator.RunLinearCurrentsInterpolationBasedOnTime(ctx)
+func (u *Updater) update(ctx context.Context) {
+	for _, ds := range datasets.All() {
+		u.updateDatasetData(ctx, ds)
+		u.interpolator.RunDatasetInterpolation(ctx, ds)
+		if err := u.climatology.RunClimatologyUpdate(ctx, ds); err != nil {
+			u.logger.Error("Climatology update failed", "dataset", ds.Name, "err", err)
+		}
+	}
+	// blooms are detected on the interpolated data
+	events, err := u.detector.RunBloomDetection(ctx)
+	if err != nil {
+		u.logger.Error("Bloom detection failed", "err", err)
+	}
+	// events saved before a failure are still reported
+	if len(events) > 0 {
+		u.notifier.Notify(models.EventBloomDetected, models.BloomEventsToGeoJSON(events))
+	}
 }
@@ -3,6 +3,9 @@ import { GeoJSON } from 'geojson'
 export interface ChlorophyllFeatureProperties {
   id: number
   measurement_time: string
+  provenance: string
+  interpolation_method?: string
+  interpolation_variance?: number
   chlor_a: number
 }
 
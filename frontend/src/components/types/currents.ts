@@ -3,6 +3,9 @@ import { GeoJSON } from 'geojson'
 export interface CurrentsFeatureProperties {
   id: number
   measurement_time: string
+  provenance: string
+  interpolation_method?: string
+  interpolation_variance?: number
   v_current: number
   u_current: number
   current_angle: number